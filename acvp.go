@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/acvp"
+	"os"
+	"strings"
+)
+
+type acvpCommand struct {
+	Request  string `short:"i" long:"input" description:"ACVP request (prompt) JSON file" required:"yes"`
+	Response string `short:"o" long:"output" description:"Response JSON file or '-' to stdout" default:"-"`
+	Expected string `short:"e" long:"expected" description:"Expected-answer JSON file to compare the response with"`
+}
+
+func init() {
+	parser.AddCommand(
+		"acvp",
+		"Runs an ACVP vector set offline",
+		"Reads an ACVP request JSON file, runs its test cases and writes the response JSON, optionally comparing it with an expected-answer file. Supported algorithms: "+strings.Join(acvp.Algorithms(), ", ")+".",
+		&acvpCommand{},
+	)
+}
+
+func readVectorSet(path string) (*acvp.VectorSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return acvp.Read(f)
+}
+
+func (c *acvpCommand) Execute(args []string) error {
+	request, err := readVectorSet(c.Request)
+	if err != nil {
+		return err
+	}
+	response, err := acvp.Process(request)
+	if err != nil {
+		return err
+	}
+	out := os.Stdout
+	if c.Response != "-" {
+		if out, err = os.Create(c.Response); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	if err := acvp.Write(out, response); err != nil {
+		return err
+	}
+	if c.Expected == "" {
+		return nil
+	}
+	expected, err := readVectorSet(c.Expected)
+	if err != nil {
+		return err
+	}
+	diffs := acvp.Compare(response, expected)
+	for _, diff := range diffs {
+		fmt.Fprintln(os.Stderr, diff)
+	}
+	if len(diffs) > 0 {
+		return errors.New("The response differs from the expected answers")
+	}
+	fmt.Fprintln(os.Stderr, "The response matches the expected answers")
+	return nil
+}
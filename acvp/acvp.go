@@ -0,0 +1,615 @@
+// Package acvp runs NIST ACVP vector sets offline. It reads the request (prompt) JSON of a vector set,
+// computes each test case with the implementations of this module and builds the response JSON, which
+// can be compared with an expected-answer file.
+//
+// The supported algorithms are ACVP-AES-ECB and ACVP-AES-CBC, with the functional (AFT) and Monte Carlo
+// (MCT) tests, ACVP-AES-CTR, ACVP-AES-GCM with external IVs, ACVP-AES-CCM, ACVP-AES-KW and ACVP-AES-KWP
+// with the forward and inverse ciphers, ACVP-AES-XTS and CMAC-AES. Other algorithms are rejected.
+package acvp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/cavp"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"github.com/emanuelzabka/crypt-aes/modes/cbc"
+	"github.com/emanuelzabka/crypt-aes/modes/ccm"
+	"github.com/emanuelzabka/crypt-aes/modes/cmac"
+	"github.com/emanuelzabka/crypt-aes/modes/ctr"
+	"github.com/emanuelzabka/crypt-aes/modes/ecb"
+	"github.com/emanuelzabka/crypt-aes/modes/gcm"
+	"github.com/emanuelzabka/crypt-aes/modes/kw"
+	"github.com/emanuelzabka/crypt-aes/modes/xts"
+	"io"
+	"sort"
+)
+
+// VectorSet is a request, response or expected-answer vector set
+type VectorSet struct {
+	VsID       int         `json:"vsId"`
+	Algorithm  string      `json:"algorithm,omitempty"`
+	Mode       string      `json:"mode,omitempty"`
+	Revision   string      `json:"revision,omitempty"`
+	IsSample   bool        `json:"isSample,omitempty"`
+	TestGroups []TestGroup `json:"testGroups"`
+}
+
+// TestGroup is a group of test cases sharing the test type, direction and lengths in bits of the key,
+// IV, payload, additional data, tag or MAC
+type TestGroup struct {
+	TgID       int        `json:"tgId"`
+	TestType   string     `json:"testType,omitempty"`
+	Direction  string     `json:"direction,omitempty"`
+	KeyLen     int        `json:"keyLen,omitempty"`
+	IVLen      int        `json:"ivLen,omitempty"`
+	IVGen      string     `json:"ivGen,omitempty"`
+	PayloadLen int        `json:"payloadLen,omitempty"`
+	AADLen     int        `json:"aadLen,omitempty"`
+	TagLen     int        `json:"tagLen,omitempty"`
+	KWCipher   string     `json:"kwCipher,omitempty"`
+	TweakMode  string     `json:"tweakMode,omitempty"`
+	MsgLen     int        `json:"msgLen,omitempty"`
+	MACLen     int        `json:"macLen,omitempty"`
+	Tests      []TestCase `json:"tests"`
+}
+
+// TestCase is a test case with hexadecimal values. TestPassed is set in the responses of the tests
+// checking a tag or a wrapped key, and SequenceNumber and TweakValue hold the XTS tweak.
+type TestCase struct {
+	TcID           int         `json:"tcId"`
+	TestPassed     *bool       `json:"testPassed,omitempty"`
+	Key            string      `json:"key,omitempty"`
+	IV             string      `json:"iv,omitempty"`
+	PT             string      `json:"pt,omitempty"`
+	CT             string      `json:"ct,omitempty"`
+	AAD            string      `json:"aad,omitempty"`
+	Tag            string      `json:"tag,omitempty"`
+	Message        string      `json:"message,omitempty"`
+	MAC            string      `json:"mac,omitempty"`
+	TweakValue     string      `json:"tweakValue,omitempty"`
+	SequenceNumber *uint64     `json:"sequenceNumber,omitempty"`
+	ResultsArray   []MCTResult `json:"resultsArray,omitempty"`
+}
+
+// MCTResult is an outer iteration of a Monte Carlo test. IV is only set for CBC.
+type MCTResult struct {
+	Key string `json:"key"`
+	IV  string `json:"iv,omitempty"`
+	PT  string `json:"pt"`
+	CT  string `json:"ct"`
+}
+
+// testHandler computes the response test case of a request test case of the group
+type testHandler func(group *TestGroup, test *TestCase) (*TestCase, error)
+
+// handlers are the supported algorithms
+var handlers = map[string]testHandler{
+	"ACVP-AES-ECB": aesBlockMode("ECB"),
+	"ACVP-AES-CBC": aesBlockMode("CBC"),
+	"ACVP-AES-CTR": aesBlockMode("CTR"),
+	"ACVP-AES-GCM": aesGCM,
+	"ACVP-AES-CCM": aesCCM,
+	"ACVP-AES-KW":  aesKW(false),
+	"ACVP-AES-KWP": aesKW(true),
+	"ACVP-AES-XTS": aesXTS,
+	"CMAC-AES":     cmacAES,
+}
+
+// Algorithms returns the names of the supported algorithms
+func Algorithms() []string {
+	names := make([]string, 0, len(handlers))
+	for name := range handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Read decodes a vector set, either alone or in the [{"acvVersion": ...}, {vector set}] envelope of
+// the ACVP files
+func Read(r io.Reader) (*VectorSet, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			var probe map[string]json.RawMessage
+			if json.Unmarshal(item, &probe) == nil && probe["testGroups"] != nil {
+				data = item
+				break
+			}
+		}
+	}
+	vs := new(VectorSet)
+	if err := json.Unmarshal(data, vs); err != nil {
+		return nil, err
+	}
+	if vs.TestGroups == nil {
+		return nil, errors.New("Invalid vector set. No testGroups found")
+	}
+	return vs, nil
+}
+
+// Write encodes the vector set in the ACVP envelope
+func Write(w io.Writer, vs *VectorSet) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode([]interface{}{map[string]string{"acvVersion": "1.0"}, vs})
+}
+
+// Process runs the test cases of a request vector set and returns the response
+func Process(request *VectorSet) (*VectorSet, error) {
+	handler, ok := handlers[request.Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("Algorithm %s is not implemented. Supported: %v", request.Algorithm, Algorithms())
+	}
+	response := &VectorSet{VsID: request.VsID, Algorithm: request.Algorithm, Mode: request.Mode, Revision: request.Revision}
+	for i := range request.TestGroups {
+		group := &request.TestGroups[i]
+		result := TestGroup{TgID: group.TgID}
+		for j := range group.Tests {
+			test, err := handler(group, &group.Tests[j])
+			if err != nil {
+				return nil, fmt.Errorf("tgId %d tcId %d: %s", group.TgID, group.Tests[j].TcID, err.Error())
+			}
+			result.Tests = append(result.Tests, *test)
+		}
+		response.TestGroups = append(response.TestGroups, result)
+	}
+	return response, nil
+}
+
+// Compare returns the differences of a response from the expected answers
+func Compare(response, expected *VectorSet) []string {
+	var diffs []string
+	results := make(map[[2]int]TestCase)
+	for _, group := range response.TestGroups {
+		for _, test := range group.Tests {
+			results[[2]int{group.TgID, test.TcID}] = test
+		}
+	}
+	for _, group := range expected.TestGroups {
+		for _, want := range group.Tests {
+			got, ok := results[[2]int{group.TgID, want.TcID}]
+			if !ok {
+				diffs = append(diffs, fmt.Sprintf("tgId %d tcId %d: missing", group.TgID, want.TcID))
+				continue
+			}
+			wantJSON, _ := json.Marshal(want)
+			gotJSON, _ := json.Marshal(got)
+			if !bytes.Equal(wantJSON, gotJSON) {
+				diffs = append(diffs, fmt.Sprintf("tgId %d tcId %d: expected %s got %s", group.TgID, want.TcID, wantJSON, gotJSON))
+			}
+		}
+	}
+	return diffs
+}
+
+// decrypting returns whether the group decrypts, validating its direction
+func decrypting(group *TestGroup) (bool, error) {
+	switch group.Direction {
+	case "encrypt":
+		return false, nil
+	case "decrypt":
+		return true, nil
+	}
+	return false, fmt.Errorf("Invalid direction %s", group.Direction)
+}
+
+// input returns the decoded plaintext of the test, or its ciphertext on decryption
+func input(test *TestCase, decrypt bool) ([]byte, error) {
+	if decrypt {
+		return hex.DecodeString(test.CT)
+	}
+	return hex.DecodeString(test.PT)
+}
+
+// setOutput sets the ciphertext of the response, or its plaintext on decryption
+func setOutput(out *TestCase, output []byte, decrypt bool) {
+	if decrypt {
+		out.PT = hex.EncodeToString(output)
+	} else {
+		out.CT = hex.EncodeToString(output)
+	}
+}
+
+// failed returns the response of a test whose tag or wrapped key is rejected
+func failed(test *TestCase) *TestCase {
+	passed := false
+	return &TestCase{TcID: test.TcID, TestPassed: &passed}
+}
+
+// newCipher creates the AES cipher of the hexadecimal key
+func newCipher(key string) (*aes.AESCipher, error) {
+	k, err := hex.DecodeString(key)
+	if err != nil {
+		return nil, err
+	}
+	return aes.NewCipher(k)
+}
+
+// newBlockMode creates the ECB, CBC or CTR mode with the key and IV
+func newBlockMode(mode string, key, iv []byte) (modes.CipherMode, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	switch mode {
+	case "CBC":
+		cbcMode, err := cbc.NewMode(c, iv)
+		if err != nil {
+			return nil, err
+		}
+		return cbcMode, nil
+	case "CTR":
+		ctrMode, err := ctr.NewMode(c, iv)
+		if err != nil {
+			return nil, err
+		}
+		return ctrMode, nil
+	}
+	return ecb.NewMode(c), nil
+}
+
+// aesBlockMode returns the handler of ACVP-AES-ECB, ACVP-AES-CBC or ACVP-AES-CTR. The Monte Carlo tests
+// are run for ECB and CBC, and the payload of CTR may end with a partial block.
+func aesBlockMode(mode string) testHandler {
+	return func(group *TestGroup, test *TestCase) (*TestCase, error) {
+		return blockModeTest(mode, group, test)
+	}
+}
+
+// blockModeTest computes a test of the ECB, CBC or CTR mode
+func blockModeTest(mode string, group *TestGroup, test *TestCase) (*TestCase, error) {
+	decrypt, err := decrypting(group)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(test.Key)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := hex.DecodeString(test.IV)
+	if err != nil {
+		return nil, err
+	}
+	data, err := input(test, decrypt)
+	if err != nil {
+		return nil, err
+	}
+	out := &TestCase{TcID: test.TcID}
+	switch group.TestType {
+	case "AFT", "CTR":
+		// The payloads are unpadded, so they are processed by block and not through modes.Reader
+		if mode != "CTR" && len(data)%16 != 0 {
+			return nil, modes.ErrInvalidLength
+		}
+		cipher, err := newBlockMode(mode, key, iv)
+		if err != nil {
+			return nil, err
+		}
+		output := make([]byte, len(data)+15)
+		copy(output, data)
+		for i := 0; i < len(data); i += 16 {
+			if decrypt {
+				cipher.Decrypt(output[i:i+16], output[i:i+16])
+			} else {
+				cipher.Encrypt(output[i:i+16], output[i:i+16])
+			}
+		}
+		setOutput(out, output[:len(data)], decrypt)
+	case "MCT":
+		if mode == "CTR" {
+			return nil, errors.New("Unsupported test type MCT")
+		}
+		if len(data) != 16 {
+			return nil, errors.New("Invalid Monte Carlo block length")
+		}
+		if out.ResultsArray, err = monteCarlo(mode, key, iv, data, decrypt); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported test type %s", group.TestType)
+	}
+	return out, nil
+}
+
+// monteCarlo runs the 100 outer iterations of the ECB or CBC Monte Carlo test, using the same iteration
+// rules as the AESAVS: the next key is XORed with the last outputs, and for CBC the next IV is the last
+// output and the next input the one before it
+func monteCarlo(mode string, key, iv, input []byte, decrypt bool) ([]MCTResult, error) {
+	results := make([]MCTResult, 100)
+	for i := range results {
+		cipher, err := newBlockMode(mode, key, iv)
+		if err != nil {
+			return nil, err
+		}
+		process := cipher.Encrypt
+		if decrypt {
+			process = cipher.Decrypt
+		}
+		results[i] = MCTResult{Key: hex.EncodeToString(key), PT: hex.EncodeToString(input)}
+		var last, previous []byte
+		if mode == "CBC" {
+			results[i].IV = hex.EncodeToString(iv)
+			outputs := cavp.MonteCarloChained(process, iv, input, 128)
+			last, previous = outputs[999], outputs[998]
+			iv, input = last, previous
+		} else {
+			last, previous = cavp.MonteCarloECB(process, input)
+			input = last
+		}
+		results[i].CT = hex.EncodeToString(last)
+		if decrypt {
+			results[i].PT, results[i].CT = results[i].CT, results[i].PT
+		}
+		key = cavp.NextMonteCarloKey(key, append(previous, last...))
+	}
+	return results, nil
+}
+
+// aead is the interface of the GCM and CCM modes
+type aead interface {
+	Seal(nonce, plaintext, additionalData []byte) ([]byte, error)
+	Open(nonce, ciphertext, additionalData []byte) ([]byte, error)
+}
+
+// runAEAD seals the plaintext of the test or opens its ciphertext, which ends with the tag, returning a
+// failed test if the tag is rejected
+func runAEAD(mode aead, test *TestCase, ciphertext []byte, decrypt bool) (*TestCase, error) {
+	iv, err := hex.DecodeString(test.IV)
+	if err != nil {
+		return nil, err
+	}
+	aad, err := hex.DecodeString(test.AAD)
+	if err != nil {
+		return nil, err
+	}
+	out := &TestCase{TcID: test.TcID}
+	if decrypt {
+		plaintext, err := mode.Open(iv, ciphertext, aad)
+		if err == modes.ErrAuthentication {
+			return failed(test), nil
+		} else if err != nil {
+			return nil, err
+		}
+		out.PT = hex.EncodeToString(plaintext)
+		return out, nil
+	}
+	plaintext, err := hex.DecodeString(test.PT)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := mode.Seal(iv, plaintext, aad)
+	if err != nil {
+		return nil, err
+	}
+	out.CT = hex.EncodeToString(sealed)
+	return out, nil
+}
+
+// aesGCM computes a test of ACVP-AES-GCM, whose responses have the tag apart from the ciphertext. Only
+// the IVs given by the request are supported.
+func aesGCM(group *TestGroup, test *TestCase) (*TestCase, error) {
+	decrypt, err := decrypting(group)
+	if err != nil {
+		return nil, err
+	}
+	if group.IVGen != "" && group.IVGen != "external" {
+		return nil, fmt.Errorf("Unsupported IV generation %s", group.IVGen)
+	}
+	c, err := newCipher(test.Key)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := gcm.NewMode(c, group.TagLen/8)
+	if err != nil {
+		return nil, err
+	}
+	var ciphertext []byte
+	if decrypt {
+		if ciphertext, err = hex.DecodeString(test.CT + test.Tag); err != nil {
+			return nil, err
+		}
+	}
+	out, err := runAEAD(mode, test, ciphertext, decrypt)
+	if err != nil || decrypt {
+		return out, err
+	}
+	out.Tag = out.CT[len(out.CT)-group.TagLen/4:]
+	out.CT = out.CT[:len(out.CT)-group.TagLen/4]
+	return out, nil
+}
+
+// aesCCM computes a test of ACVP-AES-CCM, whose ciphertexts end with the tag
+func aesCCM(group *TestGroup, test *TestCase) (*TestCase, error) {
+	decrypt, err := decrypting(group)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newCipher(test.Key)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := ccm.NewMode(c, group.TagLen/8)
+	if err != nil {
+		return nil, err
+	}
+	var ciphertext []byte
+	if decrypt {
+		if ciphertext, err = hex.DecodeString(test.CT); err != nil {
+			return nil, err
+		}
+	}
+	return runAEAD(mode, test, ciphertext, decrypt)
+}
+
+// inverseCipher swaps the encryption and decryption of a cipher, for the key wraps built on the
+// inverse cipher
+type inverseCipher struct {
+	modes.Cipher
+}
+
+// Encrypt decrypts the block with the wrapped cipher
+func (c inverseCipher) Encrypt(block, dest []byte) {
+	c.Cipher.Decrypt(block, dest)
+}
+
+// Decrypt encrypts the block with the wrapped cipher
+func (c inverseCipher) Decrypt(block, dest []byte) {
+	c.Cipher.Encrypt(block, dest)
+}
+
+// aesKW returns the handler of ACVP-AES-KW, or of ACVP-AES-KWP with pad. The decryption tests fail when
+// the unwrapping is rejected.
+func aesKW(pad bool) testHandler {
+	return func(group *TestGroup, test *TestCase) (*TestCase, error) {
+		return keyWrapTest(pad, group, test)
+	}
+}
+
+// keyWrapTest computes a test of KW or KWP, with the forward or inverse cipher
+func keyWrapTest(pad bool, group *TestGroup, test *TestCase) (*TestCase, error) {
+	decrypt, err := decrypting(group)
+	if err != nil {
+		return nil, err
+	}
+	aesCipher, err := newCipher(test.Key)
+	if err != nil {
+		return nil, err
+	}
+	var c modes.Cipher = aesCipher
+	switch group.KWCipher {
+	case "", "cipher":
+	case "inverse":
+		c = inverseCipher{c}
+	default:
+		return nil, fmt.Errorf("Invalid kwCipher %s", group.KWCipher)
+	}
+	data, err := input(test, decrypt)
+	if err != nil {
+		return nil, err
+	}
+	out := &TestCase{TcID: test.TcID}
+	var output []byte
+	switch {
+	case decrypt && pad:
+		output, err = kw.UnwrapPad(c, data)
+	case decrypt:
+		output, err = kw.Unwrap(c, data)
+	case pad:
+		output, err = kw.WrapPad(c, data)
+	default:
+		output, err = kw.Wrap(c, data)
+	}
+	if err == modes.ErrAuthentication {
+		return failed(test), nil
+	} else if err != nil {
+		return nil, err
+	}
+	setOutput(out, output, decrypt)
+	return out, nil
+}
+
+// aesXTS computes a test of ACVP-AES-XTS. The key is the data key followed by the tweak key and the
+// tweak is a hexadecimal value or a data unit sequence number, as told by the tweak mode.
+func aesXTS(group *TestGroup, test *TestCase) (*TestCase, error) {
+	decrypt, err := decrypting(group)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(test.Key)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 && len(key) != 64 {
+		return nil, errors.New("Invalid XTS key length")
+	}
+	c, err := aes.NewCipher(key[:len(key)/2])
+	if err != nil {
+		return nil, err
+	}
+	tweakCipher, err := aes.NewCipher(key[len(key)/2:])
+	if err != nil {
+		return nil, err
+	}
+	mode, err := xts.NewMode(c, tweakCipher)
+	if err != nil {
+		return nil, err
+	}
+	var tweak []byte
+	switch group.TweakMode {
+	case "hex":
+		if tweak, err = hex.DecodeString(test.TweakValue); err != nil {
+			return nil, err
+		}
+	case "number":
+		if test.SequenceNumber == nil {
+			return nil, errors.New("Missing sequenceNumber")
+		}
+		tweak = xts.SequenceTweak(*test.SequenceNumber)
+	default:
+		return nil, fmt.Errorf("Invalid tweakMode %s", group.TweakMode)
+	}
+	data, err := input(test, decrypt)
+	if err != nil {
+		return nil, err
+	}
+	output := make([]byte, len(data))
+	if decrypt {
+		err = mode.DecryptUnit(tweak, data, output)
+	} else {
+		err = mode.EncryptUnit(tweak, data, output)
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := &TestCase{TcID: test.TcID}
+	setOutput(out, output, decrypt)
+	return out, nil
+}
+
+// cmacAES computes a test of CMAC-AES: the MAC of the message truncated to macLen bits on generation,
+// and whether the MAC is accepted on verification
+func cmacAES(group *TestGroup, test *TestCase) (*TestCase, error) {
+	c, err := newCipher(test.Key)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := cmac.New(c)
+	if err != nil {
+		return nil, err
+	}
+	message, err := hex.DecodeString(test.Message)
+	if err != nil {
+		return nil, err
+	}
+	out := &TestCase{TcID: test.TcID}
+	switch group.Direction {
+	case "gen":
+		if group.MACLen <= 0 || group.MACLen > 128 || group.MACLen%8 != 0 {
+			return nil, fmt.Errorf("Invalid macLen %d", group.MACLen)
+		}
+		out.MAC = hex.EncodeToString(mac.Sum(message)[:group.MACLen/8])
+	case "ver":
+		tag, err := hex.DecodeString(test.MAC)
+		if err != nil {
+			return nil, err
+		}
+		passed := mac.Verify(message, tag) == nil
+		out.TestPassed = &passed
+	default:
+		return nil, fmt.Errorf("Invalid direction %s", group.Direction)
+	}
+	return out, nil
+}
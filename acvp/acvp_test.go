@@ -0,0 +1,89 @@
+package acvp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) *VectorSet {
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Error opening fixture: %s", err.Error())
+	}
+	defer f.Close()
+	vs, err := Read(f)
+	if err != nil {
+		t.Fatalf("Error reading fixture %s: %s", name, err.Error())
+	}
+	return vs
+}
+
+func TestFixtures(t *testing.T) {
+	for _, algorithm := range Algorithms() {
+		request := readFixture(t, algorithm+"-prompt.json")
+		expected := readFixture(t, algorithm+"-expectedResults.json")
+		response, err := Process(request)
+		if err != nil {
+			t.Fatalf("Error processing %s: %s", algorithm, err.Error())
+		}
+		for _, diff := range Compare(response, expected) {
+			t.Errorf("%s: %s", algorithm, diff)
+		}
+		// the written response reads back to the same results
+		var buffer bytes.Buffer
+		if err := Write(&buffer, response); err != nil {
+			t.Fatalf("Error writing response: %s", err.Error())
+		}
+		written, err := Read(&buffer)
+		if err != nil {
+			t.Fatalf("Error reading written response: %s", err.Error())
+		}
+		if diffs := Compare(written, expected); len(diffs) != 0 {
+			t.Errorf("Invalid written response: %v", diffs)
+		}
+	}
+}
+
+func TestCompareMismatch(t *testing.T) {
+	expected := readFixture(t, "ACVP-AES-ECB-expectedResults.json")
+	response := readFixture(t, "ACVP-AES-ECB-expectedResults.json")
+	response.TestGroups[0].Tests[0].CT = "00000000000000000000000000000000"
+	response.TestGroups[1].Tests = response.TestGroups[1].Tests[1:]
+	if diffs := Compare(response, expected); len(diffs) != 2 {
+		t.Errorf("Invalid differences. Expected 2 Got: %v", diffs)
+	}
+}
+
+func TestUnsupportedAlgorithm(t *testing.T) {
+	request, err := Read(strings.NewReader(`{"vsId": 2, "algorithm": "ACVP-AES-FF1", "revision": "1.0", "testGroups": []}`))
+	if err != nil {
+		t.Fatalf("Error reading request: %s", err.Error())
+	}
+	if _, err := Process(request); err == nil {
+		t.Errorf("Accepting unsupported algorithm")
+	}
+	if _, err := Read(strings.NewReader(`[{"acvVersion": "1.0"}]`)); err == nil {
+		t.Errorf("Accepting file without vector set")
+	}
+}
+
+func TestUnsupportedParameters(t *testing.T) {
+	for _, request := range []string{
+		`{"algorithm": "ACVP-AES-GCM", "testGroups": [{"tgId": 1, "direction": "encrypt", "ivGen": "internal", "tagLen": 128, "tests": [{"tcId": 1, "key": "00000000000000000000000000000000"}]}]}`,
+		`{"algorithm": "ACVP-AES-XTS", "testGroups": [{"tgId": 1, "direction": "encrypt", "tweakMode": "other", "tests": [{"tcId": 1, "key": "0000000000000000000000000000000000000000000000000000000000000000"}]}]}`,
+		`{"algorithm": "ACVP-AES-CTR", "testGroups": [{"tgId": 1, "direction": "encrypt", "testType": "MCT", "tests": [{"tcId": 1, "key": "00000000000000000000000000000000", "iv": "00000000000000000000000000000000", "pt": "00000000000000000000000000000000"}]}]}`,
+		`{"algorithm": "ACVP-AES-CBC", "testGroups": [{"tgId": 1, "direction": "encrypt", "testType": "AFT", "tests": [{"tcId": 1, "key": "00000000000000000000000000000000", "iv": "00000000000000000000000000000000", "pt": "00"}]}]}`,
+		`{"algorithm": "CMAC-AES", "testGroups": [{"tgId": 1, "direction": "gen", "macLen": 136, "tests": [{"tcId": 1, "key": "00000000000000000000000000000000"}]}]}`,
+	} {
+		vs, err := Read(strings.NewReader(request))
+		if err != nil {
+			t.Fatalf("Error reading request: %s", err.Error())
+		}
+		if _, err := Process(vs); err == nil {
+			t.Errorf("Accepting request %s", request)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package aes
 import (
 	"encoding/binary"
 	"errors"
+	"github.com/emanuelzabka/crypt-aes/gf256"
+	"github.com/emanuelzabka/crypt-aes/internal/fault"
 )
 
 // AESCipher is the base structure for encryption/decryption operations using the package
@@ -11,6 +13,12 @@ type AESCipher struct {
 	numRounds    int
 	key          []byte
 	expandedKeys [][]byte
+	sBox         []byte
+	invSBox      []byte
+	tracer       Tracer
+	injector     fault.Injector
+	faultCheck   int
+	shared       bool
 }
 
 var sBoxMatrix []byte = []byte{
@@ -62,21 +70,26 @@ var rCon []uint32 = []uint32{
 	0x80000000,
 	0x1b000000,
 	0x36000000,
+	0x6c000000,
+	0xd8000000,
+	0xab000000,
+	0x4d000000,
 }
 
 func subBytes(state []byte) {
-	for i := range state {
-		row := state[i] >> 4
-		col := state[i] & 0x0f
-		state[i] = sBoxMatrix[row*16+col]
-	}
+	substitute(state, sBoxMatrix)
 }
 
 func invSubBytes(state []byte) {
+	substitute(state, invSBoxMatrix)
+}
+
+// substitute replaces each byte of state by its entry in the substitution table
+func substitute(state, table []byte) {
 	for i := range state {
 		row := state[i] >> 4
 		col := state[i] & 0x0f
-		state[i] = invSBoxMatrix[row*16+col]
+		state[i] = table[row*16+col]
 	}
 }
 
@@ -102,21 +115,10 @@ func invShiftRows(state []byte) {
 	}
 }
 
-// gfMul return GF(2^8) Galois Field (finite field) multiplication using a modified version of peasant's algorithm
+// gfMul returns the GF(2^8) Galois Field (finite field) multiplication. It runs on the secret state,
+// so it uses the constant-time multiplication instead of the log/exp tables.
 func gfMul(a, b byte) byte {
-	var prod byte = 0
-	for a != 0 && b != 0 {
-		if b&1 != 0 {
-			prod ^= a
-		}
-		b >>= 1
-		carry := a & 0x80
-		a <<= 1
-		if carry != 0 {
-			a ^= 0x1b
-		}
-	}
-	return prod
+	return gf256.MulCT(a, b)
 }
 
 func mixColumns(state []byte) {
@@ -157,10 +159,15 @@ func addRoundKey(state, key []byte) {
 }
 
 func subWord(word uint32) uint32 {
+	return substituteWord(word, sBoxMatrix)
+}
+
+// substituteWord applies the substitution table to each byte of word
+func substituteWord(word uint32, table []byte) uint32 {
 	var buffer []byte = make([]byte, 4)
 	var result uint32
 	binary.BigEndian.PutUint32(buffer, word)
-	subBytes(buffer)
+	substitute(buffer, table)
 	result = binary.BigEndian.Uint32(buffer)
 	return result
 }
@@ -184,9 +191,13 @@ func getNumRounds(keyLength int) int {
 }
 
 func keyExpansion(key, expandedKeys []byte, keyLength int) {
+	expandKey(key, expandedKeys, keyLength, getNumRounds(keyLength), sBoxMatrix)
+}
+
+// expandKey runs the key expansion generating numRounds+1 round keys using the substitution table sBox
+func expandKey(key, expandedKeys []byte, keyLength, numRounds int, sBox []byte) {
 	var temp uint32
 	var wordKeys [15 * 4]uint32
-	var numRounds int = getNumRounds(keyLength)
 	i := 0
 	for i < keyLength {
 		wordKeys[i] = binary.BigEndian.Uint32(key[4*i : (4*i + 4)])
@@ -196,9 +207,9 @@ func keyExpansion(key, expandedKeys []byte, keyLength int) {
 	for i < 4*(numRounds+1) {
 		temp = wordKeys[i-1]
 		if i%keyLength == 0 {
-			temp = subWord(rotWord(temp)) ^ rCon[i/keyLength-1]
+			temp = substituteWord(rotWord(temp), sBox) ^ rCon[i/keyLength-1]
 		} else if keyLength > 6 && i%keyLength == 4 {
-			temp = subWord(temp)
+			temp = substituteWord(temp, sBox)
 		}
 		wordKeys[i] = wordKeys[i-keyLength] ^ temp
 		i++
@@ -213,25 +224,100 @@ func extractRoundKey(keys []byte, round int) []byte {
 }
 
 // NewCipher creates and returns a new cipher using the key specified
-// Allowed key lengths: 16, 25 and 32 bytes
+// Allowed key lengths: 16, 24 and 32 bytes
+// The known-answer self test runs on the first call, and the constructor fails if it did not pass.
 func NewCipher(key []byte) (*AESCipher, error) {
-	var err error = nil
-	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
-		err = errors.New("Invalid key length. Allowed lengths: 128-bit (16 bytes), 192-bit (24 bytes), 256-bit (32 bytes)")
+	if err := checkKeyLength(key); err != nil {
 		return nil, err
 	}
+	if err := powerOnSelfTest(); err != nil {
+		return nil, err
+	}
+	return NewCipherWithRounds(key, getNumRounds(len(key)/4))
+}
+
+// NewCipherWithRounds creates a cipher using a custom number of rounds (1 to 14) for cryptanalysis
+// experiments. The key schedule is extended or truncated to the number of rounds and the last round
+// omits MixColumns as in the standard cipher. It must not be used to protect real data.
+func NewCipherWithRounds(key []byte, rounds int) (*AESCipher, error) {
+	if err := checkKeyLength(key); err != nil {
+		return nil, err
+	}
+	if rounds < 1 || rounds > 14 {
+		return nil, errors.New("Invalid number of rounds. Allowed: 1 to 14")
+	}
+	return newCipher(key, rounds, sBoxMatrix, invSBoxMatrix), nil
+}
+
+// NewCipherWithSBox creates a Rijndael-structured cipher replacing the AES S-box by sBox, both in the
+// rounds and in the key schedule. sBox must be a bijective table of 256 entries. It is meant for
+// experiments and must not be used to protect real data.
+func NewCipherWithSBox(key, sBox []byte) (*AESCipher, error) {
+	if err := checkKeyLength(key); err != nil {
+		return nil, err
+	}
+	invSBox, err := InvertSBox(sBox)
+	if err != nil {
+		return nil, err
+	}
+	table := make([]byte, 256)
+	copy(table, sBox)
+	return newCipher(key, getNumRounds(len(key)/4), table, invSBox), nil
+}
+
+func checkKeyLength(key []byte) error {
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return errors.New("Invalid key length. Allowed lengths: 128-bit (16 bytes), 192-bit (24 bytes), 256-bit (32 bytes)")
+	}
+	return nil
+}
+
+func newCipher(key []byte, rounds int, sBox, invSBox []byte) *AESCipher {
 	cipher := new(AESCipher)
 	cipher.key = make([]byte, len(key))
 	copy(cipher.key, key)
 	cipher.keyLength = len(key) / 4
-	cipher.numRounds = getNumRounds(cipher.keyLength)
-	expandedKeys := make([]byte, (cipher.numRounds+1)*cipher.keyLength*4)
-	keyExpansion(cipher.key, expandedKeys, cipher.keyLength)
+	cipher.numRounds = rounds
+	cipher.sBox = sBox
+	cipher.invSBox = invSBox
+	expandedKeys := make([]byte, (cipher.numRounds+1)*16)
+	expandKey(cipher.key, expandedKeys, cipher.keyLength, cipher.numRounds, cipher.sBox)
 	cipher.expandedKeys = make([][]byte, cipher.numRounds+1)
 	for i := 0; i < cipher.numRounds+1; i++ {
 		cipher.expandedKeys[i] = extractRoundKey(expandedKeys, i)
 	}
-	return cipher, err
+	return cipher
+}
+
+// SBox returns the S-box substitution of b
+func SBox(b byte) byte {
+	return sBoxMatrix[b]
+}
+
+// InvSBox returns the inverse S-box substitution of b
+func InvSBox(b byte) byte {
+	return invSBoxMatrix[b]
+}
+
+// SetKey replaces the key of the cipher by another one of the same length, expanding it in place over
+// the current round keys without allocating. It is meant for searches testing many keys.
+// It panics on ciphers returned by a Cache.
+func (c *AESCipher) SetKey(key []byte) error {
+	if c.shared {
+		panic("Cached ciphers cannot be changed.")
+	}
+	if len(key) != len(c.key) {
+		return errors.New("Invalid key length. The new key must have the length of the current one")
+	}
+	copy(c.key, key)
+	// The round keys are slices of a single schedule, see newCipher
+	expandKey(c.key, c.expandedKeys[0][:(c.numRounds+1)*16], c.keyLength, c.numRounds, c.sBox)
+	return nil
+}
+
+// Rounds returns the number of rounds used by the cipher
+func (c *AESCipher) Rounds() int {
+	return c.numRounds
 }
 
 // Encrypt encrypt a block of data
@@ -240,16 +326,28 @@ func NewCipher(key []byte) (*AESCipher, error) {
 func (c *AESCipher) Encrypt(block, dest []byte) {
 	var state []byte = make([]byte, 16)
 	copy(state, block)
+	c.hook(0, "input", state)
 	addRoundKey(state, c.expandedKeys[0])
+	c.hook(0, "k_sch", c.expandedKeys[0])
 	for r := 1; r < c.numRounds; r++ {
-		subBytes(state)
+		c.hook(r, "start", state)
+		substitute(state, c.sBox)
+		c.hook(r, "s_box", state)
 		shiftRows(state)
+		c.hook(r, "s_row", state)
 		mixColumns(state)
+		c.hook(r, "m_col", state)
 		addRoundKey(state, c.expandedKeys[r])
+		c.hook(r, "k_sch", c.expandedKeys[r])
 	}
-	subBytes(state)
+	c.hook(c.numRounds, "start", state)
+	substitute(state, c.sBox)
+	c.hook(c.numRounds, "s_box", state)
 	shiftRows(state)
+	c.hook(c.numRounds, "s_row", state)
 	addRoundKey(state, c.expandedKeys[c.numRounds])
+	c.hook(c.numRounds, "k_sch", c.expandedKeys[c.numRounds])
+	c.hook(c.numRounds, "output", state)
 	copy(dest, state)
 }
 
@@ -259,16 +357,29 @@ func (c *AESCipher) Encrypt(block, dest []byte) {
 func (c *AESCipher) Decrypt(block, dest []byte) {
 	var state []byte = make([]byte, 16)
 	copy(state, block)
+	c.hook(0, "iinput", state)
 	addRoundKey(state, c.expandedKeys[c.numRounds])
+	c.hook(0, "ik_sch", c.expandedKeys[c.numRounds])
 	for i := c.numRounds - 1; i > 0; i-- {
+		r := c.numRounds - i
+		c.hook(r, "istart", state)
 		invShiftRows(state)
-		invSubBytes(state)
+		c.hook(r, "is_row", state)
+		substitute(state, c.invSBox)
+		c.hook(r, "is_box", state)
 		addRoundKey(state, c.expandedKeys[i])
+		c.hook(r, "ik_sch", c.expandedKeys[i])
+		c.hook(r, "ik_add", state)
 		invMixColumns(state)
 	}
+	c.hook(c.numRounds, "istart", state)
 	invShiftRows(state)
-	invSubBytes(state)
+	c.hook(c.numRounds, "is_row", state)
+	substitute(state, c.invSBox)
+	c.hook(c.numRounds, "is_box", state)
 	addRoundKey(state, c.expandedKeys[0])
+	c.hook(c.numRounds, "ik_sch", c.expandedKeys[0])
+	c.hook(c.numRounds, "ioutput", state)
 	copy(dest, state)
 }
 
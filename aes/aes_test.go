@@ -1,8 +1,11 @@
 package aes
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"github.com/emanuelzabka/crypt-aes/modes/ciphertest"
 	"testing"
 )
 
@@ -434,3 +437,108 @@ func TestDecryption(t *testing.T) {
 		}
 	}
 }
+
+func TestNewCipherWithRoundsInvalidRounds(t *testing.T) {
+	key := make([]byte, 16)
+	for _, rounds := range []int{-1, 0, 15} {
+		_, err := NewCipherWithRounds(key, rounds)
+		if err == nil {
+			t.Errorf("Accepting invalid number of rounds %d", rounds)
+		}
+	}
+}
+
+func TestNewCipherWithRoundsStandard(t *testing.T) {
+	var keys [][]byte = [][]byte{make([]byte, 16), make([]byte, 24), make([]byte, 32)}
+	rounds := []int{10, 12, 14}
+	block := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	for i := range keys {
+		standard, _ := NewCipher(keys[i])
+		custom, err := NewCipherWithRounds(keys[i], rounds[i])
+		if err != nil {
+			t.Errorf("Error creating cipher with %d rounds: %s", rounds[i], err.Error())
+			continue
+		}
+		expected := make([]byte, 16)
+		res := make([]byte, 16)
+		standard.Encrypt(block, expected)
+		custom.Encrypt(block, res)
+		if !bytes.Equal(expected, res) {
+			t.Errorf("Invalid encryption with %d rounds. Expected: 0x%s Got: 0x%s",
+				rounds[i], hex.EncodeToString(expected), hex.EncodeToString(res))
+		}
+	}
+}
+
+func TestNewCipherWithRoundsRoundTrip(t *testing.T) {
+	block := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	for _, length := range []int{16, 24, 32} {
+		for rounds := 1; rounds <= 14; rounds++ {
+			cipher, err := NewCipherWithRounds(make([]byte, length), rounds)
+			if err != nil {
+				t.Errorf("Error creating cipher with %d rounds: %s", rounds, err.Error())
+				continue
+			}
+			encrypted := make([]byte, 16)
+			decrypted := make([]byte, 16)
+			cipher.Encrypt(block, encrypted)
+			cipher.Decrypt(encrypted, decrypted)
+			if !bytes.Equal(block, decrypted) {
+				t.Errorf("Invalid round trip for key length %d and %d rounds", length, rounds)
+			}
+		}
+	}
+}
+
+func newTestCipher(key []byte) (modes.Cipher, error) {
+	return NewCipher(key)
+}
+
+// FIPS-197 appendix C
+var fips197Vectors = []ciphertest.Vector{
+	{
+		Key:        "000102030405060708090a0b0c0d0e0f",
+		Plaintext:  "00112233445566778899aabbccddeeff",
+		Ciphertext: "69c4e0d86a7b0430d8cdb78070b4c55a",
+	},
+	{
+		Key:        "000102030405060708090a0b0c0d0e0f1011121314151617",
+		Plaintext:  "00112233445566778899aabbccddeeff",
+		Ciphertext: "dda97ca4864cdfe06eaf70a0ec0d7191",
+	},
+	{
+		Key:        "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		Plaintext:  "00112233445566778899aabbccddeeff",
+		Ciphertext: "8ea2b7ca516745bfeafc49904b496089",
+	},
+}
+
+func TestCipherHarness(t *testing.T) {
+	ciphertest.CheckVectors(t, newTestCipher, fips197Vectors)
+	ciphertest.CheckRoundTrip(t, newTestCipher, []int{16, 24, 32})
+}
+
+func TestSetKey(t *testing.T) {
+	for _, v := range fips197Vectors {
+		key, _ := hex.DecodeString(v.Key)
+		plaintext, _ := hex.DecodeString(v.Plaintext)
+		cipher, err := NewCipher(make([]byte, len(key)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cipher.SetKey(key); err != nil {
+			t.Fatal(err)
+		}
+		dest := make([]byte, 16)
+		cipher.Encrypt(plaintext, dest)
+		if hex.EncodeToString(dest) != v.Ciphertext {
+			t.Errorf("Invalid encryption after SetKey. Expected: %s Got: %s", v.Ciphertext, hex.EncodeToString(dest))
+		}
+		if allocs := testing.AllocsPerRun(10, func() { cipher.SetKey(key) }); allocs != 0 {
+			t.Errorf("Invalid number of allocations in SetKey. Expected: 0 Got: %.0f", allocs)
+		}
+		if err := cipher.SetKey(key[:8]); err == nil {
+			t.Errorf("Invalid SetKey with a key of another length. Expected an error")
+		}
+	}
+}
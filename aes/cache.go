@@ -0,0 +1,145 @@
+package aes
+
+import (
+	"container/list"
+	"crypto/subtle"
+	"errors"
+	"hash/maphash"
+	"sync"
+)
+
+// Cache keeps the ciphers of the most recently used keys so services handling many keys don't expand
+// the key schedule on every request. The ciphers are indexed by a randomly seeded hash of the key, evicted in
+// least recently used order when the cache is full, and their key schedule is zeroed once evicted and
+// released by every user.
+//
+// The returned ciphers are shared: Encrypt and Decrypt may be called concurrently, but the ciphers
+// cannot be changed by SetTracer, SetFaultCheck or the fault injection hook.
+type Cache struct {
+	mutex   sync.Mutex
+	size    int
+	seed    maphash.Seed
+	entries map[uint64]*list.Element
+	order   *list.List
+}
+
+// cacheEntry is a cached cipher with the number of users that didn't release it
+type cacheEntry struct {
+	hash    uint64
+	cipher  *AESCipher
+	users   int
+	evicted bool
+}
+
+// NewCache creates a cache holding up to size ciphers
+func NewCache(size int) (*Cache, error) {
+	if size < 1 {
+		return nil, errors.New("Invalid cache size. At least one cipher must fit")
+	}
+	c := new(Cache)
+	c.size = size
+	c.seed = maphash.MakeSeed()
+	c.entries = make(map[uint64]*list.Element)
+	c.order = list.New()
+	return c, nil
+}
+
+// Get returns the cipher of key, creating it on a cache miss. release must be called once the caller
+// stops using the cipher. The cipher is created without holding the lock, so a miss doesn't delay the
+// lookups of other keys.
+func (c *Cache) Get(key []byte) (cipher *AESCipher, release func(), err error) {
+	if err := checkKeyLength(key); err != nil {
+		return nil, nil, err
+	}
+	hash := maphash.Bytes(c.seed, key)
+	c.mutex.Lock()
+	entry := c.lookup(hash, key)
+	c.mutex.Unlock()
+	if entry == nil {
+		created, err := NewCipher(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		created.shared = true
+		c.mutex.Lock()
+		// another caller may have added the key meanwhile
+		if entry = c.lookup(hash, key); entry != nil {
+			created.zero()
+		} else {
+			entry = &cacheEntry{hash: hash, cipher: created, users: 1}
+			c.entries[hash] = c.order.PushFront(entry)
+			for c.order.Len() > c.size {
+				c.evict(c.order.Back())
+			}
+		}
+		c.mutex.Unlock()
+	}
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			entry.users--
+			if entry.evicted && entry.users == 0 {
+				entry.cipher.zero()
+			}
+		})
+	}
+	return entry.cipher, release, nil
+}
+
+// lookup returns the entry of key counting a new user, or nil. An entry of another key with the same
+// hash is evicted. It must be called holding the lock.
+func (c *Cache) lookup(hash uint64, key []byte) *cacheEntry {
+	element, ok := c.entries[hash]
+	if !ok {
+		return nil
+	}
+	entry := element.Value.(*cacheEntry)
+	if subtle.ConstantTimeCompare(entry.cipher.key, key) != 1 {
+		c.evict(element)
+		return nil
+	}
+	c.order.MoveToFront(element)
+	entry.users++
+	return entry
+}
+
+// evict removes the element from the cache, zeroing its cipher if it isn't in use
+func (c *Cache) evict(element *list.Element) {
+	entry := element.Value.(*cacheEntry)
+	c.order.Remove(element)
+	delete(c.entries, entry.hash)
+	entry.evicted = true
+	if entry.users == 0 {
+		entry.cipher.zero()
+	}
+}
+
+// Len returns the number of cached ciphers
+func (c *Cache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.order.Len()
+}
+
+// Purge evicts every cipher
+func (c *Cache) Purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for c.order.Len() > 0 {
+		c.evict(c.order.Back())
+	}
+}
+
+// zero overwrites the key and the key schedule of the cipher
+func (c *AESCipher) zero() {
+	for i := range c.key {
+		c.key[i] = 0
+	}
+	for _, roundKey := range c.expandedKeys {
+		for i := range roundKey {
+			roundKey[i] = 0
+		}
+	}
+}
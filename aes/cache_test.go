@@ -0,0 +1,156 @@
+package aes
+
+import (
+	"bytes"
+	"crypto/rand"
+	"sync"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	cache, err := NewCache(2)
+	if err != nil {
+		t.Fatalf("Error creating cache: %s", err.Error())
+	}
+	keys := make([][]byte, 3)
+	for i := range keys {
+		keys[i] = make([]byte, 16)
+		rand.Read(keys[i])
+	}
+	first, release, err := cache.Get(keys[0])
+	if err != nil {
+		t.Fatalf("Error getting cipher: %s", err.Error())
+	}
+	again, releaseAgain, _ := cache.Get(keys[0])
+	if again != first {
+		t.Errorf("Cipher not reused")
+	}
+	releaseAgain()
+	second, releaseSecond, _ := cache.Get(keys[1])
+	releaseSecond()
+	// keys[0] is the least recently used and is evicted, but its schedule is kept while in use
+	_, releaseThird, _ := cache.Get(keys[2])
+	releaseThird()
+	if cache.Len() != 2 {
+		t.Errorf("Invalid cache length. Expected: 2 Got: %d", cache.Len())
+	}
+	if !bytes.Equal(first.key, keys[0]) {
+		t.Errorf("Cipher in use zeroed")
+	}
+	release()
+	release()
+	if !bytes.Equal(first.key, make([]byte, 16)) || !bytes.Equal(first.expandedKeys[10], make([]byte, 16)) {
+		t.Errorf("Evicted cipher not zeroed")
+	}
+	newFirst, release, _ := cache.Get(keys[0])
+	release()
+	if newFirst == first {
+		t.Errorf("Evicted cipher reused")
+	}
+	// keys[1] was evicted by keys[0] and zeroed as it had no users
+	if !bytes.Equal(second.key, make([]byte, 16)) {
+		t.Errorf("Evicted cipher not zeroed")
+	}
+	cache.Purge()
+	if cache.Len() != 0 {
+		t.Errorf("Cache not purged")
+	}
+	if _, _, err := cache.Get(make([]byte, 10)); err == nil {
+		t.Errorf("Accepting invalid key length")
+	}
+	for name, change := range map[string]func(){
+		"SetTracer":        func() { newFirst.SetTracer(nil) },
+		"SetFaultCheck":    func() { newFirst.SetFaultCheck(FaultCheckVerify) },
+		"setFaultInjector": func() { newFirst.setFaultInjector(nil) },
+	} {
+		if !panics(change) {
+			t.Errorf("Changing cached cipher with %s", name)
+		}
+	}
+}
+
+func panics(f func()) (panicked bool) {
+	defer func() {
+		panicked = recover() != nil
+	}()
+	f()
+	return false
+}
+
+func TestCacheConcurrency(t *testing.T) {
+	cache, _ := NewCache(4)
+	keys := make([][]byte, 8)
+	expected := make([][]byte, len(keys))
+	block := make([]byte, 16)
+	for i := range keys {
+		keys[i] = make([]byte, 16)
+		rand.Read(keys[i])
+		cipher, _ := NewCipher(keys[i])
+		expected[i] = make([]byte, 16)
+		cipher.Encrypt(block, expected[i])
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			res := make([]byte, 16)
+			for i := 0; i < 200; i++ {
+				k := (g + i) % len(keys)
+				cipher, release, err := cache.Get(keys[k])
+				if err != nil {
+					t.Errorf("Error getting cipher: %s", err.Error())
+					return
+				}
+				cipher.Encrypt(block, res)
+				release()
+				if !bytes.Equal(res, expected[k]) {
+					t.Errorf("Invalid encryption result. Expected: 0x%x Got: 0x%x", expected[k], res)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+// BenchmarkNewCipher and BenchmarkCacheHit compare the key setup alone with a cache hit. The per
+// request benchmarks below also include the encryption of a block, as done by a server for each request.
+func BenchmarkNewCipher(b *testing.B) {
+	key := make([]byte, 32)
+	for i := 0; i < b.N; i++ {
+		NewCipher(key)
+	}
+}
+
+func BenchmarkCacheHit(b *testing.B) {
+	cache, _ := NewCache(16)
+	key := make([]byte, 32)
+	_, release, _ := cache.Get(key)
+	release()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, release, _ := cache.Get(key)
+		release()
+	}
+}
+
+func BenchmarkNewCipherPerRequest(b *testing.B) {
+	key := make([]byte, 32)
+	block := make([]byte, 16)
+	for i := 0; i < b.N; i++ {
+		cipher, _ := NewCipher(key)
+		cipher.Encrypt(block, block)
+	}
+}
+
+func BenchmarkCachePerRequest(b *testing.B) {
+	cache, _ := NewCache(16)
+	key := make([]byte, 32)
+	block := make([]byte, 16)
+	for i := 0; i < b.N; i++ {
+		cipher, release, _ := cache.Get(key)
+		cipher.Encrypt(block, block)
+		release()
+	}
+}
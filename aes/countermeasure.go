@@ -0,0 +1,155 @@
+package aes
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/gf256"
+	"math/bits"
+)
+
+// Fault check modes of EncryptChecked and DecryptChecked
+const (
+	// FaultCheckNone releases the output without checking it
+	FaultCheckNone = iota
+	// FaultCheckVerify runs the inverse operation on the output and compares the result with the input
+	FaultCheckVerify
+	// FaultCheckRedundant computes the block a second time with the state stored by rows in 32-bit
+	// words and compares both outputs
+	FaultCheckRedundant
+)
+
+// ErrFaultDetected is returned by the checked operations when the computation was faulty
+var ErrFaultDetected = errors.New("Fault detected. The output block was zeroed")
+
+// SetFaultCheck sets the countermeasure used by EncryptChecked and DecryptChecked. It panics on ciphers
+// returned by a Cache.
+func (c *AESCipher) SetFaultCheck(mode int) error {
+	if c.shared {
+		panic("Cached ciphers cannot be changed.")
+	}
+	if mode != FaultCheckNone && mode != FaultCheckVerify && mode != FaultCheckRedundant {
+		return errors.New("Invalid fault check mode")
+	}
+	c.faultCheck = mode
+	return nil
+}
+
+// EncryptChecked encrypts block into dest checking the computation with the mode set by SetFaultCheck.
+// When a fault is detected dest is zeroed and ErrFaultDetected is returned.
+func (c *AESCipher) EncryptChecked(block, dest []byte) error {
+	return c.checked(block, dest, c.Encrypt, c.Decrypt, c.encryptRows)
+}
+
+// DecryptChecked decrypts block into dest checking the computation with the mode set by SetFaultCheck.
+// When a fault is detected dest is zeroed and ErrFaultDetected is returned.
+func (c *AESCipher) DecryptChecked(block, dest []byte) error {
+	return c.checked(block, dest, c.Decrypt, c.Encrypt, c.decryptRows)
+}
+
+func (c *AESCipher) checked(block, dest []byte, op, inverse, redundant func(block, dest []byte)) error {
+	input := make([]byte, 16)
+	copy(input, block)
+	output := make([]byte, 16)
+	op(input, output)
+	check := make([]byte, 16)
+	var expected []byte
+	switch c.faultCheck {
+	case FaultCheckVerify:
+		inverse(output, check)
+		expected = input
+	case FaultCheckRedundant:
+		redundant(input, check)
+		expected = output
+	default:
+		copy(dest, output)
+		return nil
+	}
+	if subtle.ConstantTimeCompare(check, expected) != 1 {
+		for i := range dest[:16] {
+			dest[i] = 0
+		}
+		return ErrFaultDetected
+	}
+	copy(dest, output)
+	return nil
+}
+
+// toRows returns the rows of a state or round key as big-endian words, the first column being the most
+// significant byte
+func toRows(data []byte) (rows [4]uint32) {
+	for r := 0; r < 4; r++ {
+		rows[r] = uint32(data[r])<<24 | uint32(data[r+4])<<16 | uint32(data[r+8])<<8 | uint32(data[r+12])
+	}
+	return rows
+}
+
+func fromRows(rows [4]uint32, dest []byte) {
+	for r := 0; r < 4; r++ {
+		dest[r] = byte(rows[r] >> 24)
+		dest[r+4] = byte(rows[r] >> 16)
+		dest[r+8] = byte(rows[r] >> 8)
+		dest[r+12] = byte(rows[r])
+	}
+}
+
+func addRoundKeyRows(state *[4]uint32, key []byte) {
+	keyRows := toRows(key)
+	for r := range state {
+		state[r] ^= keyRows[r]
+	}
+}
+
+func mixColumnsRows(state *[4]uint32) {
+	s0, s1, s2, s3 := state[0], state[1], state[2], state[3]
+	state[0] = gf256.XtimeWord(s0^s1) ^ s1 ^ s2 ^ s3
+	state[1] = gf256.XtimeWord(s1^s2) ^ s2 ^ s3 ^ s0
+	state[2] = gf256.XtimeWord(s2^s3) ^ s3 ^ s0 ^ s1
+	state[3] = gf256.XtimeWord(s3^s0) ^ s0 ^ s1 ^ s2
+}
+
+func invMixColumnsRows(state *[4]uint32) {
+	// InvMixColumns = MixColumns after multiplying the columns by {04}x^2 + {05}
+	u := gf256.XtimeWord(gf256.XtimeWord(state[0] ^ state[2]))
+	v := gf256.XtimeWord(gf256.XtimeWord(state[1] ^ state[3]))
+	state[0] ^= u
+	state[1] ^= v
+	state[2] ^= u
+	state[3] ^= v
+	mixColumnsRows(state)
+}
+
+// encryptRows encrypts the block with the state stored by rows, without calling the tracer or the fault
+// injector
+func (c *AESCipher) encryptRows(block, dest []byte) {
+	state := toRows(block)
+	addRoundKeyRows(&state, c.expandedKeys[0])
+	for r := 1; r <= c.numRounds; r++ {
+		for i := range state {
+			state[i] = substituteWord(state[i], c.sBox)
+			state[i] = bits.RotateLeft32(state[i], 8*i)
+		}
+		if r != c.numRounds {
+			mixColumnsRows(&state)
+		}
+		addRoundKeyRows(&state, c.expandedKeys[r])
+	}
+	fromRows(state, dest)
+}
+
+// decryptRows decrypts the block with the state stored by rows, without calling the tracer or the fault
+// injector
+func (c *AESCipher) decryptRows(block, dest []byte) {
+	state := toRows(block)
+	addRoundKeyRows(&state, c.expandedKeys[c.numRounds])
+	for r := c.numRounds - 1; r >= 0; r-- {
+		for i := range state {
+			state[i] = bits.RotateLeft32(state[i], -8*i)
+			state[i] = substituteWord(state[i], c.invSBox)
+		}
+		addRoundKeyRows(&state, c.expandedKeys[r])
+		if r != 0 {
+			invMixColumnsRows(&state)
+		}
+	}
+	fromRows(state, dest)
+}
@@ -0,0 +1,76 @@
+package aes
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestCheckedOperations(t *testing.T) {
+	block := make([]byte, 16)
+	for _, keyLength := range []int{16, 24, 32} {
+		key := make([]byte, keyLength)
+		rand.Read(key)
+		cipher, _ := NewCipher(key)
+		for i := 0; i < 50; i++ {
+			rand.Read(block)
+			expected := make([]byte, 16)
+			cipher.Encrypt(block, expected)
+			for _, mode := range []int{FaultCheckNone, FaultCheckVerify, FaultCheckRedundant} {
+				cipher.SetFaultCheck(mode)
+				res := make([]byte, 16)
+				if err := cipher.EncryptChecked(block, res); err != nil {
+					t.Fatalf("Error on checked encryption: %s", err.Error())
+				}
+				if !bytes.Equal(res, expected) {
+					t.Fatalf("Invalid checked encryption on mode %d. Expected: 0x%x Got: 0x%x", mode, expected, res)
+				}
+				if err := cipher.DecryptChecked(res, res); err != nil {
+					t.Fatalf("Error on checked decryption: %s", err.Error())
+				}
+				if !bytes.Equal(res, block) {
+					t.Fatalf("Invalid checked decryption on mode %d. Expected: 0x%x Got: 0x%x", mode, block, res)
+				}
+			}
+		}
+	}
+}
+
+func TestFaultCheck(t *testing.T) {
+	key := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f}
+	block := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	cipher, _ := NewCipher(key)
+	if err := cipher.SetFaultCheck(3); err == nil {
+		t.Errorf("Accepting invalid fault check mode")
+	}
+	faults := []*mockInjector{
+		{round: 9, step: "s_row"},
+		{round: 10, step: "output"},
+		{round: 1, step: "start"},
+		{round: 5, step: "istart"},
+	}
+	for _, mode := range []int{FaultCheckVerify, FaultCheckRedundant} {
+		cipher.SetFaultCheck(mode)
+		for _, fault := range faults {
+			cipher.setFaultInjector(fault)
+			res := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+			op := cipher.EncryptChecked
+			if fault.step == "istart" {
+				op = cipher.DecryptChecked
+			}
+			if err := op(block, res); err != ErrFaultDetected {
+				t.Errorf("Fault at round %d step %s not detected on mode %d", fault.round, fault.step, mode)
+			}
+			if !bytes.Equal(res, make([]byte, 16)) {
+				t.Errorf("Faulty output released: 0x%x", res)
+			}
+		}
+	}
+	// without countermeasure the faulty block is released
+	cipher.SetFaultCheck(FaultCheckNone)
+	cipher.setFaultInjector(&mockInjector{round: 9, step: "s_row"})
+	res := make([]byte, 16)
+	if err := cipher.EncryptChecked(block, res); err != nil || bytes.Equal(res, make([]byte, 16)) {
+		t.Errorf("Invalid output without fault check")
+	}
+}
@@ -0,0 +1,23 @@
+package aes
+
+import (
+	"github.com/emanuelzabka/crypt-aes/internal/fault"
+)
+
+func init() {
+	fault.Register(func(cipher interface{}, injector fault.Injector) {
+		cipher.(*AESCipher).setFaultInjector(injector)
+	}, func(cipher interface{}) fault.Injector {
+		return cipher.(*AESCipher).injector
+	})
+}
+
+// setFaultInjector sets the fault injector called during Encrypt and Decrypt, reached by the fault
+// attack experiments through the internal fault package. A nil injector disables the fault injection.
+// It panics on ciphers returned by a Cache.
+func (c *AESCipher) setFaultInjector(injector fault.Injector) {
+	if c.shared {
+		panic("Cached ciphers cannot be changed.")
+	}
+	c.injector = injector
+}
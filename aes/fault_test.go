@@ -0,0 +1,61 @@
+package aes
+
+import (
+	"bytes"
+	"testing"
+)
+
+type mockInjector struct {
+	round int
+	step  string
+	calls int
+}
+
+func (f *mockInjector) Inject(round int, step string, state []byte) {
+	if round == f.round && step == f.step {
+		state[0] ^= 0x01
+		f.calls++
+	}
+}
+
+func TestFaultInjector(t *testing.T) {
+	key := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f}
+	block := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	expected := []byte{0x69, 0xc4, 0xe0, 0xd8, 0x6a, 0x7b, 0x04, 0x30, 0xd8, 0xcd, 0xb7, 0x80, 0x70, 0xb4, 0xc5, 0x5a}
+	cipher, err := NewCipher(key)
+	if err != nil {
+		t.Fatal("Error creating cipher")
+	}
+	res := make([]byte, 16)
+	// a fault in the last step only flips the bit of the output
+	injector := &mockInjector{round: 10, step: "output"}
+	cipher.setFaultInjector(injector)
+	cipher.Encrypt(block, res)
+	if injector.calls != 1 || res[0] != expected[0]^0x01 || !bytes.Equal(res[1:], expected[1:]) {
+		t.Errorf("Invalid output fault")
+	}
+	// a fault before the last MixColumns changes a whole column
+	cipher.setFaultInjector(&mockInjector{round: 9, step: "s_row"})
+	cipher.Encrypt(block, res)
+	diff := 0
+	for i := range res {
+		if res[i] != expected[i] {
+			diff++
+		}
+	}
+	if diff != 4 {
+		t.Errorf("Invalid fault propagation. Expected 4 faulty bytes, got %d", diff)
+	}
+	// key schedule steps are not passed to the injector
+	injector = &mockInjector{round: 10, step: "k_sch"}
+	cipher.setFaultInjector(injector)
+	cipher.Encrypt(block, res)
+	if injector.calls != 0 {
+		t.Errorf("Injector called with the round key")
+	}
+	cipher.setFaultInjector(nil)
+	cipher.Encrypt(block, res)
+	if !bytes.Equal(res, expected) {
+		t.Errorf("Fault injected after disabling the injector")
+	}
+}
@@ -0,0 +1,44 @@
+package aes
+
+import (
+	"bytes"
+	stdaes "crypto/aes"
+	"testing"
+)
+
+func FuzzCipher(f *testing.F) {
+	f.Add(make([]byte, 16), make([]byte, 16))
+	f.Add(bytes.Repeat([]byte{0xff}, 24), bytes.Repeat([]byte{0x80}, 16))
+	f.Add([]byte("an AES-256 key of thirty-two b.."), []byte("a plaintext blk."))
+	f.Fuzz(func(t *testing.T, key, block []byte) {
+		// fit the inputs to the closest key length and to the block size instead of skipping them
+		size := 32
+		if len(key) <= 16 {
+			size = 16
+		} else if len(key) <= 24 {
+			size = 24
+		}
+		key = append(key[:len(key):len(key)], make([]byte, 32)...)[:size]
+		block = append(block[:len(block):len(block)], make([]byte, 16)...)[:16]
+		c, err := NewCipher(key)
+		if err != nil {
+			t.Fatalf("Error creating cipher: %s", err.Error())
+		}
+		reference, err := stdaes.NewCipher(key)
+		if err != nil {
+			t.Fatalf("Error creating reference cipher: %s", err.Error())
+		}
+		got := make([]byte, 16)
+		expected := make([]byte, 16)
+		c.Encrypt(block, got)
+		reference.Encrypt(expected, block)
+		if !bytes.Equal(got, expected) {
+			t.Errorf("Invalid encryption with key 0x%x. Expected: 0x%x Got: 0x%x", key, expected, got)
+		}
+		c.Decrypt(block, got)
+		reference.Decrypt(expected, block)
+		if !bytes.Equal(got, expected) {
+			t.Errorf("Invalid decryption with key 0x%x. Expected: 0x%x Got: 0x%x", key, expected, got)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package aes
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// InvertKeySchedule recovers the cipher key from round keys of the standard AES key expansion.
+// roundKeys holds the round key of index round for AES-128 (keyLength 16); AES-192 and AES-256
+// (keyLength 24 and 32) need the round keys of index round and round+1 concatenated.
+func InvertKeySchedule(roundKeys []byte, round, keyLength int) ([]byte, error) {
+	var wordKeys [15 * 4]uint32
+	if keyLength != 16 && keyLength != 24 && keyLength != 32 {
+		return nil, errors.New("Invalid key length. Allowed lengths: 128-bit (16 bytes), 192-bit (24 bytes), 256-bit (32 bytes)")
+	}
+	nk := keyLength / 4
+	numRounds := getNumRounds(nk)
+	numKeys := 1
+	if nk > 4 {
+		numKeys = 2
+	}
+	if len(roundKeys) != numKeys*16 {
+		return nil, errors.New("Invalid round keys length. AES-128 needs one round key, AES-192 and AES-256 need two consecutive round keys")
+	}
+	if round < 0 || round+numKeys-1 > numRounds {
+		return nil, errors.New("Invalid round index")
+	}
+	start := round * 4
+	for i := 0; i < nk; i++ {
+		wordKeys[start+i] = binary.BigEndian.Uint32(roundKeys[4*i : 4*i+4])
+	}
+	// w[i-Nk] = w[i] ^ temp, where temp only depends on w[i-1]
+	for i := start + nk - 1; i >= nk; i-- {
+		temp := wordKeys[i-1]
+		if i%nk == 0 {
+			temp = subWord(rotWord(temp)) ^ rCon[i/nk-1]
+		} else if nk > 6 && i%nk == 4 {
+			temp = subWord(temp)
+		}
+		wordKeys[i-nk] = wordKeys[i] ^ temp
+	}
+	key := make([]byte, keyLength)
+	for i := 0; i < nk; i++ {
+		binary.BigEndian.PutUint32(key[4*i:4*i+4], wordKeys[i])
+	}
+	// The AES-192 round keys hold more words than needed, which must be consistent with the key found
+	expandedKeys := make([]byte, (numRounds+1)*16)
+	keyExpansion(key, expandedKeys, nk)
+	for i := range roundKeys {
+		if expandedKeys[round*16+i] != roundKeys[i] {
+			return nil, errors.New("Inconsistent round keys")
+		}
+	}
+	return key, nil
+}
+
+// RoundKeys returns a copy of the round keys used by the cipher
+func (c *AESCipher) RoundKeys() [][]byte {
+	keys := make([][]byte, len(c.expandedKeys))
+	for i := range c.expandedKeys {
+		keys[i] = make([]byte, 16)
+		copy(keys[i], c.expandedKeys[i])
+	}
+	return keys
+}
@@ -0,0 +1,78 @@
+package aes
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+func TestInvertKeySchedule(t *testing.T) {
+	// FIPS-197 Appendix A.1, round keys 4 and 10
+	roundKeys := [][]byte{
+		[]byte{0xef, 0x44, 0xa5, 0x41, 0xa8, 0x52, 0x5b, 0x7f, 0xb6, 0x71, 0x25, 0x3b, 0xdb, 0x0b, 0xad, 0x00},
+		[]byte{0xd0, 0x14, 0xf9, 0xa8, 0xc9, 0xee, 0x25, 0x89, 0xe1, 0x3f, 0x0c, 0xc8, 0xb6, 0x63, 0x0c, 0xa6},
+	}
+	rounds := []int{4, 10}
+	expected := []byte{0x2b, 0x7e, 0x15, 0x16, 0x28, 0xae, 0xd2, 0xa6, 0xab, 0xf7, 0x15, 0x88, 0x09, 0xcf, 0x4f, 0x3c}
+	for i := range roundKeys {
+		key, err := InvertKeySchedule(roundKeys[i], rounds[i], 16)
+		if err != nil {
+			t.Errorf("Error inverting key schedule from round %d: %s", rounds[i], err.Error())
+			continue
+		}
+		if !bytes.Equal(key, expected) {
+			t.Errorf("Invalid key from round %d. Expected: 0x%s Got: 0x%s",
+				rounds[i], hex.EncodeToString(expected), hex.EncodeToString(key))
+		}
+	}
+}
+
+func TestInvertKeyScheduleRoundTrip(t *testing.T) {
+	for _, length := range []int{16, 24, 32} {
+		for n := 0; n < 20; n++ {
+			key := make([]byte, length)
+			rand.Read(key)
+			cipher, _ := NewCipher(key)
+			roundKeys := cipher.RoundKeys()
+			numKeys := 1
+			if length > 16 {
+				numKeys = 2
+			}
+			for r := 0; r+numKeys <= len(roundKeys); r++ {
+				input := bytes.Join(roundKeys[r:r+numKeys], nil)
+				res, err := InvertKeySchedule(input, r, length)
+				if err != nil {
+					t.Errorf("Error inverting key schedule for key 0x%s from round %d: %s",
+						hex.EncodeToString(key), r, err.Error())
+					continue
+				}
+				if !bytes.Equal(res, key) {
+					t.Errorf("Invalid key from round %d. Expected: 0x%s Got: 0x%s",
+						r, hex.EncodeToString(key), hex.EncodeToString(res))
+				}
+			}
+		}
+	}
+}
+
+func TestInvertKeyScheduleInvalid(t *testing.T) {
+	if _, err := InvertKeySchedule(make([]byte, 16), 0, 20); err == nil {
+		t.Errorf("Accepting invalid key length")
+	}
+	if _, err := InvertKeySchedule(make([]byte, 16), 1, 32); err == nil {
+		t.Errorf("Accepting a single round key for AES-256")
+	}
+	if _, err := InvertKeySchedule(make([]byte, 16), 11, 16); err == nil {
+		t.Errorf("Accepting invalid round index")
+	}
+	if _, err := InvertKeySchedule(make([]byte, 32), 14, 32); err == nil {
+		t.Errorf("Accepting round keys past the last round")
+	}
+	// the last two words of the second AES-192 round key are not used to recover the key
+	inconsistent := make([]byte, 32)
+	inconsistent[31] = 0x01
+	if _, err := InvertKeySchedule(inconsistent, 2, 24); err == nil {
+		t.Errorf("Accepting inconsistent AES-192 round keys")
+	}
+}
@@ -0,0 +1,220 @@
+package aes
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+)
+
+// MaskedCipher is a first-order Boolean masked implementation of the cipher for side-channel
+// experiments. Every block uses fresh masks: m and m2 for the input and output of the S-box, recomputed
+// for each block, and one mask per row for the input of MixColumns, whose output masks are derived by
+// MixColumns itself. The round keys are expanded and stored masked. The intermediate values reported to
+// the tracer are the masked ones, as processed by the implementation.
+type MaskedCipher struct {
+	keyLength  int
+	numRounds  int
+	maskedKeys [][]byte
+	keyMasks   [][]byte
+	tracer     Tracer
+}
+
+// blockMasks are the fresh masks of a block operation
+type blockMasks struct {
+	in      byte
+	out     byte
+	rows    [4]byte
+	mixRows [4]byte
+}
+
+// NewMaskedCipher creates a new masked cipher using the key specified
+// Allowed key lengths: 16, 24 and 32 bytes
+// The known-answer self test runs on the first call, and the constructor fails if it did not pass.
+func NewMaskedCipher(key []byte) (*MaskedCipher, error) {
+	if err := checkKeyLength(key); err != nil {
+		return nil, err
+	}
+	if err := powerOnSelfTest(); err != nil {
+		return nil, err
+	}
+	return newMaskedCipher(key), nil
+}
+
+func newMaskedCipher(key []byte) *MaskedCipher {
+	c := new(MaskedCipher)
+	c.keyLength = len(key) / 4
+	c.numRounds = getNumRounds(c.keyLength)
+	c.maskedKeys = make([][]byte, c.numRounds+1)
+	c.keyMasks = make([][]byte, c.numRounds+1)
+	for i := range c.maskedKeys {
+		c.maskedKeys[i] = make([]byte, 16)
+		c.keyMasks[i] = make([]byte, 16)
+	}
+	c.maskedKeyExpansion(key)
+	return c
+}
+
+func randomBytes(buffer []byte) {
+	if _, err := rand.Read(buffer); err != nil {
+		panic("Error generating masks.")
+	}
+}
+
+// newBlockMasks generates the fresh masks and the masked S-box tables of a block operation
+func newBlockMasks() *blockMasks {
+	var random [6]byte
+	var column [16]byte
+	randomBytes(random[:])
+	masks := new(blockMasks)
+	masks.in, masks.out = random[0], random[1]
+	copy(masks.rows[:], random[2:])
+	copy(column[:], random[2:])
+	mixColumns(column[:])
+	copy(masks.mixRows[:], column[:4])
+	return masks
+}
+
+// maskedSBox returns the table of S(x) ^ out indexed by x ^ in
+func maskedSBox(table []byte, in, out byte) []byte {
+	masked := make([]byte, 256)
+	for x := 0; x < 256; x++ {
+		masked[byte(x)^in] = table[x] ^ out
+	}
+	return masked
+}
+
+// maskedKeyExpansion runs the key expansion keeping every word masked. The masks follow the same
+// recurrence of the words, with the S-box steps going through a masked S-box.
+func (c *MaskedCipher) maskedKeyExpansion(key []byte) {
+	var words, masks [15 * 4]uint32
+	var random [6]byte
+	nk := c.keyLength
+	randomBytes(random[:2])
+	in, out := random[0], random[1]
+	inWord := uint32(in) * 0x01010101
+	outWord := uint32(out) * 0x01010101
+	table := maskedSBox(sBoxMatrix, in, out)
+	for i := 0; i < nk; i++ {
+		randomBytes(random[2:])
+		masks[i] = binary.BigEndian.Uint32(random[2:])
+		words[i] = binary.BigEndian.Uint32(key[4*i:4*i+4]) ^ masks[i]
+	}
+	for i := nk; i < 4*(c.numRounds+1); i++ {
+		temp, tempMask := words[i-1], masks[i-1]
+		if i%nk == 0 {
+			temp = rotWord(temp) ^ (rotWord(tempMask) ^ inWord)
+			temp = substituteWord(temp, table) ^ rCon[i/nk-1]
+			tempMask = outWord
+		} else if nk > 6 && i%nk == 4 {
+			temp = temp ^ (tempMask ^ inWord)
+			temp = substituteWord(temp, table)
+			tempMask = outWord
+		}
+		words[i] = words[i-nk] ^ temp
+		masks[i] = masks[i-nk] ^ tempMask
+	}
+	for i := 0; i < 4*(c.numRounds+1); i++ {
+		binary.BigEndian.PutUint32(c.maskedKeys[i/4][(i%4)*4:], words[i])
+		binary.BigEndian.PutUint32(c.keyMasks[i/4][(i%4)*4:], masks[i])
+	}
+}
+
+// addMaskedRoundKey xors the round key masked by mask(row) into state. The stored mask is replaced
+// before the key is used, so the round key is never unmasked.
+func (c *MaskedCipher) addMaskedRoundKey(state []byte, round int, mask func(row int) byte) {
+	for i := range state {
+		state[i] ^= c.maskedKeys[round][i] ^ (c.keyMasks[round][i] ^ mask(i&3))
+	}
+}
+
+// remask replaces the mask of each row of state
+func remask(state []byte, mask func(row int) byte) {
+	for i := range state {
+		state[i] ^= mask(i & 3)
+	}
+}
+
+// Encrypt encrypt a block of data
+// block and dest must be a slice with length 16
+// The resulting encrypted block is stored in dest
+func (c *MaskedCipher) Encrypt(block, dest []byte) {
+	var state []byte = make([]byte, 16)
+	m := newBlockMasks()
+	table := maskedSBox(sBoxMatrix, m.in, m.out)
+	copy(state, block)
+	c.hook(0, "input", state)
+	// state masked by in
+	c.addMaskedRoundKey(state, 0, func(row int) byte { return m.in })
+	for r := 1; r < c.numRounds; r++ {
+		c.hook(r, "start", state)
+		// state masked by out
+		substitute(state, table)
+		c.hook(r, "s_box", state)
+		shiftRows(state)
+		c.hook(r, "s_row", state)
+		// state masked by rows, and by mixRows after MixColumns
+		remask(state, func(row int) byte { return m.out ^ m.rows[row] })
+		mixColumns(state)
+		c.hook(r, "m_col", state)
+		c.addMaskedRoundKey(state, r, func(row int) byte { return m.mixRows[row] ^ m.in })
+	}
+	c.hook(c.numRounds, "start", state)
+	substitute(state, table)
+	c.hook(c.numRounds, "s_box", state)
+	shiftRows(state)
+	c.hook(c.numRounds, "s_row", state)
+	c.addMaskedRoundKey(state, c.numRounds, func(row int) byte { return m.out })
+	c.hook(c.numRounds, "output", state)
+	copy(dest, state)
+}
+
+// Decrypt decrypts a block of data
+// block and dest must be a slice with length 16
+// The resulting decrypted block is stored in dest
+func (c *MaskedCipher) Decrypt(block, dest []byte) {
+	var state []byte = make([]byte, 16)
+	m := newBlockMasks()
+	// inverse table of S(x) ^ out indexed by x ^ in
+	table := maskedSBox(invSBoxMatrix, m.out, m.in)
+	copy(state, block)
+	c.hook(0, "iinput", state)
+	// state masked by out
+	c.addMaskedRoundKey(state, c.numRounds, func(row int) byte { return m.out })
+	for i := c.numRounds - 1; i > 0; i-- {
+		r := c.numRounds - i
+		c.hook(r, "istart", state)
+		invShiftRows(state)
+		c.hook(r, "is_row", state)
+		// state masked by in, and by mixRows after adding the round key
+		substitute(state, table)
+		c.hook(r, "is_box", state)
+		c.addMaskedRoundKey(state, i, func(row int) byte { return m.in ^ m.mixRows[row] })
+		c.hook(r, "ik_add", state)
+		// state masked by rows after InvMixColumns
+		invMixColumns(state)
+		remask(state, func(row int) byte { return m.rows[row] ^ m.out })
+	}
+	c.hook(c.numRounds, "istart", state)
+	invShiftRows(state)
+	c.hook(c.numRounds, "is_row", state)
+	substitute(state, table)
+	c.hook(c.numRounds, "is_box", state)
+	c.addMaskedRoundKey(state, 0, func(row int) byte { return m.in })
+	c.hook(c.numRounds, "ioutput", state)
+	copy(dest, state)
+}
+
+// BlockSize returns the block size used
+func (c *MaskedCipher) BlockSize() int {
+	return 16
+}
+
+// SetTracer sets the tracer called with the masked intermediate values. A nil tracer disables tracing.
+func (c *MaskedCipher) SetTracer(tracer Tracer) {
+	c.tracer = tracer
+}
+
+func (c *MaskedCipher) hook(round int, step string, data []byte) {
+	if c.tracer != nil {
+		c.tracer.Trace(round, step, data)
+	}
+}
@@ -0,0 +1,72 @@
+package aes
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+func TestMaskedCipher(t *testing.T) {
+	for _, length := range []int{16, 24, 32} {
+		for n := 0; n < 20; n++ {
+			key := make([]byte, length)
+			block := make([]byte, 16)
+			rand.Read(key)
+			rand.Read(block)
+			reference, _ := NewCipher(key)
+			masked, err := NewMaskedCipher(key)
+			if err != nil {
+				t.Fatalf("Error creating masked cipher: %s", err.Error())
+			}
+			expected := make([]byte, 16)
+			res := make([]byte, 16)
+			reference.Encrypt(block, expected)
+			masked.Encrypt(block, res)
+			if !bytes.Equal(res, expected) {
+				t.Errorf("Invalid masked encryption for key 0x%s. Expected: 0x%s Got: 0x%s",
+					hex.EncodeToString(key), hex.EncodeToString(expected), hex.EncodeToString(res))
+			}
+			masked.Decrypt(expected, res)
+			if !bytes.Equal(res, block) {
+				t.Errorf("Invalid masked decryption for key 0x%s. Expected: 0x%s Got: 0x%s",
+					hex.EncodeToString(key), hex.EncodeToString(block), hex.EncodeToString(res))
+			}
+		}
+	}
+}
+
+type stateRecorder struct {
+	states [][]byte
+}
+
+func (r *stateRecorder) Trace(round int, step string, data []byte) {
+	if round == 1 && step == "s_box" {
+		state := make([]byte, len(data))
+		copy(state, data)
+		r.states = append(r.states, state)
+	}
+}
+
+func TestMaskedCipherIntermediates(t *testing.T) {
+	key := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f}
+	block := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	// FIPS-197 Appendix C.1, round[ 1].s_box
+	unmasked := []byte{0x63, 0xca, 0xb7, 0x04, 0x09, 0x53, 0xd0, 0x51, 0xcd, 0x60, 0xe0, 0xe7, 0xba, 0x70, 0xe1, 0x8c}
+	masked, _ := NewMaskedCipher(key)
+	recorder := new(stateRecorder)
+	masked.SetTracer(recorder)
+	for i := 0; i < 16; i++ {
+		masked.Encrypt(block, make([]byte, 16))
+	}
+	matches := 0
+	for _, state := range recorder.states {
+		if bytes.Equal(state, unmasked) {
+			matches++
+		}
+	}
+	// the state is unmasked only when the random output mask of the S-box is zero
+	if len(recorder.states) != 16 || matches > 2 {
+		t.Errorf("Intermediate values are not masked, %d of %d states unmasked", matches, len(recorder.states))
+	}
+}
@@ -0,0 +1,51 @@
+package aes
+
+import (
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/gf256"
+)
+
+// gfInv returns the multiplicative inverse in GF(2^8), mapping 0 to 0
+func gfInv(a byte) byte {
+	return gf256.Inv(a)
+}
+
+func rotl8(b byte, n uint) byte {
+	return b<<n | b>>(8-n)
+}
+
+// affine applies the affine transformation of the AES S-box over GF(2)
+func affine(b byte) byte {
+	return b ^ rotl8(b, 1) ^ rotl8(b, 2) ^ rotl8(b, 3) ^ rotl8(b, 4) ^ 0x63
+}
+
+// GenerateSBox derives the AES S-box and its inverse from the multiplicative inversion in GF(2^8)
+// followed by the affine transformation (FIPS-197 section 5.1.1)
+func GenerateSBox() (sBox, invSBox []byte) {
+	sBox = make([]byte, 256)
+	invSBox = make([]byte, 256)
+	for i := 0; i < 256; i++ {
+		s := affine(gfInv(byte(i)))
+		sBox[i] = s
+		invSBox[s] = byte(i)
+	}
+	return sBox, invSBox
+}
+
+// InvertSBox returns the inverse of a substitution table with 256 entries. It fails if the table is
+// not a bijection.
+func InvertSBox(sBox []byte) ([]byte, error) {
+	if len(sBox) != 256 {
+		return nil, errors.New("Invalid S-box length. The S-box must have 256 entries")
+	}
+	var seen [256]bool
+	invSBox := make([]byte, 256)
+	for i, s := range sBox {
+		if seen[s] {
+			return nil, errors.New("Invalid S-box. The S-box must be bijective")
+		}
+		seen[s] = true
+		invSBox[s] = byte(i)
+	}
+	return invSBox, nil
+}
@@ -0,0 +1,69 @@
+package aes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestGenerateSBox(t *testing.T) {
+	sBox, invSBox := GenerateSBox()
+	if !bytes.Equal(sBox, sBoxMatrix) {
+		t.Errorf("Generated S-box differs from the table.\nExpected: %s\nGot: %s",
+			hex.EncodeToString(sBoxMatrix), hex.EncodeToString(sBox))
+	}
+	if !bytes.Equal(invSBox, invSBoxMatrix) {
+		t.Errorf("Generated inverse S-box differs from the table.\nExpected: %s\nGot: %s",
+			hex.EncodeToString(invSBoxMatrix), hex.EncodeToString(invSBox))
+	}
+}
+
+func TestInvertSBox(t *testing.T) {
+	invSBox, err := InvertSBox(sBoxMatrix)
+	if err != nil {
+		t.Fatalf("Error inverting S-box: %s", err.Error())
+	}
+	if !bytes.Equal(invSBox, invSBoxMatrix) {
+		t.Errorf("Invalid inverse S-box")
+	}
+	if _, err := InvertSBox(make([]byte, 256)); err == nil {
+		t.Errorf("Accepting non bijective S-box")
+	}
+	if _, err := InvertSBox(sBoxMatrix[:255]); err == nil {
+		t.Errorf("Accepting S-box with invalid length")
+	}
+}
+
+func TestNewCipherWithSBox(t *testing.T) {
+	key := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f}
+	block := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	expected := []byte{0x69, 0xc4, 0xe0, 0xd8, 0x6a, 0x7b, 0x04, 0x30, 0xd8, 0xcd, 0xb7, 0x80, 0x70, 0xb4, 0xc5, 0x5a}
+	cipher, err := NewCipherWithSBox(key, sBoxMatrix)
+	if err != nil {
+		t.Fatalf("Error creating cipher: %s", err.Error())
+	}
+	res := make([]byte, 16)
+	cipher.Encrypt(block, res)
+	if !bytes.Equal(res, expected) {
+		t.Errorf("Invalid encryption with the AES S-box. Expected: 0x%s Got: 0x%s",
+			hex.EncodeToString(expected), hex.EncodeToString(res))
+	}
+	// x -> x ^ 0x5a is bijective but affine, which makes a weak but valid cipher
+	custom := make([]byte, 256)
+	for i := range custom {
+		custom[i] = byte(i) ^ 0x5a
+	}
+	cipher, err = NewCipherWithSBox(key, custom)
+	if err != nil {
+		t.Fatalf("Error creating cipher with custom S-box: %s", err.Error())
+	}
+	decrypted := make([]byte, 16)
+	cipher.Encrypt(block, res)
+	cipher.Decrypt(res, decrypted)
+	if bytes.Equal(res, expected) || !bytes.Equal(decrypted, block) {
+		t.Errorf("Invalid round trip with custom S-box")
+	}
+	if _, err := NewCipherWithSBox(key, make([]byte, 256)); err == nil {
+		t.Errorf("Accepting non bijective S-box")
+	}
+}
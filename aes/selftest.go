@@ -0,0 +1,131 @@
+package aes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// selfTestVector is a known-answer test of the cipher
+type selfTestVector struct {
+	name       string
+	key        string
+	plaintext  string
+	ciphertext string
+}
+
+// selfTestVectors are the example vectors of FIPS-197 appendix C for every key size
+var selfTestVectors = []selfTestVector{
+	{"AES-128", "000102030405060708090a0b0c0d0e0f", "00112233445566778899aabbccddeeff", "69c4e0d86a7b0430d8cdb78070b4c55a"},
+	{"AES-192", "000102030405060708090a0b0c0d0e0f1011121314151617", "00112233445566778899aabbccddeeff", "dda97ca4864cdfe06eaf70a0ec0d7191"},
+	{"AES-256", "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", "00112233445566778899aabbccddeeff", "8ea2b7ca516745bfeafc49904b496089"},
+}
+
+// SelfTestResult is the outcome of a known-answer test. Err is nil when the test passed.
+type SelfTestResult struct {
+	Name string
+	Err  error
+}
+
+// SelfTestReport holds the results of SelfTest
+type SelfTestReport struct {
+	Results []SelfTestResult
+}
+
+// Passed reports whether every test passed
+func (r *SelfTestReport) Passed() bool {
+	return r.Err() == nil
+}
+
+// Err returns an error listing the failed tests, or nil
+func (r *SelfTestReport) Err() error {
+	var failed []string
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("AES self test failed: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (r *SelfTestReport) add(name string, err error) {
+	r.Results = append(r.Results, SelfTestResult{name, err})
+}
+
+// blockCipher is the block interface shared by the implementations
+type blockCipher interface {
+	Encrypt(block, dest []byte)
+	Decrypt(block, dest []byte)
+}
+
+// SelfTest runs the known-answer tests of the reference and masked implementations, including the fault
+// checked operations, in every key size
+func SelfTest() *SelfTestReport {
+	report := new(SelfTestReport)
+	for _, v := range selfTestVectors {
+		key, _ := hex.DecodeString(v.key)
+		plaintext, _ := hex.DecodeString(v.plaintext)
+		ciphertext, _ := hex.DecodeString(v.ciphertext)
+		reference := newCipher(key, getNumRounds(len(key)/4), sBoxMatrix, invSBoxMatrix)
+		report.add(v.name+" reference", checkKnownAnswer(reference, plaintext, ciphertext))
+		report.add(v.name+" masked", checkKnownAnswer(newMaskedCipher(key), plaintext, ciphertext))
+		reference.faultCheck = FaultCheckRedundant
+		report.add(v.name+" fault checked", checkCheckedAnswer(reference, plaintext, ciphertext))
+	}
+	return report
+}
+
+func checkKnownAnswer(c blockCipher, plaintext, ciphertext []byte) error {
+	result := make([]byte, len(plaintext))
+	c.Encrypt(plaintext, result)
+	if !bytes.Equal(result, ciphertext) {
+		return fmt.Errorf("Invalid encryption. Expected: 0x%x Got: 0x%x", ciphertext, result)
+	}
+	c.Decrypt(ciphertext, result)
+	if !bytes.Equal(result, plaintext) {
+		return fmt.Errorf("Invalid decryption. Expected: 0x%x Got: 0x%x", plaintext, result)
+	}
+	return nil
+}
+
+func checkCheckedAnswer(c *AESCipher, plaintext, ciphertext []byte) error {
+	result := make([]byte, len(plaintext))
+	if err := c.EncryptChecked(plaintext, result); err != nil {
+		return err
+	}
+	if !bytes.Equal(result, ciphertext) {
+		return fmt.Errorf("Invalid encryption. Expected: 0x%x Got: 0x%x", ciphertext, result)
+	}
+	if err := c.DecryptChecked(ciphertext, result); err != nil {
+		return err
+	}
+	if !bytes.Equal(result, plaintext) {
+		return fmt.Errorf("Invalid decryption. Expected: 0x%x Got: 0x%x", plaintext, result)
+	}
+	return nil
+}
+
+// powerOn holds the result of the self test run on the first use of the constructors
+var powerOn struct {
+	once sync.Once
+	err  error
+}
+
+// ErrSelfTest is returned by the constructors when the power-on self test failed
+var ErrSelfTest = errors.New("The AES power-on self test failed. The implementation is not operational")
+
+// powerOnSelfTest runs SelfTest once, returning ErrSelfTest from then on if it failed
+func powerOnSelfTest() error {
+	powerOn.once.Do(func() {
+		if !SelfTest().Passed() {
+			powerOn.err = ErrSelfTest
+		}
+	})
+	return powerOn.err
+}
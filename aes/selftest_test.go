@@ -0,0 +1,36 @@
+package aes
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	report := SelfTest()
+	if !report.Passed() || len(report.Results) != 9 {
+		t.Errorf("Invalid self test report: %v", report.Err())
+	}
+}
+
+func TestSelfTestFailure(t *testing.T) {
+	saved := selfTestVectors
+	defer func() {
+		selfTestVectors = saved
+		powerOn.once = sync.Once{}
+		powerOn.err = nil
+	}()
+	selfTestVectors = []selfTestVector{
+		{"AES-128", "000102030405060708090a0b0c0d0e0f", "00112233445566778899aabbccddeeff", "69c4e0d86a7b0430d8cdb78070b4c55b"},
+	}
+	report := SelfTest()
+	if report.Passed() || report.Results[0].Err == nil {
+		t.Errorf("Self test passing with a wrong known answer")
+	}
+	powerOn.once = sync.Once{}
+	if _, err := NewCipher(make([]byte, 16)); err != ErrSelfTest {
+		t.Errorf("Creating cipher after a failed self test. Expected: %v Got: %v", ErrSelfTest, err)
+	}
+	if _, err := NewMaskedCipher(make([]byte, 16)); err != ErrSelfTest {
+		t.Errorf("Creating masked cipher after a failed self test. Expected: %v Got: %v", ErrSelfTest, err)
+	}
+}
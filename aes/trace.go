@@ -0,0 +1,58 @@
+package aes
+
+import (
+	"fmt"
+	"io"
+)
+
+// Tracer receives the intermediate values of the cipher. Trace is called after each transformation
+// with the round number, the step name and the state (or the round key for the k_sch/ik_sch steps).
+// Step names follow the FIPS-197 Appendix C notation: input, start, s_box, s_row, m_col, k_sch and
+// output for encryption; iinput, istart, is_row, is_box, ik_sch, ik_add and ioutput for decryption.
+// The data slice is only valid during the call.
+type Tracer interface {
+	Trace(round int, step string, data []byte)
+}
+
+// TraceWriter is a Tracer that writes the intermediate values using the FIPS-197 Appendix C layout
+type TraceWriter struct {
+	writer io.Writer
+}
+
+// NewTraceWriter creates a new TraceWriter writing to w
+func NewTraceWriter(w io.Writer) *TraceWriter {
+	t := new(TraceWriter)
+	t.writer = w
+	return t
+}
+
+// Trace writes a line for the step, preceded by the section title on the first step of a block
+func (t *TraceWriter) Trace(round int, step string, data []byte) {
+	switch step {
+	case "input":
+		fmt.Fprintln(t.writer, "CIPHER (ENCRYPT):")
+	case "iinput":
+		fmt.Fprintln(t.writer, "INVERSE CIPHER (DECRYPT):")
+	}
+	fmt.Fprintf(t.writer, "round[%2d].%-8s %x\n", round, step, data)
+}
+
+// SetTracer sets the tracer called during Encrypt and Decrypt. A nil tracer disables tracing. It panics
+// on ciphers returned by a Cache.
+func (c *AESCipher) SetTracer(tracer Tracer) {
+	if c.shared {
+		panic("Cached ciphers cannot be changed.")
+	}
+	c.tracer = tracer
+}
+
+// hook is called after each transformation. It passes the state to the fault injector and then
+// reports the step to the tracer.
+func (c *AESCipher) hook(round int, step string, data []byte) {
+	if c.injector != nil && step != "k_sch" && step != "ik_sch" {
+		c.injector.Inject(round, step, data)
+	}
+	if c.tracer != nil {
+		c.tracer.Trace(round, step, data)
+	}
+}
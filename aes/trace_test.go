@@ -0,0 +1,57 @@
+package aes
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestTraceWriter(t *testing.T) {
+	var keys [][]byte = [][]byte{
+		[]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f},
+		[]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17},
+		[]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f},
+	}
+	// FIPS-197 Appendix C.1, C.2 and C.3
+	var golden []string = []string{
+		"testdata/fips197_c1.txt",
+		"testdata/fips197_c2.txt",
+		"testdata/fips197_c3.txt",
+	}
+	input := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	for i := range keys {
+		expected, err := ioutil.ReadFile(golden[i])
+		if err != nil {
+			t.Fatalf("Error reading golden file %s: %s", golden[i], err.Error())
+		}
+		cipher, err := NewCipher(keys[i])
+		if err != nil {
+			t.Errorf("Error creating new cipher for key %d: %s", i, err.Error())
+			continue
+		}
+		var buffer bytes.Buffer
+		cipher.SetTracer(NewTraceWriter(&buffer))
+		encrypted := make([]byte, 16)
+		cipher.Encrypt(input, encrypted)
+		buffer.WriteString("\n")
+		cipher.Decrypt(encrypted, make([]byte, 16))
+		if !bytes.Equal(buffer.Bytes(), expected) {
+			t.Errorf("Invalid trace for key %d. Expected:\n%s\nGot:\n%s", i, expected, buffer.String())
+		}
+	}
+}
+
+func TestSetTracerNil(t *testing.T) {
+	key := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f}
+	cipher, err := NewCipher(key)
+	if err != nil {
+		t.Fatal("Error creating cipher")
+	}
+	var buffer bytes.Buffer
+	cipher.SetTracer(NewTraceWriter(&buffer))
+	cipher.SetTracer(nil)
+	cipher.Encrypt(make([]byte, 16), make([]byte, 16))
+	if buffer.Len() != 0 {
+		t.Errorf("Tracer called after being disabled")
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bufio"
+	stdaes "crypto/aes"
+	"crypto/cipher"
+	"encoding/json"
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type benchCommand struct {
+	Sizes      []int         `short:"s" long:"size" description:"Buffer size in bytes, a multiple of 16 (repeatable, default 16 B to 16 MiB)"`
+	KeyLengths []int         `short:"l" long:"key-length" description:"Key length in bits (repeatable, default all)" choice:"128" choice:"192" choice:"256"`
+	Backends   []string      `short:"b" long:"backend" description:"Cipher implementation: reference or masked (repeatable, default all)"`
+	Modes      []string      `short:"m" long:"mode" description:"Mode of operation: ecb, cbc or ctr (repeatable, default all)"`
+	Duration   time.Duration `short:"t" long:"time" description:"Minimum measuring time of each case" default:"200ms"`
+	GHz        float64       `long:"ghz" description:"CPU frequency for the cycles/byte estimate, read from /proc/cpuinfo by default"`
+	Baseline   bool          `long:"baseline" description:"Also measures Go's crypto/aes block through the same modes, and through the crypto/cipher CBC and CTR modes"`
+	JSON       bool          `long:"json" description:"Outputs the results as JSON"`
+}
+
+func init() {
+	parser.AddCommand(
+		"bench",
+		"Measures the throughput of the cipher",
+		"Measures the encryption throughput in MB/s, and estimates the cycles per byte, for each key size, backend and mode of operation over buffers of 16 B to 16 MiB, on a single core and on every core in parallel.",
+		&benchCommand{},
+	)
+}
+
+// benchResult is a measured case
+type benchResult struct {
+	Backend       string  `json:"backend"`
+	KeyLength     int     `json:"keyLength"`
+	Mode          string  `json:"mode"`
+	Size          int     `json:"size"`
+	Cores         int     `json:"cores"`
+	MBPerSecond   float64 `json:"mbPerSecond"`
+	CyclesPerByte float64 `json:"cyclesPerByte,omitempty"`
+}
+
+// stdBlock adapts a crypto/cipher block to modes.Cipher
+type stdBlock struct {
+	cipher.Block
+}
+
+func (b stdBlock) Encrypt(block, dest []byte) {
+	b.Block.Encrypt(dest, block)
+}
+
+func (b stdBlock) Decrypt(block, dest []byte) {
+	b.Block.Decrypt(dest, block)
+}
+
+// benchBackends are the measured implementations, including the crypto/aes baseline
+var benchBackends = map[string]func(key []byte) (modes.Cipher, error){
+	"reference": func(key []byte) (modes.Cipher, error) { return backends["reference"](key) },
+	"masked":    func(key []byte) (modes.Cipher, error) { return backends["masked"](key) },
+	"crypto/aes": func(key []byte) (modes.Cipher, error) {
+		block, err := stdaes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return stdBlock{block}, nil
+	},
+}
+
+// stdModes are the crypto/cipher modes measured by the crypto/cipher baseline, encrypting a whole buffer
+// at a time. crypto/cipher has no ECB mode.
+var stdModes = map[string]func(block cipher.Block, iv []byte) func(buffer []byte){
+	"cbc": func(block cipher.Block, iv []byte) func(buffer []byte) {
+		mode := cipher.NewCBCEncrypter(block, iv)
+		return func(buffer []byte) { mode.CryptBlocks(buffer, buffer) }
+	},
+	"ctr": func(block cipher.Block, iv []byte) func(buffer []byte) {
+		stream := cipher.NewCTR(block, iv)
+		return func(buffer []byte) { stream.XORKeyStream(buffer, buffer) }
+	},
+}
+
+// cpuGHz reads the frequency of the first processor from /proc/cpuinfo, or returns 0
+func cpuGHz() float64 {
+	file, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, value, found := strings.Cut(scanner.Text(), ":")
+		if found && strings.TrimSpace(name) == "cpu MHz" {
+			mhz, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return 0
+			}
+			return mhz / 1000
+		}
+	}
+	return 0
+}
+
+// encryptBuffer encrypts the buffer in place block by block with the mode
+func encryptBuffer(mode modes.CipherMode, buffer []byte) {
+	size := mode.BlockSize()
+	for i := 0; i+size <= len(buffer); i += size {
+		mode.Encrypt(buffer[i:i+size], buffer[i:i+size])
+	}
+}
+
+// benchEncrypter returns the function encrypting a buffer in place with the backend and the mode. The
+// throughput does not depend on the IV value, which is zero.
+func benchEncrypter(backend string, key []byte, mode string) (func(buffer []byte), error) {
+	if backend == "crypto/cipher" {
+		block, err := stdaes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return stdModes[mode](block, make([]byte, block.BlockSize())), nil
+	}
+	block, err := benchBackends[backend](key)
+	if err != nil {
+		return nil, err
+	}
+	cipherMode, err := cipherModes[mode].create(block, make([]byte, block.BlockSize()))
+	if err != nil {
+		return nil, err
+	}
+	return func(buffer []byte) { encryptBuffer(cipherMode, buffer) }, nil
+}
+
+// measure encrypts buffers of size bytes on each of the workers until the duration elapses, returning
+// the throughput in MB/s
+func (c *benchCommand) measure(backend string, key []byte, mode string, size, workers int) (float64, error) {
+	encrypters := make([]func(buffer []byte), workers)
+	for i := range encrypters {
+		var err error
+		if encrypters[i], err = benchEncrypter(backend, key, mode); err != nil {
+			return 0, err
+		}
+	}
+	var wg sync.WaitGroup
+	passes := make([]int, workers)
+	start := time.Now()
+	deadline := start.Add(c.Duration)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			buffer := make([]byte, size)
+			for passes[i] == 0 || time.Now().Before(deadline) {
+				encrypters[i](buffer)
+				passes[i]++
+			}
+		}(i)
+	}
+	wg.Wait()
+	elapsed := time.Since(start).Seconds()
+	total := 0
+	for _, n := range passes {
+		total += n * size
+	}
+	return float64(total) / elapsed / 1e6, nil
+}
+
+func (c *benchCommand) Execute(args []string) error {
+	sizes := c.Sizes
+	if len(sizes) == 0 {
+		for size := 16; size <= 16<<20; size <<= 4 {
+			sizes = append(sizes, size)
+		}
+	}
+	for _, size := range sizes {
+		if size <= 0 || size%16 != 0 {
+			return fmt.Errorf("Invalid buffer size %d. It must be a positive multiple of 16", size)
+		}
+	}
+	keyLengths := c.KeyLengths
+	if len(keyLengths) == 0 {
+		keyLengths = []int{128, 192, 256}
+	}
+	var allBackends []string
+	for name := range backends {
+		allBackends = append(allBackends, name)
+	}
+	sort.Strings(allBackends)
+	names := c.Backends
+	if len(names) == 0 {
+		names = allBackends
+	}
+	for _, name := range names {
+		if _, ok := backends[name]; !ok {
+			return fmt.Errorf("Invalid backend %s. Allowed: %s", name, strings.Join(allBackends, ", "))
+		}
+	}
+	if c.Baseline {
+		names = append(names, "crypto/aes", "crypto/cipher")
+	}
+	var allModes []string
+	for name := range cipherModes {
+		allModes = append(allModes, name)
+	}
+	sort.Strings(allModes)
+	modeNames := c.Modes
+	if len(modeNames) == 0 {
+		modeNames = allModes
+	}
+	for _, name := range modeNames {
+		if _, ok := cipherModes[name]; !ok {
+			return fmt.Errorf("Invalid mode %s. Allowed: %s", name, strings.Join(allModes, ", "))
+		}
+	}
+	ghz := c.GHz
+	if ghz == 0 {
+		ghz = cpuGHz()
+	}
+	cores := []int{1}
+	if n := runtime.GOMAXPROCS(0); n > 1 {
+		cores = append(cores, n)
+	}
+	if !c.JSON {
+		fmt.Printf("%-13s %4s %-4s %10s %5s %10s %10s\n", "backend", "key", "mode", "size", "cores", "MB/s", "cycles/B")
+	}
+	var results []benchResult
+	for _, name := range names {
+		for _, keyLength := range keyLengths {
+			key := make([]byte, keyLength/8)
+			if err := readRandom(key); err != nil {
+				return err
+			}
+			for _, mode := range modeNames {
+				if _, ok := stdModes[mode]; name == "crypto/cipher" && !ok {
+					continue
+				}
+				for _, size := range sizes {
+					for _, n := range cores {
+						mbs, err := c.measure(name, key, mode, size, n)
+						if err != nil {
+							return err
+						}
+						result := benchResult{Backend: name, KeyLength: keyLength, Mode: mode, Size: size, Cores: n, MBPerSecond: mbs}
+						// every core processes its own bytes, so a byte costs the cycles of one core
+						if ghz > 0 {
+							result.CyclesPerByte = ghz * 1e3 * float64(n) / mbs
+						}
+						results = append(results, result)
+						if !c.JSON {
+							cycles := "-"
+							if result.CyclesPerByte > 0 {
+								cycles = fmt.Sprintf("%.1f", result.CyclesPerByte)
+							}
+							fmt.Printf("%-13s %4d %-4s %10s %5d %10.2f %10s\n", name, keyLength, mode, formatSize(size), n, mbs, cycles)
+						}
+					}
+				}
+			}
+		}
+	}
+	if c.JSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+	return nil
+}
+
+// formatSize returns the size in B, KiB or MiB
+func formatSize(size int) string {
+	switch {
+	case size >= 1<<20 && size%(1<<20) == 0:
+		return fmt.Sprintf("%d MiB", size>>20)
+	case size >= 1<<10 && size%(1<<10) == 0:
+		return fmt.Sprintf("%d KiB", size>>10)
+	}
+	return fmt.Sprintf("%d B", size)
+}
@@ -0,0 +1,220 @@
+// Package camellia implements the Camellia block cipher (RFC 3713), an 18-round (128-bit keys) or
+// 24-round (192 and 256-bit keys) Feistel network with 128-bit blocks and FL/FL^-1 layers every six
+// rounds.
+package camellia
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+// BlockSize is the Camellia block size in bytes
+const BlockSize = 16
+
+var sBox1 = []byte{
+	0x70, 0x82, 0x2c, 0xec, 0xb3, 0x27, 0xc0, 0xe5, 0xe4, 0x85, 0x57, 0x35, 0xea, 0x0c, 0xae, 0x41,
+	0x23, 0xef, 0x6b, 0x93, 0x45, 0x19, 0xa5, 0x21, 0xed, 0x0e, 0x4f, 0x4e, 0x1d, 0x65, 0x92, 0xbd,
+	0x86, 0xb8, 0xaf, 0x8f, 0x7c, 0xeb, 0x1f, 0xce, 0x3e, 0x30, 0xdc, 0x5f, 0x5e, 0xc5, 0x0b, 0x1a,
+	0xa6, 0xe1, 0x39, 0xca, 0xd5, 0x47, 0x5d, 0x3d, 0xd9, 0x01, 0x5a, 0xd6, 0x51, 0x56, 0x6c, 0x4d,
+	0x8b, 0x0d, 0x9a, 0x66, 0xfb, 0xcc, 0xb0, 0x2d, 0x74, 0x12, 0x2b, 0x20, 0xf0, 0xb1, 0x84, 0x99,
+	0xdf, 0x4c, 0xcb, 0xc2, 0x34, 0x7e, 0x76, 0x05, 0x6d, 0xb7, 0xa9, 0x31, 0xd1, 0x17, 0x04, 0xd7,
+	0x14, 0x58, 0x3a, 0x61, 0xde, 0x1b, 0x11, 0x1c, 0x32, 0x0f, 0x9c, 0x16, 0x53, 0x18, 0xf2, 0x22,
+	0xfe, 0x44, 0xcf, 0xb2, 0xc3, 0xb5, 0x7a, 0x91, 0x24, 0x08, 0xe8, 0xa8, 0x60, 0xfc, 0x69, 0x50,
+	0xaa, 0xd0, 0xa0, 0x7d, 0xa1, 0x89, 0x62, 0x97, 0x54, 0x5b, 0x1e, 0x95, 0xe0, 0xff, 0x64, 0xd2,
+	0x10, 0xc4, 0x00, 0x48, 0xa3, 0xf7, 0x75, 0xdb, 0x8a, 0x03, 0xe6, 0xda, 0x09, 0x3f, 0xdd, 0x94,
+	0x87, 0x5c, 0x83, 0x02, 0xcd, 0x4a, 0x90, 0x33, 0x73, 0x67, 0xf6, 0xf3, 0x9d, 0x7f, 0xbf, 0xe2,
+	0x52, 0x9b, 0xd8, 0x26, 0xc8, 0x37, 0xc6, 0x3b, 0x81, 0x96, 0x6f, 0x4b, 0x13, 0xbe, 0x63, 0x2e,
+	0xe9, 0x79, 0xa7, 0x8c, 0x9f, 0x6e, 0xbc, 0x8e, 0x29, 0xf5, 0xf9, 0xb6, 0x2f, 0xfd, 0xb4, 0x59,
+	0x78, 0x98, 0x06, 0x6a, 0xe7, 0x46, 0x71, 0xba, 0xd4, 0x25, 0xab, 0x42, 0x88, 0xa2, 0x8d, 0xfa,
+	0x72, 0x07, 0xb9, 0x55, 0xf8, 0xee, 0xac, 0x0a, 0x36, 0x49, 0x2a, 0x68, 0x3c, 0x38, 0xf1, 0xa4,
+	0x40, 0x28, 0xd3, 0x7b, 0xbb, 0xc9, 0x43, 0xc1, 0x15, 0xe3, 0xad, 0xf4, 0x77, 0xc7, 0x80, 0x9e,
+}
+
+// sigma are the constants of the key schedule
+var sigma = []uint64{
+	0xa09e667f3bcc908b, 0xb67ae8584caa73b2, 0xc6ef372fe94f82be,
+	0x54ff53a5f1d36f1c, 0x10e527fade682d1d, 0xb05688c2b3e6c1fd,
+}
+
+// subkeys are the whitening keys kw, the round keys k and the FL/FL^-1 keys ke in the order used
+type subkeys struct {
+	kw [4]uint64
+	k  []uint64
+	ke []uint64
+}
+
+// CamelliaCipher is the Camellia cipher
+type CamelliaCipher struct {
+	encKeys subkeys
+	decKeys subkeys
+}
+
+// uint128 is a 128-bit value split in its high and low halves
+type uint128 struct {
+	hi, lo uint64
+}
+
+// rotl returns x rotated left by n bits (0 <= n < 128)
+func (x uint128) rotl(n uint) uint128 {
+	if n >= 64 {
+		x.hi, x.lo = x.lo, x.hi
+		n -= 64
+	}
+	if n == 0 {
+		return x
+	}
+	return uint128{x.hi<<n | x.lo>>(64-n), x.lo<<n | x.hi>>(64-n)}
+}
+
+func (x uint128) xor(y uint128) uint128 {
+	return uint128{x.hi ^ y.hi, x.lo ^ y.lo}
+}
+
+// NewCipher creates a new Camellia cipher using the key specified
+// Allowed key lengths: 16, 24 and 32 bytes
+func NewCipher(key []byte) (*CamelliaCipher, error) {
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return nil, errors.New("Invalid key length. Allowed lengths: 128-bit (16 bytes), 192-bit (24 bytes), 256-bit (32 bytes)")
+	}
+	kl := uint128{binary.BigEndian.Uint64(key[0:8]), binary.BigEndian.Uint64(key[8:16])}
+	var kr uint128
+	switch len(key) {
+	case 24:
+		kr.hi = binary.BigEndian.Uint64(key[16:24])
+		kr.lo = ^kr.hi
+	case 32:
+		kr = uint128{binary.BigEndian.Uint64(key[16:24]), binary.BigEndian.Uint64(key[24:32])}
+	}
+	d := kl.xor(kr)
+	d.lo ^= f(d.hi, sigma[0])
+	d.hi ^= f(d.lo, sigma[1])
+	d = d.xor(kl)
+	d.lo ^= f(d.hi, sigma[2])
+	d.hi ^= f(d.lo, sigma[3])
+	ka := d
+	c := new(CamelliaCipher)
+	if len(key) == 16 {
+		c.encKeys.kw = [4]uint64{kl.hi, kl.lo, ka.rotl(111).hi, ka.rotl(111).lo}
+		c.encKeys.k = []uint64{
+			ka.hi, ka.lo, kl.rotl(15).hi, kl.rotl(15).lo, ka.rotl(15).hi, ka.rotl(15).lo,
+			kl.rotl(45).hi, kl.rotl(45).lo, ka.rotl(45).hi, kl.rotl(60).lo, ka.rotl(60).hi, ka.rotl(60).lo,
+			kl.rotl(94).hi, kl.rotl(94).lo, ka.rotl(94).hi, ka.rotl(94).lo, kl.rotl(111).hi, kl.rotl(111).lo,
+		}
+		c.encKeys.ke = []uint64{ka.rotl(30).hi, ka.rotl(30).lo, kl.rotl(77).hi, kl.rotl(77).lo}
+	} else {
+		d = ka.xor(kr)
+		d.lo ^= f(d.hi, sigma[4])
+		d.hi ^= f(d.lo, sigma[5])
+		kb := d
+		c.encKeys.kw = [4]uint64{kl.hi, kl.lo, kb.rotl(111).hi, kb.rotl(111).lo}
+		c.encKeys.k = []uint64{
+			kb.hi, kb.lo, kr.rotl(15).hi, kr.rotl(15).lo, ka.rotl(15).hi, ka.rotl(15).lo,
+			kb.rotl(30).hi, kb.rotl(30).lo, kl.rotl(45).hi, kl.rotl(45).lo, ka.rotl(45).hi, ka.rotl(45).lo,
+			kr.rotl(60).hi, kr.rotl(60).lo, kb.rotl(60).hi, kb.rotl(60).lo, kl.rotl(77).hi, kl.rotl(77).lo,
+			kr.rotl(94).hi, kr.rotl(94).lo, ka.rotl(94).hi, ka.rotl(94).lo, kl.rotl(111).hi, kl.rotl(111).lo,
+		}
+		c.encKeys.ke = []uint64{
+			kr.rotl(30).hi, kr.rotl(30).lo, kl.rotl(60).hi, kl.rotl(60).lo, ka.rotl(77).hi, ka.rotl(77).lo,
+		}
+	}
+	c.decKeys = reverse(c.encKeys)
+	return c, nil
+}
+
+// reverse returns the subkeys in the order used for decryption
+func reverse(enc subkeys) subkeys {
+	var dec subkeys
+	dec.kw = [4]uint64{enc.kw[2], enc.kw[3], enc.kw[0], enc.kw[1]}
+	dec.k = make([]uint64, len(enc.k))
+	for i := range enc.k {
+		dec.k[i] = enc.k[len(enc.k)-1-i]
+	}
+	dec.ke = make([]uint64, len(enc.ke))
+	for i := range enc.ke {
+		dec.ke[i] = enc.ke[len(enc.ke)-1-i]
+	}
+	return dec
+}
+
+func sBox2(x byte) byte {
+	return bits.RotateLeft8(sBox1[x], 1)
+}
+
+func sBox3(x byte) byte {
+	return bits.RotateLeft8(sBox1[x], 7)
+}
+
+func sBox4(x byte) byte {
+	return sBox1[bits.RotateLeft8(x, 1)]
+}
+
+// f is the round function: the S-box layer followed by the byte mixing P-function
+func f(in, key uint64) uint64 {
+	x := in ^ key
+	t1 := sBox1[byte(x>>56)]
+	t2 := sBox2(byte(x >> 48))
+	t3 := sBox3(byte(x >> 40))
+	t4 := sBox4(byte(x >> 32))
+	t5 := sBox2(byte(x >> 24))
+	t6 := sBox3(byte(x >> 16))
+	t7 := sBox4(byte(x >> 8))
+	t8 := sBox1[byte(x)]
+	y1 := t1 ^ t3 ^ t4 ^ t6 ^ t7 ^ t8
+	y2 := t1 ^ t2 ^ t4 ^ t5 ^ t7 ^ t8
+	y3 := t1 ^ t2 ^ t3 ^ t5 ^ t6 ^ t8
+	y4 := t2 ^ t3 ^ t4 ^ t5 ^ t6 ^ t7
+	y5 := t1 ^ t2 ^ t6 ^ t7 ^ t8
+	y6 := t2 ^ t3 ^ t5 ^ t7 ^ t8
+	y7 := t3 ^ t4 ^ t5 ^ t6 ^ t8
+	y8 := t1 ^ t4 ^ t5 ^ t6 ^ t7
+	return uint64(y1)<<56 | uint64(y2)<<48 | uint64(y3)<<40 | uint64(y4)<<32 |
+		uint64(y5)<<24 | uint64(y6)<<16 | uint64(y7)<<8 | uint64(y8)
+}
+
+func fl(x, key uint64) uint64 {
+	x1, x2 := uint32(x>>32), uint32(x)
+	k1, k2 := uint32(key>>32), uint32(key)
+	x2 ^= bits.RotateLeft32(x1&k1, 1)
+	x1 ^= x2 | k2
+	return uint64(x1)<<32 | uint64(x2)
+}
+
+func flInv(y, key uint64) uint64 {
+	y1, y2 := uint32(y>>32), uint32(y)
+	k1, k2 := uint32(key>>32), uint32(key)
+	y1 ^= y2 | k2
+	y2 ^= bits.RotateLeft32(y1&k1, 1)
+	return uint64(y1)<<32 | uint64(y2)
+}
+
+// crypt runs the Feistel network with the subkeys, six rounds between each FL/FL^-1 layer
+func crypt(keys *subkeys, block, dest []byte) {
+	d1 := binary.BigEndian.Uint64(block[0:8]) ^ keys.kw[0]
+	d2 := binary.BigEndian.Uint64(block[8:16]) ^ keys.kw[1]
+	for i := 0; i < len(keys.k); i += 2 {
+		if i > 0 && i%6 == 0 {
+			d1 = fl(d1, keys.ke[i/3-2])
+			d2 = flInv(d2, keys.ke[i/3-1])
+		}
+		d2 ^= f(d1, keys.k[i])
+		d1 ^= f(d2, keys.k[i+1])
+	}
+	binary.BigEndian.PutUint64(dest[0:8], d2^keys.kw[2])
+	binary.BigEndian.PutUint64(dest[8:16], d1^keys.kw[3])
+}
+
+// Encrypt encrypts a 16-byte block into dest
+func (c *CamelliaCipher) Encrypt(block, dest []byte) {
+	crypt(&c.encKeys, block, dest)
+}
+
+// Decrypt decrypts a 16-byte block into dest
+func (c *CamelliaCipher) Decrypt(block, dest []byte) {
+	crypt(&c.decKeys, block, dest)
+}
+
+// BlockSize returns the block size of Camellia
+func (c *CamelliaCipher) BlockSize() int {
+	return BlockSize
+}
@@ -0,0 +1,44 @@
+package camellia
+
+import (
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"github.com/emanuelzabka/crypt-aes/modes/ciphertest"
+	"testing"
+)
+
+func newCipher(key []byte) (modes.Cipher, error) {
+	return NewCipher(key)
+}
+
+// RFC 3713 appendix A
+var rfc3713Vectors = []ciphertest.Vector{
+	{
+		Key:        "0123456789abcdeffedcba9876543210",
+		Plaintext:  "0123456789abcdeffedcba9876543210",
+		Ciphertext: "67673138549669730857065648eabe43",
+	},
+	{
+		Key:        "0123456789abcdeffedcba98765432100011223344556677",
+		Plaintext:  "0123456789abcdeffedcba9876543210",
+		Ciphertext: "b4993401b3e996f84ee5cee7d79b09b9",
+	},
+	{
+		Key:        "0123456789abcdeffedcba987654321000112233445566778899aabbccddeeff",
+		Plaintext:  "0123456789abcdeffedcba9876543210",
+		Ciphertext: "9acc237dff16d76c20ef7c919e3a7509",
+	},
+}
+
+func TestVectors(t *testing.T) {
+	ciphertest.CheckVectors(t, newCipher, rfc3713Vectors)
+}
+
+func TestRoundTrip(t *testing.T) {
+	ciphertest.CheckRoundTrip(t, newCipher, []int{16, 24, 32})
+}
+
+func TestKeyLength(t *testing.T) {
+	if _, err := NewCipher(make([]byte, 20)); err == nil {
+		t.Errorf("Accepting invalid key length")
+	}
+}
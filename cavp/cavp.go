@@ -0,0 +1,489 @@
+// Package cavp parses the NIST CAVP response (.rsp) files of the AES Algorithm Validation Suite
+// (AESAVS) and runs them against aes.AESCipher and the modes of operation.
+//
+// The file name selects the mode and the test: ECBGFSbox128.rsp, CBCMMT256.rsp, OFBMCT192.rsp, etc.
+// The known-answer tests (GFSbox, KeySbox, VarKey and VarTxt) and the multi-block message tests (MMT)
+// encrypt or decrypt each record; the Monte Carlo tests (MCT) follow the AESAVS iteration rules of each
+// mode. The CFB1 files write the plaintext and ciphertext as strings of bits.
+//
+// The embedded files are not the NIST ones: they are the known-answer tests rebuilt from the inputs
+// listed in the AESAVS appendices. The official archives, which add the MMT and MCT files, can be run
+// from a directory with RunDir.
+package cavp
+
+import (
+	"bufio"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"github.com/emanuelzabka/crypt-aes/modes/cbc"
+	"github.com/emanuelzabka/crypt-aes/modes/cfb"
+	"github.com/emanuelzabka/crypt-aes/modes/ecb"
+	"github.com/emanuelzabka/crypt-aes/modes/ofb"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//go:embed vectors/*.rsp
+var vectors embed.FS
+
+// Record is a test case, holding the fields by name (COUNT, KEY, IV, PLAINTEXT, CIPHERTEXT)
+type Record struct {
+	Line   int
+	Fields map[string]string
+}
+
+// Section is a group of records under a [ENCRYPT] or [DECRYPT] header
+type Section struct {
+	Name    string
+	Records []*Record
+}
+
+// File is a parsed .rsp file
+type File struct {
+	Name     string
+	Comments []string
+	Sections []*Section
+}
+
+// Parse reads a .rsp file. Records are separated by blank lines and belong to the last section header.
+func Parse(name string, r io.Reader) (*File, error) {
+	f := new(File)
+	f.Name = name
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var section *Section
+	var record *Record
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "":
+			record = nil
+		case strings.HasPrefix(text, "#"):
+			f.Comments = append(f.Comments, strings.TrimSpace(text[1:]))
+		case strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]"):
+			section = &Section{Name: text[1 : len(text)-1]}
+			f.Sections = append(f.Sections, section)
+			record = nil
+		default:
+			parts := strings.SplitN(text, "=", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("%s:%d: Invalid line: %s", name, line, text)
+			}
+			if section == nil {
+				return nil, fmt.Errorf("%s:%d: Record outside a section", name, line)
+			}
+			if record == nil {
+				record = &Record{Line: line, Fields: make(map[string]string)}
+				section.Records = append(section.Records, record)
+			}
+			record.Fields[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// bytes returns the decoded hexadecimal field
+func (r *Record) bytes(field string) ([]byte, error) {
+	value, ok := r.Fields[field]
+	if !ok {
+		return nil, fmt.Errorf("Missing field %s", field)
+	}
+	return hex.DecodeString(value)
+}
+
+// Failure is a record whose result differs from the expected one
+type Failure struct {
+	File    string
+	Section string
+	Count   string
+	Line    int
+	Err     error
+}
+
+func (f Failure) String() string {
+	return fmt.Sprintf("%s:%d [%s] COUNT = %s: %s", f.File, f.Line, f.Section, f.Count, f.Err.Error())
+}
+
+// Report is the result of running one or more files
+type Report struct {
+	Passed   int
+	Failed   int
+	Skipped  []string
+	Failures []Failure
+}
+
+// Add merges other into the report
+func (r *Report) Add(other *Report) {
+	r.Passed += other.Passed
+	r.Failed += other.Failed
+	r.Skipped = append(r.Skipped, other.Skipped...)
+	r.Failures = append(r.Failures, other.Failures...)
+}
+
+var fileName = regexp.MustCompile(`^(ECB|CBC|CFB1|CFB8|CFB128|OFB)(GFSbox|KeySbox|VarKey|VarTxt|MMT|MCT)(128|192|256)\.rsp$`)
+
+// Run runs every record of the file
+func Run(f *File) *Report {
+	report := new(Report)
+	match := fileName.FindStringSubmatch(path.Base(f.Name))
+	if match == nil {
+		report.Skipped = append(report.Skipped, fmt.Sprintf("%s: unknown test file", f.Name))
+		return report
+	}
+	mode, test := match[1], match[2]
+	for _, section := range f.Sections {
+		for i, record := range section.Records {
+			var err error
+			switch {
+			case section.Name != "ENCRYPT" && section.Name != "DECRYPT":
+				err = fmt.Errorf("Unknown section %s", section.Name)
+			case test == "MCT":
+				var next *Record
+				if i+1 < len(section.Records) {
+					next = section.Records[i+1]
+				}
+				err = runMonteCarlo(mode, record, next, section.Name == "DECRYPT")
+			default:
+				err = runRecord(mode, record, section.Name == "DECRYPT")
+			}
+			if err != nil {
+				report.Failed++
+				report.Failures = append(report.Failures, Failure{f.Name, section.Name, record.Fields["COUNT"], record.Line, err})
+			} else {
+				report.Passed++
+			}
+		}
+	}
+	return report
+}
+
+// unit is the size of the data processed at a time: a block, a byte for CFB8 and a bit for CFB1, held in
+// a byte
+func unit(mode string) int {
+	if mode == "CFB1" || mode == "CFB8" {
+		return 1
+	}
+	return 16
+}
+
+// segmentSize returns the bits processed at a time by the mode
+func segmentSize(mode string) int {
+	switch mode {
+	case "CFB1":
+		return 1
+	case "CFB8":
+		return 8
+	}
+	return 128
+}
+
+// data returns the decoded plaintext or ciphertext field, one bit per byte for CFB1
+func (r *Record) data(mode, field string) ([]byte, error) {
+	if mode != "CFB1" {
+		return r.bytes(field)
+	}
+	value, ok := r.Fields[field]
+	if !ok {
+		return nil, fmt.Errorf("Missing field %s", field)
+	}
+	bits := make([]byte, len(value))
+	for i, ch := range value {
+		if ch != '0' && ch != '1' {
+			return nil, fmt.Errorf("Invalid bit string %s", value)
+		}
+		bits[i] = byte(ch - '0')
+	}
+	return bits, nil
+}
+
+// format returns the data as written in the files of the mode
+func format(mode string, data []byte) string {
+	if mode != "CFB1" {
+		return hex.EncodeToString(data)
+	}
+	bits := make([]byte, len(data))
+	for i, b := range data {
+		bits[i] = '0' + b
+	}
+	return string(bits)
+}
+
+// processor returns the function encrypting or decrypting a unit of the mode with the key and iv. iv
+// is ignored by ECB.
+func processor(mode string, key, iv []byte, decrypt bool) (func(input, output []byte), error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	var cipher modes.Cipher
+	switch mode {
+	case "ECB":
+		cipher = ecb.NewMode(c)
+	case "CBC":
+		cipher, err = cbc.NewMode(c, iv)
+	case "OFB":
+		cipher, err = ofb.NewMode(c, iv)
+	case "CFB8":
+		cipher, err = cfb.NewMode(c, iv, 8)
+	case "CFB128":
+		cipher, err = cfb.NewMode(c, iv, 128)
+	case "CFB1":
+		bits, err := cfb.NewMode(c, iv, 1)
+		if err != nil {
+			return nil, err
+		}
+		if decrypt {
+			return func(input, output []byte) { output[0] = bits.DecryptBit(input[0]) }, nil
+		}
+		return func(input, output []byte) { output[0] = bits.EncryptBit(input[0]) }, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if decrypt {
+		return cipher.Decrypt, nil
+	}
+	return cipher.Encrypt, nil
+}
+
+// recordProcessor returns the processor of the record key and IV with the input and expected output of
+// the operation
+func recordProcessor(mode string, r *Record, decrypt bool) (process func(input, output []byte), input, expected []byte, err error) {
+	key, err := r.bytes("KEY")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var iv []byte
+	if mode != "ECB" {
+		if iv, err = r.bytes("IV"); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	plaintext, err := r.data(mode, "PLAINTEXT")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ciphertext, err := r.data(mode, "CIPHERTEXT")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(plaintext) != len(ciphertext) || len(plaintext)%unit(mode) != 0 || len(plaintext) == 0 {
+		return nil, nil, nil, errors.New("Invalid message length")
+	}
+	if process, err = processor(mode, key, iv, decrypt); err != nil {
+		return nil, nil, nil, err
+	}
+	if decrypt {
+		return process, ciphertext, plaintext, nil
+	}
+	return process, plaintext, ciphertext, nil
+}
+
+func runRecord(mode string, r *Record, decrypt bool) error {
+	process, input, expected, err := recordProcessor(mode, r, decrypt)
+	if err != nil {
+		return err
+	}
+	size := unit(mode)
+	output := make([]byte, len(input))
+	for i := 0; i < len(input); i += size {
+		process(input[i:i+size], output[i:i+size])
+	}
+	if format(mode, output) != format(mode, expected) {
+		return fmt.Errorf("Expected: %s Got: %s", format(mode, expected), format(mode, output))
+	}
+	return nil
+}
+
+// runMonteCarlo checks a record of the Monte Carlo test: the output is the result of 1000 chained
+// operations on the input with the record key and IV, and the next record, if any, must start from the
+// key, IV and input derived by the outer iteration
+func runMonteCarlo(mode string, r, next *Record, decrypt bool) error {
+	process, input, expected, err := recordProcessor(mode, r, decrypt)
+	if err != nil {
+		return err
+	}
+	if len(input) != unit(mode) {
+		return errors.New("Invalid Monte Carlo input length")
+	}
+	key, _ := r.bytes("KEY")
+	var outputs [][]byte
+	if mode == "ECB" {
+		last, previous := MonteCarloECB(process, input)
+		outputs = [][]byte{previous, last}
+	} else {
+		iv, _ := r.bytes("IV")
+		outputs = MonteCarloChained(process, iv, input, segmentSize(mode))
+	}
+	output := outputs[len(outputs)-1]
+	if format(mode, output) != format(mode, expected) {
+		return fmt.Errorf("Expected: %s Got: %s", format(mode, expected), format(mode, output))
+	}
+	if next == nil {
+		return nil
+	}
+	stream := joinOutputs(mode, outputs)
+	nextKey, err := next.bytes("KEY")
+	if err != nil {
+		return err
+	}
+	if expectedKey := NextMonteCarloKey(key, stream); hex.EncodeToString(nextKey) != hex.EncodeToString(expectedKey) {
+		return fmt.Errorf("Invalid next key. Expected: %x Got: %x", expectedKey, nextKey)
+	}
+	nextInput := output
+	if mode != "ECB" {
+		// the next IV is the last block of output and the next input the output one block before the last
+		nextIV, err := next.bytes("IV")
+		if err != nil {
+			return err
+		}
+		if expectedIV := stream[len(stream)-16:]; hex.EncodeToString(nextIV) != hex.EncodeToString(expectedIV) {
+			return fmt.Errorf("Invalid next IV. Expected: %x Got: %x", expectedIV, nextIV)
+		}
+		nextInput = outputs[len(outputs)-1-128/segmentSize(mode)]
+	}
+	field := "PLAINTEXT"
+	if decrypt {
+		field = "CIPHERTEXT"
+	}
+	actualInput, err := next.data(mode, field)
+	if err != nil {
+		return err
+	}
+	if format(mode, actualInput) != format(mode, nextInput) {
+		return fmt.Errorf("Invalid next input. Expected: %s Got: %s", format(mode, nextInput), format(mode, actualInput))
+	}
+	return nil
+}
+
+// MonteCarloECB runs the 1000 inner iterations of the AESAVS ECB Monte Carlo test, each one processing
+// the output of the previous one, and returns the last output CT[999] and the previous one CT[998]
+func MonteCarloECB(process func(input, output []byte), input []byte) (last, previous []byte) {
+	last = make([]byte, 16)
+	previous = make([]byte, 16)
+	copy(last, input)
+	for j := 0; j < 1000; j++ {
+		copy(previous, last)
+		process(previous, last)
+	}
+	return last, previous
+}
+
+// MonteCarloChained runs the 1000 inner iterations of the AESAVS Monte Carlo test of the CBC, OFB and CFB
+// modes with segments of segmentSize bits, process keeping the chaining state, and returns every output.
+// With n segments in a block, the input of iteration j+1 is the segment j of the IV while j < n and the
+// output j+1-n after it: the IV and then the previous output for the block modes, the IV bytes or bits
+// and then the output of a block before for CFB8 and CFB1.
+func MonteCarloChained(process func(input, output []byte), iv, input []byte, segmentSize int) [][]byte {
+	n := 128 / segmentSize
+	outputs := make([][]byte, 1000)
+	for j := 0; j < 1000; j++ {
+		outputs[j] = make([]byte, len(input))
+		process(input, outputs[j])
+		switch {
+		case j >= n:
+			input = outputs[j-n]
+		case segmentSize == 128:
+			input = iv
+		case segmentSize == 8:
+			input = iv[j : j+1]
+		default:
+			input = []byte{iv[j/8] >> uint(7-j%8) & 1}
+		}
+	}
+	return outputs
+}
+
+// joinOutputs concatenates the outputs as the AESAVS data stream, packing the bits of CFB1 into bytes
+func joinOutputs(mode string, outputs [][]byte) []byte {
+	var stream []byte
+	if mode != "CFB1" {
+		for _, output := range outputs {
+			stream = append(stream, output...)
+		}
+		return stream
+	}
+	stream = make([]byte, len(outputs)/8)
+	// aligns the last bit with the end of the stream
+	offset := len(outputs) - 8*len(stream)
+	for i := range stream {
+		for j := 0; j < 8; j++ {
+			stream[i] |= outputs[offset+8*i+j][0] << uint(7-j)
+		}
+	}
+	return stream
+}
+
+// NextMonteCarloKey returns the key of the next Monte Carlo outer iteration: the key XORed with the
+// last 128, 192 or 256 bits of the outputs, CT[998] || CT[999] for the block modes
+func NextMonteCarloKey(key, outputs []byte) []byte {
+	tail := outputs[len(outputs)-len(key):]
+	next := make([]byte, len(key))
+	for i := range key {
+		next[i] = key[i] ^ tail[i]
+	}
+	return next
+}
+
+// Files returns the names of the embedded .rsp files
+func Files() []string {
+	entries, _ := vectors.ReadDir("vectors")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RunEmbedded runs every embedded .rsp file
+func RunEmbedded() (*Report, error) {
+	return runFiles(vectors, "vectors", Files())
+}
+
+// RunDir runs every .rsp file of the directory dir, such as the extracted NIST CAVP AES archives.
+// The files whose name is not of an AESAVS test are reported as skipped.
+func RunDir(dir string) (*Report, error) {
+	fsys := os.DirFS(dir)
+	names, err := fs.Glob(fsys, "*.rsp")
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("No .rsp file in %s", dir)
+	}
+	sort.Strings(names)
+	return runFiles(fsys, ".", names)
+}
+
+// runFiles parses and runs the named files of the directory dir of fsys
+func runFiles(fsys fs.FS, dir string, names []string) (*Report, error) {
+	report := new(Report)
+	for _, name := range names {
+		data, err := fsys.Open(path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		f, err := Parse(name, data)
+		data.Close()
+		if err != nil {
+			return nil, err
+		}
+		report.Add(Run(f))
+	}
+	return report, nil
+}
@@ -0,0 +1,130 @@
+package cavp
+
+import (
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmbedded(t *testing.T) {
+	report, err := RunEmbedded()
+	if err != nil {
+		t.Fatalf("Error running embedded files: %s", err.Error())
+	}
+	for _, failure := range report.Failures {
+		t.Errorf("%s", failure)
+	}
+	if report.Passed == 0 {
+		t.Errorf("No record tested")
+	}
+	if len(report.Skipped) != 0 {
+		t.Errorf("Skipped embedded files: %v", report.Skipped)
+	}
+}
+
+func TestRunDir(t *testing.T) {
+	dir := t.TempDir()
+	data := "[ENCRYPT]\nCOUNT = 0\nKEY = 00000000000000000000000000000000\nPLAINTEXT = f34481ec3cc627bacd5dc3fb08f273e6\nCIPHERTEXT = 0336763e966d92595a567cc9ce537f5e\n"
+	os.WriteFile(filepath.Join(dir, "ECBGFSbox128.rsp"), []byte(data), 0644)
+	os.WriteFile(filepath.Join(dir, "CTRGFSbox128.rsp"), []byte(data), 0644)
+	report, err := RunDir(dir)
+	if err != nil {
+		t.Fatalf("Error running directory: %s", err.Error())
+	}
+	if report.Passed != 1 || report.Failed != 0 || len(report.Skipped) != 1 {
+		t.Errorf("Invalid report. Passed: %d Failed: %d Skipped: %v", report.Passed, report.Failed, report.Skipped)
+	}
+	if _, err := RunDir(t.TempDir()); err == nil {
+		t.Errorf("Accepting a directory without .rsp files")
+	}
+}
+
+func TestParse(t *testing.T) {
+	data := "# comment\r\n\r\n[ENCRYPT]\r\n\r\nCOUNT = 0\r\nKEY = 00\r\n\r\nCOUNT = 1\r\nKEY = 01\r\n\r\n[DECRYPT]\r\n\r\nCOUNT = 0\r\nKEY = 02\r\n"
+	f, err := Parse("test.rsp", strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error parsing: %s", err.Error())
+	}
+	if len(f.Comments) != 1 || len(f.Sections) != 2 || len(f.Sections[0].Records) != 2 || len(f.Sections[1].Records) != 1 {
+		t.Fatalf("Invalid parsed structure")
+	}
+	if f.Sections[0].Records[1].Fields["KEY"] != "01" || f.Sections[1].Name != "DECRYPT" {
+		t.Errorf("Invalid parsed fields")
+	}
+	if _, err := Parse("test.rsp", strings.NewReader("KEY = 00\n")); err == nil {
+		t.Errorf("Accepting record outside a section")
+	}
+}
+
+func TestMismatch(t *testing.T) {
+	data := "[ENCRYPT]\nCOUNT = 0\nKEY = 00000000000000000000000000000000\nPLAINTEXT = f34481ec3cc627bacd5dc3fb08f273e6\nCIPHERTEXT = 0336763e966d92595a567cc9ce537f5f\n"
+	f, _ := Parse("ECBGFSbox128.rsp", strings.NewReader(data))
+	report := Run(f)
+	if report.Failed != 1 || report.Passed != 0 {
+		t.Errorf("Mismatch not reported")
+	}
+	f.Name = "CBCGFSbox128.rsp"
+	if report := Run(f); report.Failed != 1 || report.Failures[0].Err.Error() != "Missing field IV" {
+		t.Errorf("Missing IV not reported")
+	}
+	f.Name = "CTRGFSbox128.rsp"
+	if report := Run(f); len(report.Skipped) != 1 {
+		t.Errorf("Unknown test file not skipped")
+	}
+}
+
+// COUNT 0 of the ENCRYPT section of the NIST CBCMCT128.rsp
+func TestMonteCarloCBC(t *testing.T) {
+	data := "[ENCRYPT]\nCOUNT = 0\nKEY = 8809e7dd3a959ee5d8dbb13f501f2274\nIV = e5c0bb535d7d54572ad06d170a0e58ae\n" +
+		"PLAINTEXT = 1fd4ee65603e6130cfc2a82ab3d56c24\nCIPHERTEXT = b127a5b4c4692d87483db0c3b0d11e64\n"
+	f, _ := Parse("CBCMCT128.rsp", strings.NewReader(data))
+	if report := Run(f); report.Passed != 1 {
+		t.Errorf("Invalid CBC Monte Carlo record: %v", report.Failures)
+	}
+}
+
+// The CFB1 files write the data as bits. The segments are those of NIST SP 800-38A F.3.1.
+func TestCFB1Bits(t *testing.T) {
+	data := "[ENCRYPT]\nCOUNT = 0\nKEY = 2b7e151628aed2a6abf7158809cf4f3c\nIV = 000102030405060708090a0b0c0d0e0f\n" +
+		"PLAINTEXT = 0110101111000001\nCIPHERTEXT = 0110100010110011\n\n[DECRYPT]\nCOUNT = 0\n" +
+		"KEY = 2b7e151628aed2a6abf7158809cf4f3c\nIV = 000102030405060708090a0b0c0d0e0f\n" +
+		"CIPHERTEXT = 0110100010110011\nPLAINTEXT = 0110101111000001\n"
+	f, _ := Parse("CFB1MMT128.rsp", strings.NewReader(data))
+	if report := Run(f); report.Passed != 2 {
+		t.Errorf("Invalid CFB1 records: %v", report.Failures)
+	}
+	f.Sections[0].Records[0].Fields["PLAINTEXT"] = "01101011110000012"
+	if report := Run(f); report.Failed != 1 {
+		t.Errorf("Invalid bit string not reported")
+	}
+}
+
+// The records following the first of a Monte Carlo file are derived by the outer iteration
+func TestMonteCarloChain(t *testing.T) {
+	key, _ := hex.DecodeString("139a35422f1d61de3c91787fe0507afd")
+	input, _ := hex.DecodeString("b9145a768b7dc489a096b546f43b231f")
+	c, _ := aes.NewCipher(key)
+	last, previous := MonteCarloECB(c.Encrypt, input)
+	next := NextMonteCarloKey(key, append(previous, last...))
+	c2, _ := aes.NewCipher(next)
+	second, _ := MonteCarloECB(c2.Encrypt, last)
+	data := "[ENCRYPT]\nCOUNT = 0\nKEY = " + hex.EncodeToString(key) + "\nPLAINTEXT = " + hex.EncodeToString(input) +
+		"\nCIPHERTEXT = " + hex.EncodeToString(last) + "\n\nCOUNT = 1\nKEY = " + hex.EncodeToString(next) +
+		"\nPLAINTEXT = " + hex.EncodeToString(last) + "\nCIPHERTEXT = " + hex.EncodeToString(second) + "\n"
+	f, _ := Parse("ECBMCT128.rsp", strings.NewReader(data))
+	if report := Run(f); report.Passed != 2 {
+		t.Errorf("Invalid chained Monte Carlo records: %v", report.Failures)
+	}
+	f.Sections[0].Records[1].Fields["KEY"] = hex.EncodeToString(key)
+	if report := Run(f); report.Failed == 0 || report.Failures[0].Count != "0" {
+		t.Errorf("Invalid next key not reported")
+	}
+	// 192 and 256-bit keys take the tail of CT[998] || CT[999]
+	key192 := make([]byte, 24)
+	if next := NextMonteCarloKey(key192, append(previous, last...)); hex.EncodeToString(next) != hex.EncodeToString(previous[8:])+hex.EncodeToString(last) {
+		t.Errorf("Invalid 192-bit next key")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/circuit"
+	"os"
+)
+
+type circuitCommand struct {
+	KeyLength int    `short:"l" long:"key-length" description:"Key length of the circuit" choice:"128" choice:"192" choice:"256" default:"128"`
+	Format    string `short:"f" long:"format" description:"Output format" choice:"bristol-fashion" choice:"bristol" default:"bristol-fashion"`
+	Output    string `short:"o" long:"output" description:"Output file path or '-' to stdout" default:"-"`
+}
+
+func init() {
+	parser.AddCommand(
+		"circuit",
+		"Exports the AES encryption as a Boolean circuit",
+		"Exports the AES encryption, key expansion included, as a Boolean circuit in the Bristol Fashion or Bristol format. The inputs are the key and the plaintext. The gate counts are written to standard error.",
+		&circuitCommand{},
+	)
+}
+
+func (c *circuitCommand) Execute(args []string) error {
+	aesCircuit, err := circuit.AES(c.KeyLength / 8)
+	if err != nil {
+		return err
+	}
+	output := os.Stdout
+	if c.Output != "-" {
+		file, err := os.OpenFile(c.Output, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		output = file
+	}
+	if c.Format == "bristol" {
+		err = aesCircuit.WriteBristol(output)
+	} else {
+		err = aesCircuit.WriteBristolFashion(output)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "AND gates: %d\n", aesCircuit.Count(circuit.AND))
+	fmt.Fprintf(os.Stderr, "XOR gates: %d\n", aesCircuit.Count(circuit.XOR))
+	fmt.Fprintf(os.Stderr, "INV gates: %d\n", aesCircuit.Count(circuit.INV))
+	fmt.Fprintf(os.Stderr, "Wires:     %d\n", aesCircuit.NumWires)
+	return nil
+}
@@ -0,0 +1,395 @@
+package circuit
+
+import (
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/gf256"
+)
+
+// builder adds gates to a circuit, numbering the wires in creation order. The wires are renumbered by
+// finish so the outputs are the last ones.
+type builder struct {
+	c    *Circuit
+	next int
+}
+
+// byteWires are the wires of a byte, index i holding the bit of value 2^i
+type byteWires [8]int
+
+// nibbleWires are the wires of an element of GF(2^4), index i holding the bit of value 2^i
+type nibbleWires [4]int
+
+func newBuilder(inputs []int) *builder {
+	b := new(builder)
+	b.c = new(Circuit)
+	b.c.Inputs = inputs
+	b.next = sum(inputs)
+	return b
+}
+
+func (b *builder) gate(op string, in ...int) int {
+	out := b.next
+	b.next++
+	b.c.Gates = append(b.c.Gates, Gate{Op: op, In: in, Out: out})
+	return out
+}
+
+func (b *builder) xor(x, y int) int {
+	return b.gate(XOR, x, y)
+}
+
+func (b *builder) and(x, y int) int {
+	return b.gate(AND, x, y)
+}
+
+func (b *builder) inv(x int) int {
+	return b.gate(INV, x)
+}
+
+// inputBytes returns the wires of an input value of n bytes starting at the wire offset
+func inputBytes(offset, n int) []byteWires {
+	values := make([]byteWires, n)
+	for i := range values {
+		for j := 0; j < 8; j++ {
+			values[i][j] = offset + 8*i + 7 - j
+		}
+	}
+	return values
+}
+
+// finish renumbers the wires so the output bytes are the last wires, most significant bit first. The
+// output wires must be gate outputs not used by other gates.
+func (b *builder) finish(outputs [][]byteWires) (*Circuit, error) {
+	numOutputs := 0
+	position := make(map[int]int)
+	for _, value := range outputs {
+		b.c.Outputs = append(b.c.Outputs, 8*len(value))
+		for _, wires := range value {
+			for j := 7; j >= 0; j-- {
+				position[wires[j]] = numOutputs
+				numOutputs++
+			}
+		}
+	}
+	numInputs := sum(b.c.Inputs)
+	numWires := numInputs + len(b.c.Gates)
+	renumber := make([]int, b.next)
+	for i := 0; i < numInputs; i++ {
+		renumber[i] = i
+	}
+	next := numInputs
+	for _, g := range b.c.Gates {
+		if p, ok := position[g.Out]; ok {
+			renumber[g.Out] = numWires - numOutputs + p
+			delete(position, g.Out)
+		} else {
+			renumber[g.Out] = next
+			next++
+		}
+	}
+	if len(position) != 0 {
+		return nil, errors.New("Output wires must be gate outputs")
+	}
+	for i := range b.c.Gates {
+		g := &b.c.Gates[i]
+		for j := range g.In {
+			if renumber[g.In[j]] >= numWires-numOutputs {
+				return nil, errors.New("Output wires must not be used by other gates")
+			}
+			g.In[j] = renumber[g.In[j]]
+		}
+		g.Out = renumber[g.Out]
+	}
+	b.c.NumWires = numWires
+	return b.c, nil
+}
+
+// linear returns the bits of the GF(2)-linear function f applied to the input wires. f must not map a
+// non-zero input to zero output bits.
+func (b *builder) linear(in []int, f func(x uint) uint) []int {
+	out := make([]int, len(in))
+	for bit := range out {
+		wire := -1
+		for i := range in {
+			if f(1<<uint(i))>>uint(bit)&1 == 1 {
+				if wire < 0 {
+					wire = in[i]
+				} else {
+					wire = b.xor(wire, in[i])
+				}
+			}
+		}
+		out[bit] = wire
+	}
+	return out
+}
+
+func (b *builder) linearByte(in byteWires, f func(x byte) byte) byteWires {
+	var out byteWires
+	copy(out[:], b.linear(in[:], func(x uint) uint { return uint(f(byte(x))) }))
+	return out
+}
+
+func (b *builder) linearNibble(in nibbleWires, f func(x byte) byte) nibbleWires {
+	var out nibbleWires
+	copy(out[:], b.linear(in[:], func(x uint) uint { return uint(f(byte(x))) }))
+	return out
+}
+
+func (b *builder) xorByte(x, y byteWires) byteWires {
+	var out byteWires
+	for i := range out {
+		out[i] = b.xor(x[i], y[i])
+	}
+	return out
+}
+
+func (b *builder) xorNibble(x, y nibbleWires) nibbleWires {
+	var out nibbleWires
+	for i := range out {
+		out[i] = b.xor(x[i], y[i])
+	}
+	return out
+}
+
+// xorConstant xors the constant into the byte, using INV gates
+func (b *builder) xorConstant(x byteWires, c byte) byteWires {
+	for i := range x {
+		if c>>uint(i)&1 == 1 {
+			x[i] = b.inv(x[i])
+		}
+	}
+	return x
+}
+
+// GF(2^4) with the polynomial x^4 + x + 1
+func gf16Mul(a, c byte) byte {
+	var prod byte = 0
+	for i := uint(0); i < 4; i++ {
+		if c>>i&1 == 1 {
+			prod ^= a << i
+		}
+	}
+	for i := uint(6); i >= 4; i-- {
+		if prod>>i&1 == 1 {
+			prod ^= 0x13 << (i - 4)
+		}
+	}
+	return prod
+}
+
+// mulNibble multiplies two elements of GF(2^4) with 16 AND gates
+func (b *builder) mulNibble(x, y nibbleWires) nibbleWires {
+	var prod [7]int
+	for i := range prod {
+		prod[i] = -1
+	}
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			p := b.and(x[i], y[j])
+			if prod[i+j] < 0 {
+				prod[i+j] = p
+			} else {
+				prod[i+j] = b.xor(prod[i+j], p)
+			}
+		}
+	}
+	// x^4 = x + 1, x^5 = x^2 + x, x^6 = x^3 + x^2
+	return nibbleWires{
+		b.xor(prod[0], prod[4]),
+		b.xor(b.xor(prod[1], prod[4]), prod[5]),
+		b.xor(b.xor(prod[2], prod[5]), prod[6]),
+		b.xor(prod[3], prod[6]),
+	}
+}
+
+// The S-box inversion is computed in the tower field GF((2^4)^2) = GF(2^4)[Y]/(Y^2 + Y + lambda),
+// whose elements are stored as the high (coefficient of Y) and low nibbles of a byte. The basis change
+// from and to the AES field is found at init and merged with the affine transformation.
+var lambda byte
+var toTower, fromTower [256]byte
+
+func towerMul(a, c byte) byte {
+	ah, al, ch, cl := a>>4, a&0x0f, c>>4, c&0x0f
+	hh := gf16Mul(ah, ch)
+	hi := hh ^ gf16Mul(ah, cl) ^ gf16Mul(al, ch)
+	lo := gf16Mul(hh, lambda) ^ gf16Mul(al, cl)
+	return hi<<4 | lo
+}
+
+func init() {
+	// Y^2 + Y + lambda is irreducible when no t satisfies t^2 + t = lambda
+	var image [16]bool
+	for t := byte(0); t < 16; t++ {
+		image[gf16Mul(t, t)^t] = true
+	}
+	for lambda = 1; image[lambda]; lambda++ {
+	}
+	// beta is a root of the AES polynomial x^8 + x^4 + x^3 + x + 1 in the tower field
+	var beta byte
+	for candidate := 2; candidate < 256; candidate++ {
+		var powers [9]byte
+		powers[0] = 1
+		for i := 1; i < 9; i++ {
+			powers[i] = towerMul(powers[i-1], byte(candidate))
+		}
+		if powers[8]^powers[4]^powers[3]^powers[1]^powers[0] == 0 {
+			beta = byte(candidate)
+			break
+		}
+	}
+	var powers [8]byte
+	powers[0] = 1
+	for i := 1; i < 8; i++ {
+		powers[i] = towerMul(powers[i-1], beta)
+	}
+	for x := 0; x < 256; x++ {
+		var t byte = 0
+		for i := uint(0); i < 8; i++ {
+			if x>>i&1 == 1 {
+				t ^= powers[i]
+			}
+		}
+		toTower[x] = t
+		fromTower[t] = byte(x)
+	}
+}
+
+func rotl8(x byte, n uint) byte {
+	return x<<n | x>>(8-n)
+}
+
+// sBox adds the S-box circuit: inversion in the tower field followed by the affine transformation
+func (b *builder) sBox(x byteWires) byteWires {
+	t := b.linearByte(x, func(v byte) byte { return toTower[v] })
+	var ah, al nibbleWires
+	copy(al[:], t[:4])
+	copy(ah[:], t[4:])
+	// (ah Y + al)^-1 = (ah Y + ah + al) / d, where d = lambda ah^2 + ah al + al^2
+	square := func(v byte) byte { return gf16Mul(v, v) }
+	d := b.xorNibble(
+		b.xorNibble(b.linearNibble(ah, func(v byte) byte { return gf16Mul(lambda, square(v)) }), b.mulNibble(ah, al)),
+		b.linearNibble(al, square),
+	)
+	// d^-1 = d^14 = d^12 d^2, with d^12 = (d^3)^4
+	d2 := b.linearNibble(d, square)
+	d3 := b.mulNibble(d2, d)
+	d12 := b.linearNibble(d3, func(v byte) byte { return square(square(v)) })
+	dInv := b.mulNibble(d12, d2)
+	var inv byteWires
+	hi := b.mulNibble(ah, dInv)
+	lo := b.mulNibble(b.xorNibble(ah, al), dInv)
+	copy(inv[:4], lo[:])
+	copy(inv[4:], hi[:])
+	out := b.linearByte(inv, func(v byte) byte {
+		a := fromTower[v]
+		return a ^ rotl8(a, 1) ^ rotl8(a, 2) ^ rotl8(a, 3) ^ rotl8(a, 4)
+	})
+	return b.xorConstant(out, 0x63)
+}
+
+func xtime(x byte) byte {
+	return gf256.Mul(x, 2)
+}
+
+func (b *builder) mixColumns(state []byteWires) []byteWires {
+	out := make([]byteWires, 16)
+	for c := 0; c < 4; c++ {
+		col := state[4*c : 4*c+4]
+		var doubled [4]byteWires
+		for r := range doubled {
+			doubled[r] = b.linearByte(col[r], xtime)
+		}
+		for r := 0; r < 4; r++ {
+			// 2 a[r] + 3 a[r+1] + a[r+2] + a[r+3]
+			v := b.xorByte(doubled[r], doubled[(r+1)&3])
+			v = b.xorByte(v, col[(r+1)&3])
+			v = b.xorByte(v, col[(r+2)&3])
+			out[4*c+r] = b.xorByte(v, col[(r+3)&3])
+		}
+	}
+	return out
+}
+
+func shiftRows(state []byteWires) []byteWires {
+	out := make([]byteWires, 16)
+	for r := 0; r < 4; r++ {
+		for c := 0; c < 4; c++ {
+			out[r+c*4] = state[r+((c+r)&3)*4]
+		}
+	}
+	return out
+}
+
+func (b *builder) addRoundKey(state, key []byteWires) []byteWires {
+	out := make([]byteWires, 16)
+	for i := range out {
+		out[i] = b.xorByte(state[i], key[i])
+	}
+	return out
+}
+
+// keyExpansion returns the bytes of the round keys
+func (b *builder) keyExpansion(key []byteWires, numRounds int) []byteWires {
+	nk := len(key) / 4
+	words := make([][]byteWires, 4*(numRounds+1))
+	for i := 0; i < nk; i++ {
+		words[i] = key[4*i : 4*i+4]
+	}
+	var rCon byte = 1
+	for i := nk; i < len(words); i++ {
+		temp := words[i-1]
+		if i%nk == 0 {
+			temp = []byteWires{b.sBox(temp[1]), b.sBox(temp[2]), b.sBox(temp[3]), b.sBox(temp[0])}
+			temp[0] = b.xorConstant(temp[0], rCon)
+			rCon = xtime(rCon)
+		} else if nk > 6 && i%nk == 4 {
+			temp = []byteWires{b.sBox(temp[0]), b.sBox(temp[1]), b.sBox(temp[2]), b.sBox(temp[3])}
+		}
+		words[i] = make([]byteWires, 4)
+		for j := range words[i] {
+			words[i][j] = b.xorByte(words[i-nk][j], temp[j])
+		}
+	}
+	var keys []byteWires
+	for _, w := range words {
+		keys = append(keys, w...)
+	}
+	return keys
+}
+
+// AES returns the circuit of the AES encryption for keys of keyLength bytes (16, 24 or 32). The inputs
+// are the key and the plaintext and the output is the ciphertext, most significant bit of the first
+// byte first.
+func AES(keyLength int) (*Circuit, error) {
+	if keyLength != 16 && keyLength != 24 && keyLength != 32 {
+		return nil, errors.New("Invalid key length. Allowed lengths: 128-bit (16 bytes), 192-bit (24 bytes), 256-bit (32 bytes)")
+	}
+	numRounds := keyLength/4 + 6
+	b := newBuilder([]int{8 * keyLength, 128})
+	keys := b.keyExpansion(inputBytes(0, keyLength), numRounds)
+	state := b.addRoundKey(inputBytes(8*keyLength, 16), keys[:16])
+	for r := 1; r <= numRounds; r++ {
+		for i := range state {
+			state[i] = b.sBox(state[i])
+		}
+		state = shiftRows(state)
+		if r < numRounds {
+			state = b.mixColumns(state)
+		}
+		state = b.addRoundKey(state, keys[16*r:16*r+16])
+	}
+	return b.finish([][]byteWires{state})
+}
+
+// SBox returns the circuit of the AES S-box, with one byte input and output
+func SBox() (*Circuit, error) {
+	b := newBuilder([]int{8})
+	out := b.sBox(inputBytes(0, 1)[0])
+	// output bits equal to an input bit need a gate
+	for i := range out {
+		if out[i] < 8 {
+			out[i] = b.inv(b.inv(out[i]))
+		}
+	}
+	return b.finish([][]byteWires{{out}})
+}
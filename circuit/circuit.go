@@ -0,0 +1,223 @@
+// Package circuit builds Boolean circuits and reads and writes them in the Bristol formats.
+package circuit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Gate types
+const (
+	XOR = "XOR"
+	AND = "AND"
+	INV = "INV"
+	// EQ assigns the constant In[0] to the output wire
+	EQ = "EQ"
+	// EQW copies the input wire to the output wire
+	EQW = "EQW"
+)
+
+// Gate is a gate of the circuit. For EQ gates In holds the constant value instead of a wire.
+type Gate struct {
+	Op  string
+	In  []int
+	Out int
+}
+
+// Circuit is a Boolean circuit. The input wires come first, in the order of the inputs, and the output
+// wires are the last ones, in the order of the outputs. The gates are in topological order.
+type Circuit struct {
+	NumWires int
+	// Inputs and Outputs hold the number of bits of each input and output value
+	Inputs  []int
+	Outputs []int
+	Gates   []Gate
+}
+
+// Count returns the number of gates of the type
+func (c *Circuit) Count(op string) int {
+	count := 0
+	for _, g := range c.Gates {
+		if g.Op == op {
+			count++
+		}
+	}
+	return count
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// Evaluate runs the circuit. Each input and output value is a slice of bits.
+func (c *Circuit) Evaluate(inputs ...[]bool) ([][]bool, error) {
+	if len(inputs) != len(c.Inputs) {
+		return nil, fmt.Errorf("Invalid number of inputs. Expected: %d Got: %d", len(c.Inputs), len(inputs))
+	}
+	wires := make([]bool, c.NumWires)
+	pos := 0
+	for i, input := range inputs {
+		if len(input) != c.Inputs[i] {
+			return nil, fmt.Errorf("Invalid size of input %d. Expected: %d Got: %d", i, c.Inputs[i], len(input))
+		}
+		copy(wires[pos:], input)
+		pos += len(input)
+	}
+	for _, g := range c.Gates {
+		switch g.Op {
+		case XOR:
+			wires[g.Out] = wires[g.In[0]] != wires[g.In[1]]
+		case AND:
+			wires[g.Out] = wires[g.In[0]] && wires[g.In[1]]
+		case INV:
+			wires[g.Out] = !wires[g.In[0]]
+		case EQ:
+			wires[g.Out] = g.In[0] == 1
+		case EQW:
+			wires[g.Out] = wires[g.In[0]]
+		default:
+			return nil, fmt.Errorf("Unsupported gate %s", g.Op)
+		}
+	}
+	outputs := make([][]bool, len(c.Outputs))
+	pos = c.NumWires - sum(c.Outputs)
+	for i, size := range c.Outputs {
+		outputs[i] = make([]bool, size)
+		copy(outputs[i], wires[pos:pos+size])
+		pos += size
+	}
+	return outputs, nil
+}
+
+func writeGates(w *bufio.Writer, gates []Gate) {
+	for _, g := range gates {
+		fmt.Fprintf(w, "%d 1", len(g.In))
+		for _, in := range g.In {
+			fmt.Fprintf(w, " %d", in)
+		}
+		fmt.Fprintf(w, " %d %s\n", g.Out, g.Op)
+	}
+}
+
+// WriteBristolFashion writes the circuit in the Bristol Fashion format
+func (c *Circuit) WriteBristolFashion(writer io.Writer) error {
+	w := bufio.NewWriter(writer)
+	fmt.Fprintf(w, "%d %d\n", len(c.Gates), c.NumWires)
+	for _, values := range [][]int{c.Inputs, c.Outputs} {
+		fmt.Fprintf(w, "%d", len(values))
+		for _, v := range values {
+			fmt.Fprintf(w, " %d", v)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w)
+	writeGates(w, c.Gates)
+	return w.Flush()
+}
+
+// WriteBristol writes the circuit in the older Bristol format, which supports circuits with two inputs
+// and one output made of XOR, AND and INV gates
+func (c *Circuit) WriteBristol(writer io.Writer) error {
+	if len(c.Inputs) != 2 || len(c.Outputs) != 1 {
+		return errors.New("The Bristol format requires two inputs and one output")
+	}
+	for _, g := range c.Gates {
+		if g.Op != XOR && g.Op != AND && g.Op != INV {
+			return fmt.Errorf("The Bristol format does not support %s gates", g.Op)
+		}
+	}
+	w := bufio.NewWriter(writer)
+	fmt.Fprintf(w, "%d %d\n", len(c.Gates), c.NumWires)
+	fmt.Fprintf(w, "%d %d %d\n\n", c.Inputs[0], c.Inputs[1], c.Outputs[0])
+	writeGates(w, c.Gates)
+	return w.Flush()
+}
+
+// ReadBristolFashion reads a circuit in the Bristol Fashion format
+func ReadBristolFashion(reader io.Reader) (*Circuit, error) {
+	scanner := bufio.NewScanner(reader)
+	var lines [][]int
+	var ops []string
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		values := make([]int, 0, len(fields))
+		for i, field := range fields {
+			v, err := strconv.Atoi(field)
+			if err != nil {
+				if len(lines) >= 3 && i == len(fields)-1 {
+					ops = append(ops, field)
+					break
+				}
+				return nil, fmt.Errorf("Invalid circuit line: %s", scanner.Text())
+			}
+			values = append(values, v)
+		}
+		lines = append(lines, values)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) < 3 || len(lines[0]) != 2 {
+		return nil, errors.New("Invalid circuit header")
+	}
+	c := new(Circuit)
+	numGates := lines[0][0]
+	c.NumWires = lines[0][1]
+	for i, values := range []*[]int{&c.Inputs, &c.Outputs} {
+		line := lines[i+1]
+		if len(line) == 0 || len(line) != line[0]+1 {
+			return nil, errors.New("Invalid circuit header")
+		}
+		*values = line[1:]
+	}
+	if len(lines)-3 != numGates || len(ops) != numGates {
+		return nil, fmt.Errorf("Invalid number of gates. Expected: %d Got: %d", numGates, len(lines)-3)
+	}
+	for i, line := range lines[3:] {
+		if len(line) < 2 || line[1] != 1 || len(line) != line[0]+3 {
+			return nil, fmt.Errorf("Invalid gate %d", i)
+		}
+		g := Gate{Op: ops[i], In: line[2 : 2+line[0]], Out: line[len(line)-1]}
+		if g.Out < 0 || g.Out >= c.NumWires {
+			return nil, fmt.Errorf("Invalid wire in gate %d", i)
+		}
+		for _, in := range g.In {
+			if g.Op != EQ && (in < 0 || in >= c.NumWires) {
+				return nil, fmt.Errorf("Invalid wire in gate %d", i)
+			}
+		}
+		c.Gates = append(c.Gates, g)
+	}
+	return c, nil
+}
+
+// BytesToBits converts bytes to bits, most significant bit first
+func BytesToBits(data []byte) []bool {
+	bits := make([]bool, 8*len(data))
+	for i := range bits {
+		bits[i] = data[i/8]>>uint(7-i%8)&1 == 1
+	}
+	return bits
+}
+
+// BitsToBytes converts bits to bytes, most significant bit first
+func BitsToBytes(bits []bool) []byte {
+	data := make([]byte, (len(bits)+7)/8)
+	for i, bit := range bits {
+		if bit {
+			data[i/8] |= 1 << uint(7-i%8)
+		}
+	}
+	return data
+}
@@ -0,0 +1,124 @@
+package circuit
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"strings"
+	"testing"
+)
+
+func TestSBox(t *testing.T) {
+	c, err := SBox()
+	if err != nil {
+		t.Fatalf("Error building S-box circuit: %s", err.Error())
+	}
+	for x := 0; x < 256; x++ {
+		out, err := c.Evaluate(BytesToBits([]byte{byte(x)}))
+		if err != nil {
+			t.Fatalf("Error evaluating circuit: %s", err.Error())
+		}
+		if res := BitsToBytes(out[0])[0]; res != aes.SBox(byte(x)) {
+			t.Errorf("Invalid S-box circuit output for 0x%02x, got: 0x%02x, want: 0x%02x", x, res, aes.SBox(byte(x)))
+		}
+	}
+	t.Logf("S-box: %d AND, %d XOR, %d INV", c.Count(AND), c.Count(XOR), c.Count(INV))
+}
+
+func TestAES(t *testing.T) {
+	for _, length := range []int{16, 24, 32} {
+		c, err := AES(length)
+		if err != nil {
+			t.Fatalf("Error building AES circuit: %s", err.Error())
+		}
+		t.Logf("AES-%d: %d AND, %d XOR, %d INV, %d wires",
+			8*length, c.Count(AND), c.Count(XOR), c.Count(INV), c.NumWires)
+		for n := 0; n < 5; n++ {
+			key := make([]byte, length)
+			block := make([]byte, 16)
+			rand.Read(key)
+			rand.Read(block)
+			cipher, _ := aes.NewCipher(key)
+			expected := make([]byte, 16)
+			cipher.Encrypt(block, expected)
+			out, err := c.Evaluate(BytesToBits(key), BytesToBits(block))
+			if err != nil {
+				t.Fatalf("Error evaluating circuit: %s", err.Error())
+			}
+			if res := BitsToBytes(out[0]); !bytes.Equal(res, expected) {
+				t.Errorf("Invalid AES-%d circuit output. Expected: 0x%s Got: 0x%s",
+					8*length, hex.EncodeToString(expected), hex.EncodeToString(res))
+			}
+		}
+	}
+}
+
+func TestBristolFashion(t *testing.T) {
+	c, _ := AES(16)
+	var buffer bytes.Buffer
+	if err := c.WriteBristolFashion(&buffer); err != nil {
+		t.Fatalf("Error writing circuit: %s", err.Error())
+	}
+	loaded, err := ReadBristolFashion(&buffer)
+	if err != nil {
+		t.Fatalf("Error reading circuit: %s", err.Error())
+	}
+	// FIPS-197 Appendix C.1
+	key := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f}
+	block := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	expected := []byte{0x69, 0xc4, 0xe0, 0xd8, 0x6a, 0x7b, 0x04, 0x30, 0xd8, 0xcd, 0xb7, 0x80, 0x70, 0xb4, 0xc5, 0x5a}
+	out, err := loaded.Evaluate(BytesToBits(key), BytesToBits(block))
+	if err != nil {
+		t.Fatalf("Error evaluating circuit: %s", err.Error())
+	}
+	if res := BitsToBytes(out[0]); !bytes.Equal(res, expected) {
+		t.Errorf("Invalid output of the loaded circuit. Expected: 0x%s Got: 0x%s",
+			hex.EncodeToString(expected), hex.EncodeToString(res))
+	}
+}
+
+func TestReadBristolFashion(t *testing.T) {
+	// out = (a AND b) XOR NOT a, with a copied by EQW and a constant gate
+	source := "5 7\n2 1 1\n1 1\n\n1 1 0 2 EQW\n2 1 2 1 3 AND\n1 1 2 4 INV\n1 1 1 5 EQ\n2 1 3 4 6 XOR\n"
+	c, err := ReadBristolFashion(bytes.NewBufferString(source))
+	if err != nil {
+		t.Fatalf("Error reading circuit: %s", err.Error())
+	}
+	for _, a := range []bool{false, true} {
+		for _, b := range []bool{false, true} {
+			out, err := c.Evaluate([]bool{a}, []bool{b})
+			if err != nil {
+				t.Fatalf("Error evaluating circuit: %s", err.Error())
+			}
+			if out[0][0] != ((a && b) != !a) {
+				t.Errorf("Invalid output for a: %t, b: %t", a, b)
+			}
+		}
+	}
+	for _, invalid := range []string{"", "1 2\n1 1\n1 1\n", "1 2\n1 1\n1 1\n\n2 1 0 5 1 XOR\n"} {
+		if _, err := ReadBristolFashion(bytes.NewBufferString(invalid)); err == nil {
+			t.Errorf("Accepting invalid circuit %q", invalid)
+		}
+	}
+}
+
+func TestWriteBristol(t *testing.T) {
+	c, _ := SBox()
+	var buffer bytes.Buffer
+	if err := c.WriteBristol(&buffer); err == nil {
+		t.Errorf("Accepting circuit with one input")
+	}
+	c, _ = AES(16)
+	if err := c.WriteBristol(&buffer); err != nil {
+		t.Fatalf("Error writing circuit: %s", err.Error())
+	}
+	expected := fmt.Sprintf("%d %d\n128 128 128\n\n", len(c.Gates), c.NumWires)
+	if !strings.HasPrefix(buffer.String(), expected) {
+		t.Errorf("Invalid header. Expected: %q", expected)
+	}
+	if lines := strings.Count(buffer.String(), "\n"); lines != len(c.Gates)+3 {
+		t.Errorf("Invalid number of lines, got: %d, want: %d", lines, len(c.Gates)+3)
+	}
+}
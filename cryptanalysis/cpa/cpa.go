@@ -0,0 +1,126 @@
+// Package cpa simulates power traces of the cipher and runs a correlation power analysis on them.
+//
+// The simulated power consumption of each intermediate state byte is its Hamming weight plus gaussian
+// noise. The attack correlates the traces with the Hamming weight of the first round S-box output
+// predicted for each guess of a key byte, and keeps the guess with the highest correlation. Against a
+// masked implementation the processed values are independent of the predictions, so the attack fails.
+package cpa
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"math"
+	"math/bits"
+	mrand "math/rand"
+)
+
+// TracedCipher is a cipher that reports its intermediate values, like aes.AESCipher and
+// aes.MaskedCipher
+type TracedCipher interface {
+	Encrypt(block, dest []byte)
+	SetTracer(tracer aes.Tracer)
+}
+
+// Trace is a simulated power trace of the encryption of Plaintext
+type Trace struct {
+	Plaintext []byte
+	Samples   []float64
+}
+
+// Recorder is an aes.Tracer recording the Hamming weight of the state bytes of the first round,
+// plus gaussian noise with standard deviation Noise
+type Recorder struct {
+	Noise   float64
+	Samples []float64
+	random  *mrand.Rand
+}
+
+// NewRecorder creates a new Recorder
+func NewRecorder(noise float64, seed int64) *Recorder {
+	r := new(Recorder)
+	r.Noise = noise
+	r.random = mrand.New(mrand.NewSource(seed))
+	return r
+}
+
+// Trace records the samples of the state steps of the first round
+func (r *Recorder) Trace(round int, step string, data []byte) {
+	if round > 1 || step == "k_sch" {
+		return
+	}
+	for _, b := range data {
+		r.Samples = append(r.Samples, float64(bits.OnesCount8(b))+r.random.NormFloat64()*r.Noise)
+	}
+}
+
+// Record encrypts n random plaintexts recording a trace of each one
+func Record(cipher TracedCipher, n int, noise float64) ([]Trace, error) {
+	var seed [8]byte
+	if _, err := rand.Read(seed[:]); err != nil {
+		return nil, err
+	}
+	recorder := NewRecorder(noise, int64(binary.LittleEndian.Uint64(seed[:])))
+	cipher.SetTracer(recorder)
+	defer cipher.SetTracer(nil)
+	traces := make([]Trace, n)
+	dest := make([]byte, 16)
+	for i := range traces {
+		traces[i].Plaintext = make([]byte, 16)
+		if _, err := rand.Read(traces[i].Plaintext); err != nil {
+			return nil, err
+		}
+		recorder.Samples = nil
+		cipher.Encrypt(traces[i].Plaintext, dest)
+		traces[i].Samples = recorder.Samples
+	}
+	return traces, nil
+}
+
+// Attack returns the most likely key and the correlation of each key byte
+func Attack(traces []Trace) (key []byte, correlations []float64) {
+	key = make([]byte, 16)
+	correlations = make([]float64, 16)
+	if len(traces) == 0 {
+		return key, correlations
+	}
+	numSamples := len(traces[0].Samples)
+	samples := make([]float64, len(traces))
+	hypotheses := make([]float64, len(traces))
+	for s := 0; s < numSamples; s++ {
+		for i := range traces {
+			samples[i] = traces[i].Samples[s]
+		}
+		for j := 0; j < 16; j++ {
+			for k := 0; k < 256; k++ {
+				for i := range traces {
+					hypotheses[i] = float64(bits.OnesCount8(aes.SBox(traces[i].Plaintext[j] ^ byte(k))))
+				}
+				corr := math.Abs(correlation(hypotheses, samples))
+				if corr > correlations[j] {
+					correlations[j] = corr
+					key[j] = byte(k)
+				}
+			}
+		}
+	}
+	return key, correlations
+}
+
+// correlation returns the Pearson correlation coefficient of x and y
+func correlation(x, y []float64) float64 {
+	var sumX, sumY, sumXX, sumYY, sumXY float64
+	n := float64(len(x))
+	for i := range x {
+		sumX += x[i]
+		sumY += y[i]
+		sumXX += x[i] * x[i]
+		sumYY += y[i] * y[i]
+		sumXY += x[i] * y[i]
+	}
+	den := math.Sqrt(n*sumXX-sumX*sumX) * math.Sqrt(n*sumYY-sumY*sumY)
+	if den == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / den
+}
@@ -0,0 +1,48 @@
+package cpa
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"testing"
+)
+
+func correctBytes(key, expected []byte) int {
+	count := 0
+	for i := range key {
+		if key[i] == expected[i] {
+			count++
+		}
+	}
+	return count
+}
+
+func TestAttackReference(t *testing.T) {
+	key := make([]byte, 16)
+	rand.Read(key)
+	cipher, _ := aes.NewCipher(key)
+	traces, err := Record(cipher, 200, 1.0)
+	if err != nil {
+		t.Fatalf("Error recording traces: %s", err.Error())
+	}
+	res, _ := Attack(traces)
+	if correct := correctBytes(res, key); correct != 16 {
+		t.Errorf("CPA recovered %d key bytes from the reference. Expected: 0x%s Got: 0x%s",
+			correct, hex.EncodeToString(key), hex.EncodeToString(res))
+	}
+}
+
+func TestAttackMasked(t *testing.T) {
+	key := make([]byte, 16)
+	rand.Read(key)
+	cipher, _ := aes.NewMaskedCipher(key)
+	traces, err := Record(cipher, 200, 1.0)
+	if err != nil {
+		t.Fatalf("Error recording traces: %s", err.Error())
+	}
+	res, _ := Attack(traces)
+	// a guess is right by chance with probability 1/256
+	if correct := correctBytes(res, key); correct > 2 {
+		t.Errorf("CPA recovered %d key bytes from the masked cipher", correct)
+	}
+}
@@ -0,0 +1,245 @@
+// Package dfa simulates the differential fault analysis of AES-128 described by Piret and Quisquater.
+//
+// A single-byte fault injected in the state before the MixColumns of round 9 spreads to one column,
+// where the differences are the fault multiplied by one column of the MixColumns matrix. The last
+// round has no MixColumns, so these four differences reach four ciphertext bytes and each hypothesis
+// of fault value and row can be checked against each byte of the last round key independently. Every
+// pair leaves a few hundred candidates for four bytes of the last round key, and two pairs for the
+// same column are usually enough to leave only one.
+package dfa
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/gf256"
+	"github.com/emanuelzabka/crypt-aes/internal/fault"
+)
+
+// FaultRound is the round where the faults are injected, before MixColumns
+const FaultRound = 9
+
+// MaxCandidates is the maximum number of last round keys tested against the plaintexts
+const MaxCandidates = 1 << 16
+
+// Pair holds the correct and faulty ciphertexts of the same plaintext
+type Pair struct {
+	Plaintext []byte
+	Correct   []byte
+	Faulty    []byte
+}
+
+// Result holds the outcome of the attack
+type Result struct {
+	RoundKey []byte
+	Key      []byte
+}
+
+// Injector is a fault injector that xors Value to the state byte Position before the MixColumns
+// of FaultRound
+type Injector struct {
+	Position int
+	Value    byte
+}
+
+// Inject injects the fault into the state
+func (f *Injector) Inject(round int, step string, state []byte) {
+	if round == FaultRound && step == "s_row" {
+		state[f.Position] ^= f.Value
+	}
+}
+
+// CollectPairs encrypts n random plaintexts with and without a fault of random value. The faults are
+// injected into each column in turn, in a random row. The injector of the cipher is restored on return.
+func CollectPairs(cipher *aes.AESCipher, n int) ([]Pair, error) {
+	if cipher.Rounds() != 10 {
+		return nil, errors.New("Invalid cipher. The attack requires AES-128")
+	}
+	if n < 1 {
+		return nil, errors.New("Invalid number of pairs. At least 1 is needed")
+	}
+	defer fault.SetInjector(cipher, fault.CurrentInjector(cipher))
+	random := make([]byte, 2)
+	pairs := make([]Pair, n)
+	for i := range pairs {
+		pair := &pairs[i]
+		pair.Plaintext = make([]byte, 16)
+		pair.Correct = make([]byte, 16)
+		pair.Faulty = make([]byte, 16)
+		if _, err := rand.Read(pair.Plaintext); err != nil {
+			return nil, err
+		}
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		injector := new(Injector)
+		injector.Position = (i%4)*4 + int(random[0]&3)
+		injector.Value = random[1]
+		if injector.Value == 0 {
+			injector.Value = 1
+		}
+		fault.SetInjector(cipher, nil)
+		cipher.Encrypt(pair.Plaintext, pair.Correct)
+		fault.SetInjector(cipher, injector)
+		cipher.Encrypt(pair.Plaintext, pair.Faulty)
+	}
+	return pairs, nil
+}
+
+// columnPositions returns the ciphertext positions reached by a fault in the column of the state
+// before the MixColumns of round 9
+func columnPositions(column int) [4]int {
+	var positions [4]int
+	for r := 0; r < 4; r++ {
+		positions[r] = r + 4*((column-r)&3)
+	}
+	return positions
+}
+
+// faultColumn returns the column of the fault that explains the difference between the ciphertexts, or
+// -1 if the difference does not match a single-byte fault before the MixColumns of round 9
+func faultColumn(pair Pair) int {
+	for column := 0; column < 4; column++ {
+		positions := columnPositions(column)
+		matches := true
+		for i := 0; i < 16 && matches; i++ {
+			inColumn := i == positions[0] || i == positions[1] || i == positions[2] || i == positions[3]
+			if inColumn != (pair.Correct[i] != pair.Faulty[i]) {
+				matches = false
+			}
+		}
+		if matches {
+			return column
+		}
+	}
+	return -1
+}
+
+// mixCoefficients[r] are the MixColumns coefficients applied to a fault in row r, by output row
+var mixCoefficients = [4][4]byte{
+	{0x02, 0x01, 0x01, 0x03},
+	{0x03, 0x02, 0x01, 0x01},
+	{0x01, 0x03, 0x02, 0x01},
+	{0x01, 0x01, 0x03, 0x02},
+}
+
+// pairCandidates returns the values of the four last round key bytes of the column that explain the
+// pair, packed in an uint32 in the order of columnPositions
+func pairCandidates(pair Pair, column int) map[uint32]bool {
+	positions := columnPositions(column)
+	candidates := make(map[uint32]bool)
+	var keys [4][]byte
+	for row := 0; row < 4; row++ {
+		for fault := 1; fault < 256; fault++ {
+			found := true
+			for i, p := range positions {
+				expected := gf256.Mul(mixCoefficients[row][i], byte(fault))
+				keys[i] = keys[i][:0]
+				for k := 0; k < 256; k++ {
+					c, f := pair.Correct[p]^byte(k), pair.Faulty[p]^byte(k)
+					if aes.InvSBox(c)^aes.InvSBox(f) == expected {
+						keys[i] = append(keys[i], byte(k))
+					}
+				}
+				if len(keys[i]) == 0 {
+					found = false
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+			for _, k0 := range keys[0] {
+				for _, k1 := range keys[1] {
+					for _, k2 := range keys[2] {
+						for _, k3 := range keys[3] {
+							candidates[uint32(k0)<<24|uint32(k1)<<16|uint32(k2)<<8|uint32(k3)] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+// Attack recovers the last round key and the cipher key of AES-128 from correct and faulty
+// ciphertext pairs. Pairs whose difference does not match a fault before the MixColumns of round 9 are
+// ignored. Every column needs at least one pair and usually two.
+func Attack(pairs []Pair) (*Result, error) {
+	var columns [4]map[uint32]bool
+	for _, pair := range pairs {
+		column := faultColumn(pair)
+		if column < 0 {
+			continue
+		}
+		candidates := pairCandidates(pair, column)
+		if columns[column] == nil {
+			columns[column] = candidates
+			continue
+		}
+		for k := range columns[column] {
+			if !candidates[k] {
+				delete(columns[column], k)
+			}
+		}
+	}
+	total := 1
+	var lists [4][]uint32
+	for column := range columns {
+		if columns[column] == nil {
+			return nil, errors.New("Not enough faulty pairs. Every column needs at least one pair")
+		}
+		if len(columns[column]) == 0 {
+			return nil, fmt.Errorf("Inconsistent pairs. No last round key explains every pair of column %d", column)
+		}
+		for k := range columns[column] {
+			lists[column] = append(lists[column], k)
+		}
+		total *= len(lists[column])
+		if total > MaxCandidates {
+			return nil, errors.New("Too many candidates for the last round key. More faulty pairs are needed")
+		}
+	}
+	roundKey := make([]byte, 16)
+	for n := 0; n < total; n++ {
+		index := n
+		for column := range lists {
+			k := lists[column][index%len(lists[column])]
+			index /= len(lists[column])
+			for i, p := range columnPositions(column) {
+				roundKey[p] = byte(k >> uint(24-8*i))
+			}
+		}
+		key, err := aes.InvertKeySchedule(roundKey, 10, 16)
+		if err != nil {
+			return nil, err
+		}
+		if verify(key, pairs) {
+			result := new(Result)
+			result.RoundKey = roundKey
+			result.Key = key
+			return result, nil
+		}
+	}
+	return nil, errors.New("No candidate matches the correct ciphertexts")
+}
+
+// verify checks if the key encrypts the plaintexts into the correct ciphertexts
+func verify(key []byte, pairs []Pair) bool {
+	cipher, err := aes.NewCipher(key)
+	if err != nil {
+		return false
+	}
+	res := make([]byte, 16)
+	for _, pair := range pairs {
+		cipher.Encrypt(pair.Plaintext, res)
+		for i := range res {
+			if res[i] != pair.Correct[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
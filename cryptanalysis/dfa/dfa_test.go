@@ -0,0 +1,100 @@
+package dfa
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/internal/fault"
+	"strings"
+	"testing"
+)
+
+func TestFaultColumn(t *testing.T) {
+	key := make([]byte, 16)
+	cipher, _ := aes.NewCipher(key)
+	for position := 0; position < 16; position++ {
+		pair := Pair{Plaintext: make([]byte, 16), Correct: make([]byte, 16), Faulty: make([]byte, 16)}
+		cipher.Encrypt(pair.Plaintext, pair.Correct)
+		fault.SetInjector(cipher, &Injector{Position: position, Value: 0x80})
+		cipher.Encrypt(pair.Plaintext, pair.Faulty)
+		fault.SetInjector(cipher, nil)
+		if column := faultColumn(pair); column != position/4 {
+			t.Errorf("Invalid column for fault at %d, got: %d, want: %d", position, column, position/4)
+		}
+		candidates := pairCandidates(pair, position/4)
+		positions := columnPositions(position / 4)
+		roundKey := cipher.RoundKeys()[10]
+		expected := uint32(roundKey[positions[0]])<<24 | uint32(roundKey[positions[1]])<<16 |
+			uint32(roundKey[positions[2]])<<8 | uint32(roundKey[positions[3]])
+		if !candidates[expected] {
+			t.Errorf("Round key missing from the candidates of the fault at %d", position)
+		}
+	}
+}
+
+func TestAttack(t *testing.T) {
+	for n := 0; n < 3; n++ {
+		key := make([]byte, 16)
+		rand.Read(key)
+		cipher, err := aes.NewCipher(key)
+		if err != nil {
+			t.Fatal("Error creating cipher")
+		}
+		pairs, err := CollectPairs(cipher, 8)
+		if err != nil {
+			t.Fatalf("Error collecting pairs: %s", err.Error())
+		}
+		result, err := Attack(pairs)
+		if err != nil {
+			t.Fatalf("Attack failed for key 0x%s: %s", hex.EncodeToString(key), err.Error())
+		}
+		if !bytes.Equal(result.Key, key) {
+			t.Errorf("Invalid recovered key. Expected: 0x%s Got: 0x%s",
+				hex.EncodeToString(key), hex.EncodeToString(result.Key))
+		}
+		if !bytes.Equal(result.RoundKey, cipher.RoundKeys()[10]) {
+			t.Errorf("Invalid recovered round key")
+		}
+	}
+}
+
+func TestAttackNotEnoughPairs(t *testing.T) {
+	cipher, _ := aes.NewCipher(make([]byte, 16))
+	pairs, _ := CollectPairs(cipher, 3)
+	if _, err := Attack(pairs); err == nil {
+		t.Errorf("Attack succeeded without pairs for every column")
+	}
+}
+
+func TestAttackInconsistentPairs(t *testing.T) {
+	first, _ := aes.NewCipher(make([]byte, 16))
+	second, _ := aes.NewCipher(bytes.Repeat([]byte{0xff}, 16))
+	pairs, _ := CollectPairs(first, 4)
+	more, _ := CollectPairs(second, 4)
+	_, err := Attack(append(pairs, more...))
+	if err == nil || !strings.HasPrefix(err.Error(), "Inconsistent pairs") {
+		t.Errorf("Invalid error for pairs of different keys: %v", err)
+	}
+}
+
+func TestCollectPairsInvalidCount(t *testing.T) {
+	cipher, _ := aes.NewCipher(make([]byte, 16))
+	for _, n := range []int{0, -1} {
+		if _, err := CollectPairs(cipher, n); err == nil {
+			t.Errorf("Accepting %d pairs", n)
+		}
+	}
+}
+
+func TestCollectPairsRestoresInjector(t *testing.T) {
+	cipher, _ := aes.NewCipher(make([]byte, 16))
+	injector := &Injector{Position: 0, Value: 1}
+	fault.SetInjector(cipher, injector)
+	if _, err := CollectPairs(cipher, 2); err != nil {
+		t.Fatalf("Error collecting pairs: %s", err.Error())
+	}
+	if fault.CurrentInjector(cipher) != injector {
+		t.Errorf("Injector of the caller not restored")
+	}
+}
@@ -0,0 +1,151 @@
+// Package sbox computes the cryptographic properties of 8-bit S-boxes
+package sbox
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// Report holds the properties of an S-box
+type Report struct {
+	// DDT[a][b] is the number of inputs x such that S(x) ^ S(x ^ a) == b
+	DDT [][]int
+	// LAT[a][b] is the number of inputs x such that a·x == b·S(x), minus 128
+	LAT                    [][]int
+	DifferentialUniformity int
+	Nonlinearity           int
+	AlgebraicDegree        int
+	FixedPoints            []byte
+	Bijective              bool
+}
+
+// Analyze computes the properties of a substitution table with 256 entries
+func Analyze(sBox []byte) (*Report, error) {
+	if len(sBox) != 256 {
+		return nil, errors.New("Invalid S-box length. The S-box must have 256 entries")
+	}
+	r := new(Report)
+	r.DDT = DifferenceDistributionTable(sBox)
+	r.LAT = LinearApproximationTable(sBox)
+	r.DifferentialUniformity = DifferentialUniformity(r.DDT)
+	r.Nonlinearity = Nonlinearity(r.LAT)
+	r.AlgebraicDegree = AlgebraicDegree(sBox)
+	r.FixedPoints = FixedPoints(sBox)
+	r.Bijective = Bijective(sBox)
+	return r, nil
+}
+
+// DifferenceDistributionTable returns the difference distribution table of the S-box
+func DifferenceDistributionTable(sBox []byte) [][]int {
+	ddt := newTable()
+	for a := 0; a < 256; a++ {
+		for x := 0; x < 256; x++ {
+			ddt[a][sBox[x]^sBox[x^a]]++
+		}
+	}
+	return ddt
+}
+
+// LinearApproximationTable returns the linear approximation table of the S-box
+func LinearApproximationTable(sBox []byte) [][]int {
+	lat := newTable()
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			count := 0
+			for x := 0; x < 256; x++ {
+				if bits.OnesCount8(byte(a&x)^(byte(b)&sBox[x]))&1 == 0 {
+					count++
+				}
+			}
+			lat[a][b] = count - 128
+		}
+	}
+	return lat
+}
+
+// DifferentialUniformity returns the highest entry of the DDT for a non-zero input difference
+func DifferentialUniformity(ddt [][]int) int {
+	max := 0
+	for a := 1; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			if ddt[a][b] > max {
+				max = ddt[a][b]
+			}
+		}
+	}
+	return max
+}
+
+// Nonlinearity returns the minimum distance of the non-zero component functions to the affine
+// functions, given by 128 minus the highest absolute LAT entry for a non-zero output mask
+func Nonlinearity(lat [][]int) int {
+	max := 0
+	for a := 0; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			v := lat[a][b]
+			if v < 0 {
+				v = -v
+			}
+			if v > max {
+				max = v
+			}
+		}
+	}
+	return 128 - max
+}
+
+// AlgebraicDegree returns the highest degree of the algebraic normal form of the coordinate functions
+func AlgebraicDegree(sBox []byte) int {
+	degree := 0
+	var anf [256]byte
+	for bit := uint(0); bit < 8; bit++ {
+		for x := range anf {
+			anf[x] = (sBox[x] >> bit) & 1
+		}
+		// Möbius transform from the truth table to the algebraic normal form
+		for step := 1; step < 256; step <<= 1 {
+			for x := range anf {
+				if x&step != 0 {
+					anf[x] ^= anf[x^step]
+				}
+			}
+		}
+		for x := range anf {
+			if anf[x] != 0 && bits.OnesCount8(byte(x)) > degree {
+				degree = bits.OnesCount8(byte(x))
+			}
+		}
+	}
+	return degree
+}
+
+// FixedPoints returns the inputs x such that S(x) == x
+func FixedPoints(sBox []byte) []byte {
+	points := []byte{}
+	for x := range sBox {
+		if sBox[x] == byte(x) {
+			points = append(points, byte(x))
+		}
+	}
+	return points
+}
+
+// Bijective checks if the S-box is a permutation
+func Bijective(sBox []byte) bool {
+	var seen [256]bool
+	for _, s := range sBox {
+		if seen[s] {
+			return false
+		}
+		seen[s] = true
+	}
+	return true
+}
+
+func newTable() [][]int {
+	table := make([][]int, 256)
+	for i := range table {
+		table[i] = make([]int, 256)
+	}
+	return table
+}
@@ -0,0 +1,74 @@
+package sbox
+
+import (
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"testing"
+)
+
+func TestAnalyzeAES(t *testing.T) {
+	sBox, invSBox := aes.GenerateSBox()
+	for i, table := range [][]byte{sBox, invSBox} {
+		r, err := Analyze(table)
+		if err != nil {
+			t.Fatalf("Error analyzing S-box %d: %s", i, err.Error())
+		}
+		if r.DifferentialUniformity != 4 {
+			t.Errorf("Invalid differential uniformity for S-box %d, got: %d, want: 4", i, r.DifferentialUniformity)
+		}
+		if r.Nonlinearity != 112 {
+			t.Errorf("Invalid nonlinearity for S-box %d, got: %d, want: 112", i, r.Nonlinearity)
+		}
+		if r.AlgebraicDegree != 7 {
+			t.Errorf("Invalid algebraic degree for S-box %d, got: %d, want: 7", i, r.AlgebraicDegree)
+		}
+		if len(r.FixedPoints) != 0 {
+			t.Errorf("Invalid fixed points for S-box %d, got: %v, want: none", i, r.FixedPoints)
+		}
+		if !r.Bijective {
+			t.Errorf("S-box %d not reported as bijective", i)
+		}
+		if r.DDT[0][0] != 256 || r.LAT[0][0] != 128 {
+			t.Errorf("Invalid trivial entries for S-box %d, got DDT: %d, LAT: %d", i, r.DDT[0][0], r.LAT[0][0])
+		}
+	}
+}
+
+func TestAnalyzeAffine(t *testing.T) {
+	table := make([]byte, 256)
+	for x := range table {
+		table[x] = byte(x) ^ 0x01
+	}
+	r, err := Analyze(table)
+	if err != nil {
+		t.Fatalf("Error analyzing S-box: %s", err.Error())
+	}
+	if r.DifferentialUniformity != 256 {
+		t.Errorf("Invalid differential uniformity, got: %d, want: 256", r.DifferentialUniformity)
+	}
+	if r.Nonlinearity != 0 {
+		t.Errorf("Invalid nonlinearity, got: %d, want: 0", r.Nonlinearity)
+	}
+	if r.AlgebraicDegree != 1 {
+		t.Errorf("Invalid algebraic degree, got: %d, want: 1", r.AlgebraicDegree)
+	}
+}
+
+func TestAnalyzeFixedPoints(t *testing.T) {
+	table := make([]byte, 256)
+	for x := range table {
+		table[x] = byte(x)
+	}
+	table[1], table[2] = 2, 1
+	r, _ := Analyze(table)
+	if len(r.FixedPoints) != 254 {
+		t.Errorf("Invalid fixed points count, got: %d, want: 254", len(r.FixedPoints))
+	}
+	table[2] = 2
+	r, _ = Analyze(table)
+	if r.Bijective {
+		t.Errorf("Non bijective S-box reported as bijective")
+	}
+	if _, err := Analyze(table[:10]); err == nil {
+		t.Errorf("Accepting S-box with invalid length")
+	}
+}
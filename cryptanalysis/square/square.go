@@ -0,0 +1,140 @@
+// Package square implements the integral (Square) attack against 4-round AES-128.
+//
+// A Λ-set is a group of 256 plaintexts that differ only in one byte, which takes every possible value.
+// After three rounds every byte of the state is balanced (the XOR of the 256 states is zero). The last
+// round has no MixColumns, so each byte of the last round key can be guessed independently: the right
+// guess makes the partially decrypted bytes balanced. Wrong guesses survive with probability 2^-8, so a
+// few Λ-sets are enough to leave a single candidate per byte.
+package square
+
+import (
+	"crypto/rand"
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/modes"
+)
+
+// Rounds is the number of rounds of the attacked cipher
+const Rounds = 4
+
+// MaxSets is the maximum number of Λ-sets used before giving up
+const MaxSets = 16
+
+// Result holds the outcome of the attack
+type Result struct {
+	RoundKey         []byte
+	Key              []byte
+	Sets             int
+	ChosenPlaintexts int
+}
+
+// Attack runs the attack using cipher as an encryption oracle for chosen plaintexts. The cipher must be
+// a 4-round AES-128 such as the one returned by aes.NewCipherWithRounds(key, 4).
+func Attack(cipher modes.Cipher) (*Result, error) {
+	if cipher.BlockSize() != 16 {
+		return nil, errors.New("Invalid block size. The attack requires a 16 bytes block cipher")
+	}
+	var candidates [16][256]bool
+	for j := range candidates {
+		for k := range candidates[j] {
+			candidates[j][k] = true
+		}
+	}
+	result := new(Result)
+	ciphertexts := make([][]byte, 256)
+	for i := range ciphertexts {
+		ciphertexts[i] = make([]byte, 16)
+	}
+	for !resolved(&candidates) {
+		if result.Sets == MaxSets {
+			return nil, errors.New("Unable to isolate the round key. Is the cipher a 4-round AES-128?")
+		}
+		if err := encryptLambdaSet(cipher, ciphertexts); err != nil {
+			return nil, err
+		}
+		result.Sets++
+		result.ChosenPlaintexts += 256
+		for j := 0; j < 16; j++ {
+			for k := 0; k < 256; k++ {
+				if candidates[j][k] && !balanced(ciphertexts, j, byte(k)) {
+					candidates[j][k] = false
+				}
+			}
+		}
+	}
+	result.RoundKey = make([]byte, 16)
+	for j := 0; j < 16; j++ {
+		for k := 0; k < 256; k++ {
+			if candidates[j][k] {
+				result.RoundKey[j] = byte(k)
+			}
+		}
+	}
+	key, err := aes.InvertKeySchedule(result.RoundKey, Rounds, 16)
+	if err != nil {
+		return nil, err
+	}
+	result.Key = key
+	if !verify(cipher, result.Key) {
+		return nil, errors.New("The recovered key does not match the oracle")
+	}
+	return result, nil
+}
+
+// encryptLambdaSet encrypts a Λ-set with random constant bytes and the first byte active
+func encryptLambdaSet(cipher modes.Cipher, ciphertexts [][]byte) error {
+	plaintext := make([]byte, 16)
+	if _, err := rand.Read(plaintext); err != nil {
+		return err
+	}
+	for i := range ciphertexts {
+		plaintext[0] = byte(i)
+		cipher.Encrypt(plaintext, ciphertexts[i])
+	}
+	return nil
+}
+
+// balanced checks if the guess for the byte j of the last round key leads to a balanced state byte
+// before the last SubBytes
+func balanced(ciphertexts [][]byte, j int, guess byte) bool {
+	var sum byte = 0
+	for _, c := range ciphertexts {
+		sum ^= aes.InvSBox(c[j] ^ guess)
+	}
+	return sum == 0
+}
+
+// resolved checks if there is only one candidate left for every byte of the round key
+func resolved(candidates *[16][256]bool) bool {
+	for j := range candidates {
+		count := 0
+		for k := range candidates[j] {
+			if candidates[j][k] {
+				count++
+			}
+		}
+		if count != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// verify compares the oracle against a cipher created with the recovered key
+func verify(cipher modes.Cipher, key []byte) bool {
+	candidate, err := aes.NewCipherWithRounds(key, Rounds)
+	if err != nil {
+		return false
+	}
+	plaintext := make([]byte, 16)
+	expected := make([]byte, 16)
+	got := make([]byte, 16)
+	cipher.Encrypt(plaintext, expected)
+	candidate.Encrypt(plaintext, got)
+	for i := range expected {
+		if expected[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
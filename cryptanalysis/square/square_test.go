@@ -0,0 +1,42 @@
+package square
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"testing"
+)
+
+func TestAttack(t *testing.T) {
+	for i := 0; i < 4; i++ {
+		key := make([]byte, 16)
+		rand.Read(key)
+		cipher, err := aes.NewCipherWithRounds(key, Rounds)
+		if err != nil {
+			t.Fatal("Error creating cipher")
+		}
+		result, err := Attack(cipher)
+		if err != nil {
+			t.Fatalf("Attack failed for key 0x%s: %s", hex.EncodeToString(key), err.Error())
+		}
+		if !bytes.Equal(result.Key, key) {
+			t.Errorf("Invalid recovered key. Expected: 0x%s Got: 0x%s",
+				hex.EncodeToString(key), hex.EncodeToString(result.Key))
+		}
+		if result.ChosenPlaintexts != result.Sets*256 {
+			t.Errorf("Invalid chosen plaintexts count %d for %d sets", result.ChosenPlaintexts, result.Sets)
+		}
+	}
+}
+
+func TestAttackFullRounds(t *testing.T) {
+	key := make([]byte, 16)
+	cipher, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal("Error creating cipher")
+	}
+	if _, err := Attack(cipher); err == nil {
+		t.Errorf("Attack succeeded against the full cipher")
+	}
+}
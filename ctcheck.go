@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/ctcheck"
+	"math"
+	"sort"
+)
+
+type ctcheckCommand struct {
+	Samples int  `short:"n" long:"samples" description:"Number of measurements per target" default:"100000"`
+	Verbose bool `short:"v" long:"verbose" description:"Outputs the t-statistic along the test"`
+}
+
+func init() {
+	parser.AddCommand(
+		"ctcheck",
+		"Checks the cipher backends and helpers for timing leaks",
+		"Runs Welch's t-test over the execution times of fixed and random inputs (dudect methodology) for every cipher backend, the padding removal of the decryption and the tag comparison helpers. |t| above 4.5 indicates a likely leak.",
+		&ctcheckCommand{},
+	)
+}
+
+func (c *ctcheckCommand) Execute(args []string) error {
+	if c.Samples < 2 {
+		return errors.New("Invalid number of samples. At least 2 are needed")
+	}
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	var targets []ctcheck.Target
+	var names []string
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cipher, err := backends[name](key)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, ctcheck.CipherTarget("encrypt/"+name, cipher))
+		targets = append(targets, ctcheck.PaddingTarget("padding/"+name, cipher))
+	}
+	targets = append(targets, ctcheck.CompareTarget("compare/bytes.Equal", bytes.Equal, secret))
+	targets = append(targets, ctcheck.CompareTarget("compare/subtle", func(a, b []byte) bool {
+		return subtle.ConstantTimeCompare(a, b) == 1
+	}, secret))
+	fmt.Printf("%-24s %10s %10s  %s\n", "target", "samples", "max |t|", "result")
+	for _, target := range targets {
+		result, err := ctcheck.Check(target, c.Samples)
+		if err != nil {
+			return err
+		}
+		status := "ok"
+		if math.Abs(result.T) > ctcheck.DefiniteLeakThreshold {
+			status = "leak"
+		} else if result.Leak() {
+			status = "probable leak"
+		}
+		fmt.Printf("%-24s %10d %10.2f  %s\n", result.Name, result.Samples, math.Abs(result.T), status)
+		if c.Verbose {
+			for i, t := range result.History {
+				fmt.Printf("  %10d %10.2f\n", (i+1)*result.Samples/len(result.History), t)
+			}
+		}
+	}
+	return nil
+}
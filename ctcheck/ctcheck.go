@@ -0,0 +1,197 @@
+// Package ctcheck looks for timing leaks following the dudect methodology.
+//
+// The target is run with inputs from two classes, a fixed input and random inputs, interleaved at random.
+// Welch's t-test is applied to the execution times of both classes, both on the raw measurements and
+// on measurements cropped at several percentiles, which removes the outliers caused by the system. A
+// |t| above LeakThreshold means that the execution time likely depends on the input.
+package ctcheck
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"math"
+	"sort"
+	"time"
+)
+
+// LeakThreshold is the |t| above which a leak is likely
+const LeakThreshold = 4.5
+
+// DefiniteLeakThreshold is the |t| above which a leak is almost certain
+const DefiniteLeakThreshold = 10
+
+// Repetitions is the number of runs of the target in each measurement
+const Repetitions = 16
+
+// HistoryPoints is the number of t-statistics recorded along the test
+const HistoryPoints = 10
+
+// percentiles are the crops applied to the measurements, 1 means no crop
+var percentiles = []float64{1, 0.99, 0.9, 0.75, 0.5}
+
+// Target is a function whose execution time is checked
+type Target struct {
+	Name string
+	// Fixed is the input of the fixed class. The random class uses random inputs of the same size.
+	Fixed []byte
+	Run   func(input []byte)
+}
+
+// Result holds the outcome of the check of a target
+type Result struct {
+	Name    string
+	Samples int
+	// T is the t-statistic with the highest absolute value among the crops
+	T float64
+	// History holds T along the test
+	History []float64
+}
+
+// Leak checks if the result indicates a likely timing leak
+func (r *Result) Leak() bool {
+	return math.Abs(r.T) > LeakThreshold
+}
+
+// stats keeps the online mean and variance of a class (Welford's algorithm)
+type stats struct {
+	n    float64
+	mean float64
+	m2   float64
+}
+
+func (s *stats) push(x float64) {
+	s.n++
+	delta := x - s.mean
+	s.mean += delta / s.n
+	s.m2 += delta * (x - s.mean)
+}
+
+// welch returns the Welch's t-statistic of the two classes
+func welch(a, b *stats) float64 {
+	if a.n < 2 || b.n < 2 {
+		return 0
+	}
+	den := math.Sqrt(a.m2/(a.n-1)/a.n + b.m2/(b.n-1)/b.n)
+	if den == 0 {
+		return 0
+	}
+	return (a.mean - b.mean) / den
+}
+
+// Check measures samples executions of the target and runs the t-test on them. At least 2 samples are
+// needed.
+func Check(target Target, samples int) (*Result, error) {
+	if samples < 2 {
+		return nil, errors.New("Invalid number of samples. At least 2 are needed")
+	}
+	size := len(target.Fixed)
+	classes := make([]byte, samples)
+	inputs := make([]byte, samples*size)
+	if _, err := rand.Read(classes); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(inputs); err != nil {
+		return nil, err
+	}
+	for i := range classes {
+		classes[i] &= 1
+		if classes[i] == 0 {
+			copy(inputs[i*size:], target.Fixed)
+		}
+	}
+	times := make([]float64, samples)
+	for i := range times {
+		input := inputs[i*size : (i+1)*size]
+		start := time.Now()
+		for j := 0; j < Repetitions; j++ {
+			target.Run(input)
+		}
+		times[i] = float64(time.Since(start).Nanoseconds())
+	}
+	// the crop thresholds are taken from the first measurements, as the warm up
+	warmUp := samples / HistoryPoints
+	if warmUp == 0 {
+		warmUp = samples
+	}
+	sorted := make([]float64, warmUp)
+	copy(sorted, times[:warmUp])
+	sort.Float64s(sorted)
+	thresholds := make([]float64, len(percentiles))
+	for i, p := range percentiles {
+		if p == 1 {
+			thresholds[i] = math.Inf(1)
+		} else {
+			thresholds[i] = sorted[int(p*float64(warmUp-1))]
+		}
+	}
+	result := new(Result)
+	result.Name = target.Name
+	result.Samples = samples
+	tests := make([][2]stats, len(percentiles))
+	for i := range times {
+		for j := range tests {
+			if times[i] <= thresholds[j] {
+				tests[j][classes[i]].push(times[i])
+			}
+		}
+		if (i+1)%warmUp == 0 || i == samples-1 {
+			result.T = 0
+			for j := range tests {
+				t := welch(&tests[j][0], &tests[j][1])
+				if math.Abs(t) > math.Abs(result.T) {
+					result.T = t
+				}
+			}
+			result.History = append(result.History, result.T)
+		}
+	}
+	return result, nil
+}
+
+// CipherTarget checks the encryption of the cipher, the fixed class being the zero block
+func CipherTarget(name string, cipher modes.Cipher) Target {
+	dest := make([]byte, cipher.BlockSize())
+	return Target{
+		Name:  name,
+		Fixed: make([]byte, cipher.BlockSize()),
+		Run: func(input []byte) {
+			cipher.Encrypt(input, dest)
+		},
+	}
+}
+
+// CompareTarget checks a tag comparison function, comparing the inputs against a secret tag. The fixed
+// class is the secret itself, so a comparison returning on the first difference is detected.
+func CompareTarget(name string, compare func(a, b []byte) bool, secret []byte) Target {
+	return Target{
+		Name:  name,
+		Fixed: secret,
+		Run: func(input []byte) {
+			compare(input, secret)
+		},
+	}
+}
+
+// PaddingTarget checks the decryption and padding removal of modes.Reader on single block messages.
+// The fixed class is a block with valid padding and the random class mostly has invalid padding.
+func PaddingTarget(name string, cipher modes.Cipher) Target {
+	size := cipher.BlockSize()
+	plain := bytes.Repeat([]byte{byte(size)}, size)
+	fixed := make([]byte, size)
+	cipher.Encrypt(plain, fixed)
+	dest := make([]byte, size)
+	return Target{
+		Name:  name,
+		Fixed: fixed,
+		Run: func(input []byte) {
+			reader := modes.NewReader(cipher, bytes.NewReader(input), modes.DECRYPTION)
+			for {
+				if n, _ := reader.Read(dest); n <= 0 {
+					break
+				}
+			}
+		},
+	}
+}
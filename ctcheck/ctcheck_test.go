@@ -0,0 +1,80 @@
+package ctcheck
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"math"
+	"testing"
+)
+
+func TestWelch(t *testing.T) {
+	var a, b stats
+	for _, x := range []float64{1, 2, 3, 4, 5} {
+		a.push(x)
+	}
+	for _, x := range []float64{2, 4, 6, 8, 10} {
+		b.push(x)
+	}
+	if a.mean != 3 || a.m2/(a.n-1) != 2.5 {
+		t.Errorf("Invalid statistics, got mean: %f, variance: %f, want mean: 3, variance: 2.5", a.mean, a.m2/(a.n-1))
+	}
+	// (3 - 6) / sqrt(2.5/5 + 10/5)
+	expected := -3 / math.Sqrt(2.5)
+	if res := welch(&a, &b); math.Abs(res-expected) > 1e-9 {
+		t.Errorf("Invalid t-statistic, got: %f, want: %f", res, expected)
+	}
+	if res := welch(&a, &a); res != 0 {
+		t.Errorf("Invalid t-statistic for the same class, got: %f, want: 0", res)
+	}
+}
+
+var sink int
+
+func TestCheckLeak(t *testing.T) {
+	target := Target{
+		Name:  "leaky",
+		Fixed: make([]byte, 16),
+		Run: func(input []byte) {
+			if input[0] == 0 {
+				for i := 0; i < 2000; i++ {
+					sink += i
+				}
+			}
+		},
+	}
+	result, err := Check(target, 2000)
+	if err != nil {
+		t.Fatalf("Error checking target: %s", err.Error())
+	}
+	if !result.Leak() || math.Abs(result.T) < DefiniteLeakThreshold {
+		t.Errorf("Leak not detected, t: %f", result.T)
+	}
+	if len(result.History) != HistoryPoints || result.Samples != 2000 {
+		t.Errorf("Invalid history length %d for %d samples", len(result.History), result.Samples)
+	}
+}
+
+func TestCompareTarget(t *testing.T) {
+	secret := []byte{0x01, 0x02, 0x03}
+	var compared [][]byte
+	target := CompareTarget("compare", func(a, b []byte) bool {
+		compared = append(compared, a)
+		return subtle.ConstantTimeCompare(a, b) == 1
+	}, secret)
+	target.Run(secret)
+	if len(compared) != 1 || !bytes.Equal(compared[0], secret) || !bytes.Equal(target.Fixed, secret) {
+		t.Errorf("Invalid comparison target")
+	}
+}
+
+func TestCheckSamples(t *testing.T) {
+	target := Target{Name: "noop", Fixed: make([]byte, 16), Run: func(input []byte) {}}
+	for _, samples := range []int{-1, 0, 1} {
+		if _, err := Check(target, samples); err == nil {
+			t.Errorf("Accepting %d samples", samples)
+		}
+	}
+	if _, err := Check(target, 2); err != nil {
+		t.Errorf("Error checking 2 samples: %s", err.Error())
+	}
+}
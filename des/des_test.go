@@ -0,0 +1,142 @@
+package des
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/des"
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"github.com/emanuelzabka/crypt-aes/modes/cbc"
+	"github.com/emanuelzabka/crypt-aes/modes/ciphertest"
+	"io"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+// NIST SP 800-20 known-answer tests: variable plaintext, variable key and substitution table
+var desVectors = []ciphertest.Vector{
+	{Key: "0101010101010101", Plaintext: "8000000000000000", Ciphertext: "95f8a5e5dd31d900"},
+	{Key: "0101010101010101", Plaintext: "4000000000000000", Ciphertext: "dd7f121ca5015619"},
+	{Key: "0101010101010101", Plaintext: "2000000000000000", Ciphertext: "2e8653104f3834ea"},
+	{Key: "0101010101010101", Plaintext: "1000000000000000", Ciphertext: "4bd388ff6cd81d4f"},
+	{Key: "8001010101010101", Plaintext: "0000000000000000", Ciphertext: "95a8d72813daa94d"},
+	{Key: "4001010101010101", Plaintext: "0000000000000000", Ciphertext: "0eec1487dd8c26d5"},
+	{Key: "2001010101010101", Plaintext: "0000000000000000", Ciphertext: "7ad16ffb79c45926"},
+	{Key: "7ca110454a1a6e57", Plaintext: "01a1d6d039776742", Ciphertext: "690f5b0d9a26939b"},
+	{Key: "0131d9619dc1376e", Plaintext: "5cd54ca83def57da", Ciphertext: "7a389d10354bd271"},
+	{Key: "133457799bbcdff1", Plaintext: "0123456789abcdef", Ciphertext: "85e813540f0ab405"},
+}
+
+func newCipher(key []byte) (modes.Cipher, error) {
+	return NewCipher(key)
+}
+
+func newTripleDESCipher(key []byte) (modes.Cipher, error) {
+	return NewTripleDESCipher(key)
+}
+
+func TestDESVectors(t *testing.T) {
+	ciphertest.CheckVectors(t, newCipher, desVectors)
+	ciphertest.CheckRoundTrip(t, newCipher, []int{8})
+}
+
+// The SP 800-20 TDEA tests use K1 = K2 = K3, reducing TDEA to DES
+func TestTripleDESVectors(t *testing.T) {
+	vectors := make([]ciphertest.Vector, len(desVectors))
+	for i, v := range desVectors {
+		vectors[i] = v
+		vectors[i].Key = v.Key + v.Key + v.Key
+	}
+	ciphertest.CheckVectors(t, newTripleDESCipher, vectors)
+	for i, v := range desVectors {
+		vectors[i] = v
+		vectors[i].Key = v.Key + v.Key
+	}
+	ciphertest.CheckVectors(t, newTripleDESCipher, vectors)
+	ciphertest.CheckRoundTrip(t, newTripleDESCipher, []int{16, 24})
+}
+
+func TestCrossCheck(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	block := make([]byte, BlockSize)
+	res := make([]byte, BlockSize)
+	expected := make([]byte, BlockSize)
+	for _, keyLength := range []int{8, 16, 24} {
+		key := make([]byte, keyLength)
+		for i := 0; i < 20; i++ {
+			random.Read(key)
+			random.Read(block)
+			var c modes.Cipher
+			var reference cipher.Block
+			switch keyLength {
+			case 8:
+				c, _ = NewCipher(key)
+				reference, _ = des.NewCipher(key)
+			case 16:
+				c, _ = NewTripleDESCipher(key)
+				reference, _ = des.NewTripleDESCipher(append(append([]byte{}, key...), key[:8]...))
+			default:
+				c, _ = NewTripleDESCipher(key)
+				reference, _ = des.NewTripleDESCipher(key)
+			}
+			c.Encrypt(block, res)
+			reference.Encrypt(expected, block)
+			if !bytes.Equal(res, expected) {
+				t.Fatalf("Invalid encryption with key 0x%x. Expected: 0x%x Got: 0x%x", key, expected, res)
+			}
+		}
+	}
+}
+
+func TestKeyLength(t *testing.T) {
+	if _, err := NewCipher(make([]byte, 7)); err == nil {
+		t.Errorf("Accepting invalid DES key length")
+	}
+	if _, err := NewTripleDESCipher(make([]byte, 8)); err == nil {
+		t.Errorf("Accepting invalid TDEA key length")
+	}
+}
+
+func TestTripleDESExample(t *testing.T) {
+	// SP 800-67 example with three keys
+	key, _ := hex.DecodeString("0123456789abcdef23456789abcdef01456789abcdef0123")
+	c, _ := NewTripleDESCipher(key)
+	plaintext := []byte("The qufck brown fox jump")
+	expected := "a826fd8ce53b855fcce21c8112256fe668d5c05dd9b6b900"
+	res := make([]byte, len(plaintext))
+	for i := 0; i < len(plaintext); i += BlockSize {
+		c.Encrypt(plaintext[i:i+BlockSize], res[i:i+BlockSize])
+	}
+	if hex.EncodeToString(res) != expected {
+		t.Errorf("Invalid encryption result. Expected: 0x%s Got: 0x%x", expected, res)
+	}
+}
+
+// testdata/legacy-3des-cbc.enc was encrypted by OpenSSL 3.0 with
+// openssl enc -des-ede3-cbc -K 0123456789abcdeffedcba987654321089abcdef01234567 -iv 0011223344556677
+func TestTripleDESCBCArchive(t *testing.T) {
+	key, _ := hex.DecodeString("0123456789abcdeffedcba987654321089abcdef01234567")
+	iv, _ := hex.DecodeString("0011223344556677")
+	expected, err := os.ReadFile("testdata/legacy.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := os.Open("testdata/legacy-3des-cbc.enc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	c, _ := NewTripleDESCipher(key)
+	mode, err := cbc.NewMode(c, iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := io.ReadAll(modes.NewReader(mode, archive, modes.DECRYPTION))
+	if err != nil {
+		t.Fatalf("Error decrypting: %s", err.Error())
+	}
+	if !bytes.Equal(decrypted, expected) {
+		t.Errorf("Invalid decryption. Expected: %q Got: %q", expected, decrypted)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/cryptanalysis/dfa"
+	"time"
+)
+
+type dfaCommand struct {
+	Pairs int `short:"n" long:"pairs" description:"Number of faulty ciphertext pairs" default:"8"`
+}
+
+func init() {
+	parser.AddCommand(
+		"dfa",
+		"Runs the differential fault analysis against AES-128",
+		"Injects single-byte faults before the MixColumns of round 9 of an AES-128 with a random key and recovers the key from the correct and faulty ciphertexts (Piret-Quisquater attack)",
+		&dfaCommand{},
+	)
+}
+
+func (c *dfaCommand) Execute(args []string) error {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	cipher, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	pairs, err := dfa.CollectPairs(cipher, c.Pairs)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	result, err := dfa.Attack(pairs)
+	if err != nil {
+		return err
+	}
+	elapsed := time.Since(start)
+	fmt.Printf("Cipher key:           %s\n", hex.EncodeToString(key))
+	fmt.Printf("Recovered round key:  %s\n", hex.EncodeToString(result.RoundKey))
+	fmt.Printf("Recovered cipher key: %s\n", hex.EncodeToString(result.Key))
+	fmt.Printf("Faulty pairs:         %d\n", len(pairs))
+	fmt.Printf("Time:                 %s\n", elapsed)
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/gf256"
+	"strconv"
+	"strings"
+)
+
+type gfCommand struct {
+	Table bool `long:"table" description:"Prints the 16x16 table of the operation (inv, log or exp) instead of a single result"`
+}
+
+func init() {
+	parser.AddCommand(
+		"gf",
+		"GF(2^8) calculator",
+		"Computes operations in GF(2^8) with the AES polynomial 0x11b. Usage: gf add|mul|div A B, gf inv|log A, gf exp A N, gf eval C0,C1,...,Cn X, gf interpolate X1:Y1,X2:Y2,... Elements are hexadecimal bytes and N is a decimal exponent.",
+		&gfCommand{},
+	)
+}
+
+func parseElement(s string) (byte, error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 8)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid field element: %s", s)
+	}
+	return byte(v), nil
+}
+
+func parseElements(s string) ([]byte, error) {
+	var result []byte
+	for _, item := range strings.Split(s, ",") {
+		v, err := parseElement(item)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+func (c *gfCommand) Execute(args []string) error {
+	if len(args) == 0 {
+		return errors.New("An operation must be informed: add, mul, div, inv, exp, log, eval or interpolate")
+	}
+	if c.Table {
+		return printGfTable(args[0])
+	}
+	if len(args) != 2 && len(args) != 3 {
+		return errors.New("Invalid number of arguments")
+	}
+	switch args[0] {
+	case "inv", "log":
+		if len(args) != 2 {
+			return errors.New("Invalid number of arguments")
+		}
+		a, err := parseElement(args[1])
+		if err != nil {
+			return err
+		}
+		if args[0] == "inv" {
+			fmt.Printf("%02x\n", gf256.Inv(a))
+		} else {
+			if a == 0 {
+				return errors.New("The logarithm of zero is undefined")
+			}
+			fmt.Println(gf256.Log(a))
+		}
+		return nil
+	case "interpolate":
+		if len(args) != 2 {
+			return errors.New("Invalid number of arguments")
+		}
+		var xs, ys []byte
+		for _, point := range strings.Split(args[1], ",") {
+			parts := strings.Split(point, ":")
+			if len(parts) != 2 {
+				return fmt.Errorf("Invalid point: %s", point)
+			}
+			x, err := parseElement(parts[0])
+			if err != nil {
+				return err
+			}
+			y, err := parseElement(parts[1])
+			if err != nil {
+				return err
+			}
+			xs = append(xs, x)
+			ys = append(ys, y)
+		}
+		p, err := gf256.Interpolate(xs, ys)
+		if err != nil {
+			return err
+		}
+		coefficients := make([]string, len(p))
+		for i, v := range p {
+			coefficients[i] = fmt.Sprintf("%02x", v)
+		}
+		fmt.Println(strings.Join(coefficients, ","))
+		return nil
+	}
+	if len(args) != 3 {
+		return errors.New("Invalid number of arguments")
+	}
+	switch args[0] {
+	case "exp":
+		a, err := parseElement(args[1])
+		if err != nil {
+			return err
+		}
+		n, err := strconv.Atoi(args[2])
+		if err != nil {
+			return fmt.Errorf("Invalid exponent: %s", args[2])
+		}
+		if a == 0 && n < 0 {
+			return errors.New("Division by zero")
+		}
+		fmt.Printf("%02x\n", gf256.Exp(a, n))
+		return nil
+	case "eval":
+		p, err := parseElements(args[1])
+		if err != nil {
+			return err
+		}
+		x, err := parseElement(args[2])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%02x\n", gf256.Polynomial(p).Eval(x))
+		return nil
+	}
+	a, err := parseElement(args[1])
+	if err != nil {
+		return err
+	}
+	b, err := parseElement(args[2])
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "add":
+		fmt.Printf("%02x\n", gf256.Add(a, b))
+	case "mul":
+		fmt.Printf("%02x\n", gf256.Mul(a, b))
+	case "div":
+		if b == 0 {
+			return errors.New("Division by zero")
+		}
+		fmt.Printf("%02x\n", gf256.Div(a, b))
+	default:
+		return fmt.Errorf("Invalid operation: %s", args[0])
+	}
+	return nil
+}
+
+// printGfTable prints a 16x16 table indexed by the high and low nibbles of the operand
+func printGfTable(op string) error {
+	var f func(a byte) string
+	switch op {
+	case "inv":
+		f = func(a byte) string { return fmt.Sprintf("%02x", gf256.Inv(a)) }
+	case "log":
+		f = func(a byte) string {
+			if a == 0 {
+				return "--"
+			}
+			return fmt.Sprintf("%02x", gf256.Log(a))
+		}
+	case "exp":
+		f = func(a byte) string { return fmt.Sprintf("%02x", gf256.Exp(gf256.Generator, int(a))) }
+	default:
+		return fmt.Errorf("Invalid table operation: %s. Available: inv, log, exp", op)
+	}
+	fmt.Print("   ")
+	for col := 0; col < 16; col++ {
+		fmt.Printf(" %x ", col)
+	}
+	fmt.Println()
+	for row := 0; row < 16; row++ {
+		fmt.Printf("%x  ", row)
+		for col := 0; col < 16; col++ {
+			fmt.Printf("%s ", f(byte(row<<4|col)))
+		}
+		fmt.Println()
+	}
+	return nil
+}
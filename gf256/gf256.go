@@ -0,0 +1,293 @@
+// Package gf256 implements the arithmetic of the finite field GF(2^8) with the AES polynomial
+// x^8 + x^4 + x^3 + x + 1 (0x11b).
+//
+// The addition is the XOR of the bytes. The multiplication, inverse and exponentiation use log and
+// antilog tables of the generator 0x03, whose lookups depend on the operands; MulCT and InvCT compute
+// the same results without secret dependent branches or lookups.
+package gf256
+
+import (
+	"errors"
+)
+
+// Poly is the reduction polynomial of the field
+const Poly = 0x11b
+
+// Generator is the generator of the multiplicative group used by the tables
+const Generator = 0x03
+
+var expTable [510]byte
+var logTable [256]int
+
+func init() {
+	var x byte = 1
+	for i := 0; i < 255; i++ {
+		expTable[i] = x
+		expTable[i+255] = x
+		logTable[x] = i
+		x = MulCT(x, Generator)
+	}
+}
+
+// Add returns a + b, which is also a - b
+func Add(a, b byte) byte {
+	return a ^ b
+}
+
+// Mul returns a * b
+func Mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[logTable[a]+logTable[b]]
+}
+
+// Div returns a / b. It panics if b is zero.
+func Div(a, b byte) byte {
+	if b == 0 {
+		panic("Division by zero.")
+	}
+	if a == 0 {
+		return 0
+	}
+	return expTable[logTable[a]+255-logTable[b]]
+}
+
+// Inv returns the multiplicative inverse of a, mapping 0 to 0 as the AES S-box
+func Inv(a byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return expTable[255-logTable[a]]
+}
+
+// Exp returns a^n. n may be negative for non-zero a.
+func Exp(a byte, n int) byte {
+	if n == 0 {
+		return 1
+	}
+	if a == 0 {
+		if n < 0 {
+			panic("Division by zero.")
+		}
+		return 0
+	}
+	e := (logTable[a] * (n % 255)) % 255
+	if e < 0 {
+		e += 255
+	}
+	return expTable[e]
+}
+
+// Log returns the discrete logarithm of a to the base Generator. It panics if a is zero.
+func Log(a byte) int {
+	if a == 0 {
+		panic("Logarithm of zero.")
+	}
+	return logTable[a]
+}
+
+// MulCT returns a * b in constant time, using a branchless version of the peasant's algorithm
+func MulCT(a, b byte) byte {
+	var prod byte = 0
+	for i := 0; i < 8; i++ {
+		prod ^= -(b & 1) & a
+		carry := -(a >> 7)
+		a = a<<1 ^ (Poly & 0xff & carry)
+		b >>= 1
+	}
+	return prod
+}
+
+// Xtime returns a * x (0x02) in constant time
+func Xtime(a byte) byte {
+	return a<<1 ^ (Poly & 0xff & -(a >> 7))
+}
+
+// XtimeWord returns each of the four bytes packed in w multiplied by x (0x02) in constant time
+func XtimeWord(w uint32) uint32 {
+	return (w&0x7f7f7f7f)<<1 ^ (w>>7&0x01010101)*(Poly&0xff)
+}
+
+// InvCT returns the multiplicative inverse of a in constant time, computing a^254 (0 for a == 0)
+func InvCT(a byte) byte {
+	// a^254 = a^(2+4+8+16+32+64+128)
+	var result byte = 1
+	square := a
+	for i := 1; i < 8; i++ {
+		square = MulCT(square, square)
+		result = MulCT(result, square)
+	}
+	return result
+}
+
+// Polynomial is a polynomial over GF(2^8), the element i being the coefficient of x^i
+type Polynomial []byte
+
+// Eval returns the value of the polynomial at x
+func (p Polynomial) Eval(x byte) byte {
+	var result byte = 0
+	for i := len(p) - 1; i >= 0; i-- {
+		result = Mul(result, x) ^ p[i]
+	}
+	return result
+}
+
+// Add returns p + q
+func (p Polynomial) Add(q Polynomial) Polynomial {
+	if len(p) < len(q) {
+		p, q = q, p
+	}
+	result := make(Polynomial, len(p))
+	copy(result, p)
+	for i := range q {
+		result[i] ^= q[i]
+	}
+	return result
+}
+
+// Mul returns p * q
+func (p Polynomial) Mul(q Polynomial) Polynomial {
+	if len(p) == 0 || len(q) == 0 {
+		return Polynomial{}
+	}
+	result := make(Polynomial, len(p)+len(q)-1)
+	for i := range p {
+		for j := range q {
+			result[i+j] ^= Mul(p[i], q[j])
+		}
+	}
+	return result
+}
+
+// Interpolate returns the polynomial of lowest degree with p(xs[i]) == ys[i] (Lagrange interpolation).
+// The values of xs must be distinct.
+func Interpolate(xs, ys []byte) (Polynomial, error) {
+	if len(xs) != len(ys) || len(xs) == 0 {
+		return nil, errors.New("Invalid points. xs and ys must have the same non-zero length")
+	}
+	result := make(Polynomial, len(xs))
+	for i := range xs {
+		basis := Polynomial{1}
+		var den byte = 1
+		for j := range xs {
+			if i == j {
+				continue
+			}
+			if xs[i] == xs[j] {
+				return nil, errors.New("Invalid points. The values of xs must be distinct")
+			}
+			basis = basis.Mul(Polynomial{xs[j], 1})
+			den = Mul(den, xs[i]^xs[j])
+		}
+		factor := Div(ys[i], den)
+		for k := range basis {
+			result[k] ^= Mul(basis[k], factor)
+		}
+	}
+	return result, nil
+}
+
+// Matrix is a matrix over GF(2^8), stored by rows
+type Matrix [][]byte
+
+// NewMatrix creates a zero matrix
+func NewMatrix(rows, cols int) Matrix {
+	m := make(Matrix, rows)
+	for i := range m {
+		m[i] = make([]byte, cols)
+	}
+	return m
+}
+
+// Identity creates the identity matrix of size n
+func Identity(n int) Matrix {
+	m := NewMatrix(n, n)
+	for i := range m {
+		m[i][i] = 1
+	}
+	return m
+}
+
+// Vandermonde creates the matrix whose element (i, j) is i^j. Any cols rows of it form an invertible
+// matrix, as the rows have distinct elements. rows must not be greater than 256.
+func Vandermonde(rows, cols int) Matrix {
+	m := NewMatrix(rows, cols)
+	for i := range m {
+		for j := range m[i] {
+			m[i][j] = Exp(byte(i), j)
+		}
+	}
+	return m
+}
+
+// Mul returns m * n
+func (m Matrix) Mul(n Matrix) (Matrix, error) {
+	if len(m) == 0 || len(n) == 0 || len(m[0]) != len(n) {
+		return nil, errors.New("Invalid matrix dimensions")
+	}
+	result := NewMatrix(len(m), len(n[0]))
+	for i := range result {
+		for j := range result[i] {
+			for k := range n {
+				result[i][j] ^= Mul(m[i][k], n[k][j])
+			}
+		}
+	}
+	return result, nil
+}
+
+// MulVec returns m * v
+func (m Matrix) MulVec(v []byte) ([]byte, error) {
+	if len(m) == 0 || len(m[0]) != len(v) {
+		return nil, errors.New("Invalid matrix dimensions")
+	}
+	result := make([]byte, len(m))
+	for i := range m {
+		for j := range v {
+			result[i] ^= Mul(m[i][j], v[j])
+		}
+	}
+	return result, nil
+}
+
+// Invert returns the inverse of the square matrix m (Gauss-Jordan elimination)
+func (m Matrix) Invert() (Matrix, error) {
+	n := len(m)
+	work := NewMatrix(n, 2*n)
+	for i := range m {
+		if len(m[i]) != n {
+			return nil, errors.New("Invalid matrix dimensions. The matrix must be square")
+		}
+		copy(work[i], m[i])
+		work[i][n+i] = 1
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for pivot < n && work[pivot][col] == 0 {
+			pivot++
+		}
+		if pivot == n {
+			return nil, errors.New("Singular matrix")
+		}
+		work[col], work[pivot] = work[pivot], work[col]
+		factor := Inv(work[col][col])
+		for j := range work[col] {
+			work[col][j] = Mul(work[col][j], factor)
+		}
+		for i := 0; i < n; i++ {
+			if i == col || work[i][col] == 0 {
+				continue
+			}
+			factor := work[i][col]
+			for j := range work[i] {
+				work[i][j] ^= Mul(factor, work[col][j])
+			}
+		}
+	}
+	result := NewMatrix(n, n)
+	for i := range result {
+		copy(result[i], work[i][n:])
+	}
+	return result, nil
+}
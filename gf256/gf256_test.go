@@ -0,0 +1,166 @@
+package gf256
+
+import (
+	"bytes"
+	"testing"
+)
+
+// peasantMul is the plain shift and add multiplication used as reference
+func peasantMul(a, b byte) byte {
+	var prod byte = 0
+	for a != 0 && b != 0 {
+		if b&1 != 0 {
+			prod ^= a
+		}
+		b >>= 1
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+	}
+	return prod
+}
+
+func TestMul(t *testing.T) {
+	// FIPS-197 section 4.2
+	if got := Mul(0x57, 0x83); got != 0xc1 {
+		t.Errorf("Invalid multiplication. Expected: 0xc1 Got: 0x%02x", got)
+	}
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			expected := peasantMul(byte(a), byte(b))
+			if got := Mul(byte(a), byte(b)); got != expected {
+				t.Fatalf("Invalid multiplication of 0x%02x and 0x%02x. Expected: 0x%02x Got: 0x%02x", a, b, expected, got)
+			}
+			if got := MulCT(byte(a), byte(b)); got != expected {
+				t.Fatalf("Invalid constant time multiplication of 0x%02x and 0x%02x. Expected: 0x%02x Got: 0x%02x", a, b, expected, got)
+			}
+		}
+	}
+}
+
+func TestXtime(t *testing.T) {
+	for a := 0; a < 256; a++ {
+		expected := Mul(byte(a), 0x02)
+		if got := Xtime(byte(a)); got != expected {
+			t.Fatalf("Invalid xtime of 0x%02x. Expected: 0x%02x Got: 0x%02x", a, expected, got)
+		}
+		w := uint32(a)<<24 | uint32(a^0x55)<<16 | uint32(a^0xaa)<<8 | uint32(255-a)
+		expectedWord := uint32(expected)<<24 | uint32(Xtime(byte(a^0x55)))<<16 | uint32(Xtime(byte(a^0xaa)))<<8 | uint32(Xtime(byte(255-a)))
+		if got := XtimeWord(w); got != expectedWord {
+			t.Fatalf("Invalid xtime of the word 0x%08x. Expected: 0x%08x Got: 0x%08x", w, expectedWord, got)
+		}
+	}
+}
+
+func TestInv(t *testing.T) {
+	if Inv(0) != 0 || InvCT(0) != 0 {
+		t.Errorf("Invalid inverse of zero")
+	}
+	for a := 1; a < 256; a++ {
+		inv := Inv(byte(a))
+		if Mul(byte(a), inv) != 1 {
+			t.Fatalf("Invalid inverse of 0x%02x: 0x%02x", a, inv)
+		}
+		if got := InvCT(byte(a)); got != inv {
+			t.Fatalf("Invalid constant time inverse of 0x%02x. Expected: 0x%02x Got: 0x%02x", a, inv, got)
+		}
+		if got := Div(1, byte(a)); got != inv {
+			t.Fatalf("Invalid division of 1 by 0x%02x. Expected: 0x%02x Got: 0x%02x", a, inv, got)
+		}
+	}
+}
+
+func TestExpLog(t *testing.T) {
+	for a := 0; a < 256; a++ {
+		var expected byte = 1
+		for n := 0; n < 300; n++ {
+			if got := Exp(byte(a), n); got != expected {
+				t.Fatalf("Invalid 0x%02x^%d. Expected: 0x%02x Got: 0x%02x", a, n, expected, got)
+			}
+			expected = Mul(expected, byte(a))
+		}
+		if a != 0 {
+			if got := Exp(byte(a), -1); got != Inv(byte(a)) {
+				t.Fatalf("Invalid 0x%02x^-1. Expected: 0x%02x Got: 0x%02x", a, Inv(byte(a)), got)
+			}
+			if got := Exp(Generator, Log(byte(a))); got != byte(a) {
+				t.Fatalf("Invalid logarithm of 0x%02x", a)
+			}
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	p := Polynomial{0x2a, 0x13, 0xf0, 0x07}
+	xs := []byte{0x01, 0x02, 0x03, 0xfe}
+	ys := make([]byte, len(xs))
+	for i, x := range xs {
+		ys[i] = p.Eval(x)
+	}
+	got, err := Interpolate(xs, ys)
+	if err != nil {
+		t.Fatalf("Error interpolating: %s", err.Error())
+	}
+	if !bytes.Equal(got, p) {
+		t.Errorf("Invalid interpolated polynomial. Expected: %x Got: %x", []byte(p), []byte(got))
+	}
+	if _, err := Interpolate([]byte{1, 1}, []byte{2, 3}); err == nil {
+		t.Errorf("Accepting repeated points")
+	}
+}
+
+func TestMatrixInvert(t *testing.T) {
+	// AES MixColumns and InvMixColumns matrices
+	m := Matrix{
+		{0x02, 0x03, 0x01, 0x01},
+		{0x01, 0x02, 0x03, 0x01},
+		{0x01, 0x01, 0x02, 0x03},
+		{0x03, 0x01, 0x01, 0x02},
+	}
+	expected := Matrix{
+		{0x0e, 0x0b, 0x0d, 0x09},
+		{0x09, 0x0e, 0x0b, 0x0d},
+		{0x0d, 0x09, 0x0e, 0x0b},
+		{0x0b, 0x0d, 0x09, 0x0e},
+	}
+	inv, err := m.Invert()
+	if err != nil {
+		t.Fatalf("Error inverting matrix: %s", err.Error())
+	}
+	for i := range expected {
+		if !bytes.Equal(inv[i], expected[i]) {
+			t.Errorf("Invalid inverse row %d. Expected: %x Got: %x", i, expected[i], inv[i])
+		}
+	}
+	prod, err := m.Mul(inv)
+	if err != nil {
+		t.Fatalf("Error multiplying matrices: %s", err.Error())
+	}
+	for i, row := range Identity(4) {
+		if !bytes.Equal(prod[i], row) {
+			t.Errorf("Invalid product row %d: %x", i, prod[i])
+		}
+	}
+	// FIPS-197 appendix B, first column of round 1
+	column, err := m.MulVec([]byte{0xd4, 0xbf, 0x5d, 0x30})
+	if err != nil {
+		t.Fatalf("Error multiplying vector: %s", err.Error())
+	}
+	if !bytes.Equal(column, []byte{0x04, 0x66, 0x81, 0xe5}) {
+		t.Errorf("Invalid mixed column. Expected: 046681e5 Got: %x", column)
+	}
+	if _, err := NewMatrix(3, 3).Invert(); err == nil {
+		t.Errorf("Inverting singular matrix")
+	}
+}
+
+func TestVandermonde(t *testing.T) {
+	// every square submatrix made of distinct rows of a Vandermonde matrix is invertible
+	v := Vandermonde(8, 4)
+	sub := Matrix{v[7], v[2], v[5], v[0]}
+	if _, err := sub.Invert(); err != nil {
+		t.Errorf("Error inverting Vandermonde submatrix: %s", err.Error())
+	}
+}
@@ -0,0 +1,34 @@
+// Package fault gives the fault attack experiments of the module access to the fault injection hook of
+// the AES implementation, which is not part of its public API.
+package fault
+
+// Injector simulates computation faults. Inject is called with the state after each transformation,
+// using the same round numbers and step names of aes.Tracer except for the k_sch/ik_sch steps, and may
+// change the state in place.
+type Injector interface {
+	Inject(round int, step string, state []byte)
+}
+
+// hooks installed by the aes package
+var (
+	setHook func(cipher interface{}, injector Injector)
+	getHook func(cipher interface{}) Injector
+)
+
+// Register installs the functions setting and returning the injector of a cipher. It is called by the
+// aes package on initialization.
+func Register(set func(cipher interface{}, injector Injector), get func(cipher interface{}) Injector) {
+	setHook = set
+	getHook = get
+}
+
+// SetInjector sets the injector called during the Encrypt and Decrypt of an *aes.AESCipher. A nil
+// injector disables the fault injection. It panics on ciphers returned by an aes.Cache.
+func SetInjector(cipher interface{}, injector Injector) {
+	setHook(cipher, injector)
+}
+
+// CurrentInjector returns the injector of an *aes.AESCipher, or nil
+func CurrentInjector(cipher interface{}) Injector {
+	return getHook(cipher)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/aes"
+)
+
+type keyScheduleCommand struct {
+	Key       string `short:"k" long:"key" description:"Cipher key whose round keys are dumped"`
+	RoundKey  string `long:"round-key" description:"Round key to invert (two consecutive round keys for 192 and 256-bit keys)"`
+	Round     int    `short:"r" long:"round" description:"Index of the (first) round key to invert"`
+	KeyLength int    `short:"l" long:"key-length" description:"Key length used to invert the round key" choice:"128" choice:"192" choice:"256" default:"128"`
+}
+
+func init() {
+	parser.AddCommand(
+		"key-schedule",
+		"Dumps the round keys of a key or recovers the key from a round key",
+		"Dumps all round keys of the cipher key given with --key, or inverts the key schedule from the round key given with --round-key and --round. 192 and 256-bit keys need two consecutive round keys.",
+		&keyScheduleCommand{},
+	)
+}
+
+func (c *keyScheduleCommand) Execute(args []string) error {
+	if (c.Key == "") == (c.RoundKey == "") {
+		return errors.New("Either --key or --round-key must be informed")
+	}
+	if c.Key != "" {
+		key, err := hex.DecodeString(c.Key)
+		if err != nil {
+			return fmt.Errorf("Error decoding the provided key: %s", c.Key)
+		}
+		cipher, err := aes.NewCipher(key)
+		if err != nil {
+			return err
+		}
+		for i, roundKey := range cipher.RoundKeys() {
+			fmt.Printf("round[%2d] %s\n", i, hex.EncodeToString(roundKey))
+		}
+		return nil
+	}
+	roundKey, err := hex.DecodeString(c.RoundKey)
+	if err != nil {
+		return fmt.Errorf("Error decoding the provided round key: %s", c.RoundKey)
+	}
+	key, err := aes.InvertKeySchedule(roundKey, c.Round, c.KeyLength/8)
+	if err != nil {
+		return err
+	}
+	fmt.Println(hex.EncodeToString(key))
+	return nil
+}
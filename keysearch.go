@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/keysearch"
+	"os"
+	"os/signal"
+	"runtime"
+	"time"
+)
+
+type keySearchCommand struct {
+	Template    string   `short:"t" long:"template" description:"Hexadecimal key with '?' in place of each unknown nibble" required:"yes"`
+	Plaintexts  []string `short:"p" long:"plaintext" description:"Known plaintext block (repeat for more pairs)" required:"yes"`
+	Ciphertexts []string `short:"c" long:"ciphertext" description:"Ciphertext block of the plaintext of the same position (repeat for more pairs)" required:"yes"`
+	Workers     int      `short:"w" long:"workers" description:"Number of parallel workers (default: number of CPUs)"`
+	Checkpoint  string   `long:"checkpoint" description:"File where the progress is saved, and read from when it exists"`
+	Interval    int      `long:"interval" description:"Seconds between progress reports and checkpoints" default:"5"`
+}
+
+// keySearchCheckpoint is the content of the checkpoint file
+type keySearchCheckpoint struct {
+	Template string `json:"template"`
+	Pairs    string `json:"pairs"`
+	Done     uint64 `json:"done"`
+}
+
+func init() {
+	parser.AddCommand(
+		"keysearch",
+		"Searches the unknown nibbles of a partially known AES key",
+		"Tries every key matching the template against the known plaintext/ciphertext pairs using all CPUs. The progress is saved periodically to the checkpoint file, and a search interrupted with Ctrl-C is resumed when run again with the same checkpoint file.",
+		&keySearchCommand{},
+	)
+}
+
+// pairsDigest returns the hexadecimal SHA-256 of the pairs, stored in the checkpoint so that it is not
+// resumed with other pairs
+func pairsDigest(pairs []keysearch.Pair) string {
+	h := sha256.New()
+	for _, p := range pairs {
+		h.Write(p.Plaintext)
+		h.Write(p.Ciphertext)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func readCheckpoint(path string, template *keysearch.Template, pairs []keysearch.Pair) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var checkpoint keySearchCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return 0, fmt.Errorf("Invalid checkpoint file: %s", err.Error())
+	}
+	if checkpoint.Template != template.String() {
+		return 0, errors.New("The checkpoint file belongs to a search with another template")
+	}
+	if checkpoint.Pairs != pairsDigest(pairs) {
+		return 0, errors.New("The checkpoint file belongs to a search with other pairs")
+	}
+	return checkpoint.Done, nil
+}
+
+func writeCheckpoint(path string, template *keysearch.Template, pairs []keysearch.Pair, done uint64) error {
+	data, err := json.Marshal(keySearchCheckpoint{Template: template.String(), Pairs: pairsDigest(pairs), Done: done})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func (c *keySearchCommand) Execute(args []string) error {
+	template, err := keysearch.ParseTemplate(c.Template)
+	if err != nil {
+		return err
+	}
+	plaintexts, err := decodeBlocks(c.Plaintexts)
+	if err != nil {
+		return err
+	}
+	ciphertexts, err := decodeBlocks(c.Ciphertexts)
+	if err != nil {
+		return err
+	}
+	if len(plaintexts) != len(ciphertexts) {
+		return errors.New("Each plaintext must have a ciphertext")
+	}
+	pairs := make([]keysearch.Pair, len(plaintexts))
+	for i := range pairs {
+		pairs[i] = keysearch.Pair{Plaintext: plaintexts[i], Ciphertext: ciphertexts[i]}
+	}
+	if c.Interval <= 0 {
+		return errors.New("Invalid interval. It must be at least 1 second")
+	}
+	workers := c.Workers
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+	search, err := keysearch.NewSearch(template, pairs, workers)
+	if err != nil {
+		return err
+	}
+	if c.Checkpoint != "" {
+		if search.Start, err = readCheckpoint(c.Checkpoint, template, pairs); err != nil {
+			return err
+		}
+	}
+	total := template.Candidates()
+	fmt.Fprintf(os.Stderr, "Searching %d candidates with %d workers, starting at %d\n", total, workers, search.Start)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ticker := time.NewTicker(time.Duration(c.Interval) * time.Second)
+	finished := make(chan struct{})
+	reporterDone := make(chan struct{})
+	start := time.Now()
+	go func() {
+		defer close(reporterDone)
+		for {
+			select {
+			case <-finished:
+				return
+			case <-ticker.C:
+			}
+			done := search.Done()
+			// A resumed search restarts at the chunk of Start, so the watermark may stay below it for a while
+			var processed uint64
+			if done > search.Start {
+				processed = done - search.Start
+			}
+			rate := float64(processed) / time.Since(start).Seconds()
+			fmt.Fprintf(os.Stderr, "Progress: %d/%d (%.2f%%) %.0f keys/s\n", done, total, 100*float64(done)/float64(total), rate)
+			if c.Checkpoint != "" {
+				if err := writeCheckpoint(c.Checkpoint, template, pairs, done); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing checkpoint: %s\n", err.Error())
+				}
+			}
+		}
+	}()
+	key, err := search.Run(ctx)
+	// The reporter is stopped before the last checkpoint so that it cannot overwrite it
+	ticker.Stop()
+	close(finished)
+	<-reporterDone
+	if c.Checkpoint != "" {
+		if err := writeCheckpoint(c.Checkpoint, template, pairs, search.Done()); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("Search interrupted at %d/%d", search.Done(), total)
+	}
+	fmt.Fprintf(os.Stderr, "Time: %s\n", time.Since(start))
+	if key == nil {
+		return errors.New("No key matches the template and the pairs")
+	}
+	fmt.Println(hex.EncodeToString(key))
+	return nil
+}
@@ -0,0 +1,236 @@
+// Package keysearch implements a parallel brute-force search of AES keys whose value is partially known,
+// testing the candidates against known plaintext/ciphertext block pairs.
+//
+// The unknown nibbles of the key template are enumerated as the digits of a candidate index, most
+// significant unknown nibble first. The index space is split into chunks handed to the workers, and the
+// search keeps a watermark below which every candidate was tested, used as checkpoint to resume it.
+package keysearch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ChunkSize is the number of candidates tested by a worker at a time
+const ChunkSize = 1 << 12
+
+// Template is a key with known and unknown nibbles
+type Template struct {
+	// Key holds the known nibbles, the unknown ones being zero
+	Key []byte
+	// Unknown are the positions of the unknown nibbles, 0 being the high nibble of the first byte
+	Unknown []int
+}
+
+// ParseTemplate parses a hexadecimal key where each unknown nibble is written as '?', for example
+// "2b7e1516????d2a6abf71588??cf4f3c". Spaces are ignored.
+func ParseTemplate(s string) (*Template, error) {
+	s = strings.ReplaceAll(s, " ", "")
+	if len(s) != 32 && len(s) != 48 && len(s) != 64 {
+		return nil, errors.New("Invalid key template length. Allowed: 32, 48 or 64 nibbles")
+	}
+	t := new(Template)
+	t.Key = make([]byte, len(s)/2)
+	for i, ch := range strings.ToLower(s) {
+		var v byte
+		switch {
+		case ch == '?':
+			t.Unknown = append(t.Unknown, i)
+			continue
+		case ch >= '0' && ch <= '9':
+			v = byte(ch - '0')
+		case ch >= 'a' && ch <= 'f':
+			v = byte(ch-'a') + 10
+		default:
+			return nil, errors.New("Invalid key template. Use hexadecimal digits and '?' for unknown nibbles")
+		}
+		if i%2 == 0 {
+			t.Key[i/2] |= v << 4
+		} else {
+			t.Key[i/2] |= v
+		}
+	}
+	if len(t.Unknown) > 15 {
+		return nil, errors.New("Too many unknown nibbles. At most 15 are allowed")
+	}
+	return t, nil
+}
+
+// String returns the template in the format read by ParseTemplate
+func (t *Template) String() string {
+	const digits = "0123456789abcdef"
+	result := make([]byte, 2*len(t.Key))
+	for i := range result {
+		if i%2 == 0 {
+			result[i] = digits[t.Key[i/2]>>4]
+		} else {
+			result[i] = digits[t.Key[i/2]&0xf]
+		}
+	}
+	for _, pos := range t.Unknown {
+		result[pos] = '?'
+	}
+	return string(result)
+}
+
+// Candidates returns the number of keys matching the template
+func (t *Template) Candidates() uint64 {
+	return 1 << (4 * uint(len(t.Unknown)))
+}
+
+// Candidate writes into dest the key of index i
+func (t *Template) Candidate(i uint64, dest []byte) {
+	copy(dest, t.Key)
+	for j := len(t.Unknown) - 1; j >= 0; j-- {
+		pos := t.Unknown[j]
+		v := byte(i & 0xf)
+		i >>= 4
+		if pos%2 == 0 {
+			dest[pos/2] |= v << 4
+		} else {
+			dest[pos/2] |= v
+		}
+	}
+}
+
+// Pair is a known plaintext block and its ciphertext
+type Pair struct {
+	Plaintext  []byte
+	Ciphertext []byte
+}
+
+// Search is a key search. Start may be set to resume a previous search from its checkpoint.
+type Search struct {
+	Template *Template
+	Pairs    []Pair
+	Workers  int
+	Start    uint64
+
+	next      uint64
+	watermark uint64
+	mutex     sync.Mutex
+	finished  map[uint64]bool
+}
+
+// NewSearch creates a search of the key matching the template and every pair
+func NewSearch(template *Template, pairs []Pair, workers int) (*Search, error) {
+	if len(pairs) == 0 {
+		return nil, errors.New("At least one plaintext/ciphertext pair is required")
+	}
+	for _, p := range pairs {
+		if len(p.Plaintext) != 16 || len(p.Ciphertext) != 16 {
+			return nil, errors.New("Invalid block length. Plaintext and ciphertext blocks must be 16 bytes long")
+		}
+	}
+	if workers < 1 {
+		return nil, errors.New("Invalid number of workers")
+	}
+	s := new(Search)
+	s.Template = template
+	s.Pairs = pairs
+	s.Workers = workers
+	return s, nil
+}
+
+// Done returns the number of candidates below which every key was tested. It is safe to call it while
+// the search runs and its value can be used as Start to resume the search.
+func (s *Search) Done() uint64 {
+	return atomic.LoadUint64(&s.watermark)
+}
+
+// finish marks the chunk starting at start as tested and advances the watermark
+func (s *Search) finish(start uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.finished[start] = true
+	w := atomic.LoadUint64(&s.watermark)
+	for s.finished[w] {
+		delete(s.finished, w)
+		w += ChunkSize
+	}
+	if total := s.Template.Candidates(); w > total {
+		w = total
+	}
+	atomic.StoreUint64(&s.watermark, w)
+}
+
+// test returns whether key encrypts every pair. The key is expanded in place over the schedule of the
+// worker cipher c, the remaining pairs being checked only when the first one matches.
+func (s *Search) test(c *aes.AESCipher, key, dest []byte) bool {
+	if err := c.SetKey(key); err != nil {
+		return false
+	}
+	for _, p := range s.Pairs {
+		c.Encrypt(p.Plaintext, dest)
+		if !bytes.Equal(dest, p.Ciphertext) {
+			return false
+		}
+	}
+	return true
+}
+
+// Run searches the key with the workers, stopping on the first match or when ctx is done. It returns
+// the key found or nil if there was no match, and ctx.Err() if the search was interrupted.
+func (s *Search) Run(ctx context.Context) ([]byte, error) {
+	total := s.Template.Candidates()
+	start := s.Start - s.Start%ChunkSize
+	s.next = start
+	s.watermark = start
+	s.finished = make(map[uint64]bool)
+	// Each worker reuses its own cipher, created here so that a failed self test stops the search
+	ciphers := make([]*aes.AESCipher, s.Workers)
+	for w := range ciphers {
+		c, err := aes.NewCipher(s.Template.Key)
+		if err != nil {
+			return nil, err
+		}
+		ciphers[w] = c
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var found []byte
+	var once sync.Once
+	var wg sync.WaitGroup
+	for w := 0; w < s.Workers; w++ {
+		wg.Add(1)
+		go func(c *aes.AESCipher) {
+			defer wg.Done()
+			key := make([]byte, len(s.Template.Key))
+			dest := make([]byte, 16)
+			for ctx.Err() == nil {
+				chunk := atomic.AddUint64(&s.next, ChunkSize) - ChunkSize
+				if chunk >= total {
+					return
+				}
+				end := chunk + ChunkSize
+				if end > total {
+					end = total
+				}
+				for i := chunk; i < end; i++ {
+					s.Template.Candidate(i, key)
+					if s.test(c, key, dest) {
+						once.Do(func() {
+							found = append([]byte{}, key...)
+							cancel()
+						})
+						return
+					}
+				}
+				s.finish(chunk)
+			}
+		}(ciphers[w])
+	}
+	wg.Wait()
+	if found != nil {
+		return found, nil
+	}
+	if err := ctx.Err(); err != nil && s.Done() < total {
+		return nil, err
+	}
+	return nil, nil
+}
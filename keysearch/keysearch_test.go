@@ -0,0 +1,89 @@
+package keysearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"testing"
+)
+
+func TestParseTemplate(t *testing.T) {
+	s := "2b7e1516????d2a6abf71588??cf4f3?"
+	template, err := ParseTemplate(s)
+	if err != nil {
+		t.Fatalf("Error parsing template: %s", err.Error())
+	}
+	if template.String() != s {
+		t.Errorf("Invalid template. Expected: %s Got: %s", s, template.String())
+	}
+	if template.Candidates() != 1<<28 {
+		t.Errorf("Invalid number of candidates: %d", template.Candidates())
+	}
+	key := make([]byte, 16)
+	template.Candidate(0x1234567, key)
+	expected := "2b7e15161234d2a6abf7158856cf4f37"
+	if hex.EncodeToString(key) != expected {
+		t.Errorf("Invalid candidate. Expected: 0x%s Got: 0x%x", expected, key)
+	}
+	if _, err := ParseTemplate("2b7e1516"); err == nil {
+		t.Errorf("Accepting short template")
+	}
+	if _, err := ParseTemplate("2b7e1516x28aed2a6abf7158809cf4f3c"); err == nil {
+		t.Errorf("Accepting invalid digit")
+	}
+}
+
+func newPairs(key []byte) []Pair {
+	c, _ := aes.NewCipher(key)
+	pairs := []Pair{
+		{Plaintext: []byte("known plaintext1")},
+		{Plaintext: []byte("known plaintext2")},
+	}
+	for i := range pairs {
+		pairs[i].Ciphertext = make([]byte, 16)
+		c.Encrypt(pairs[i].Plaintext, pairs[i].Ciphertext)
+	}
+	return pairs
+}
+
+func TestSearch(t *testing.T) {
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	template, _ := ParseTemplate("2b7e151628aed2a6abf7158809??4f??")
+	s, err := NewSearch(template, newPairs(key), 4)
+	if err != nil {
+		t.Fatalf("Error creating search: %s", err.Error())
+	}
+	found, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Error searching: %s", err.Error())
+	}
+	if !bytes.Equal(found, key) {
+		t.Errorf("Invalid key found. Expected: 0x%x Got: 0x%x", key, found)
+	}
+	// resuming after the key skips it
+	s, _ = NewSearch(template, newPairs(key), 4)
+	s.Start = 0xd000
+	found, err = s.Run(context.Background())
+	if err != nil || found != nil {
+		t.Errorf("Invalid resumed search. Expected no key. Got: 0x%x", found)
+	}
+	if s.Done() != template.Candidates() {
+		t.Errorf("Invalid done count. Expected: %d Got: %d", template.Candidates(), s.Done())
+	}
+}
+
+func TestSearchCancel(t *testing.T) {
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	template, _ := ParseTemplate("2b7e151628aed2a6abf715????????3c")
+	s, _ := NewSearch(template, newPairs(key), 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	found, err := s.Run(ctx)
+	if err == nil || found != nil {
+		t.Errorf("Search not interrupted")
+	}
+	if s.Done() != 0 {
+		t.Errorf("Invalid done count after interruption: %d", s.Done())
+	}
+}
@@ -0,0 +1,93 @@
+package paddingoracle
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+)
+
+// ErrNoPadding is returned by the attack when no guess of a byte gives a valid padding, as with an
+// authenticating server
+var ErrNoPadding = errors.New("No guess gives a valid padding. The oracle does not leak the padding")
+
+// intermediate finds the block cipher decryption of block, querying the oracle with a crafted previous
+// block for each byte from the last one
+func intermediate(oracle Oracle, block []byte) ([]byte, error) {
+	result := make([]byte, BlockSize)
+	query := make([]byte, 2*BlockSize)
+	copy(query[BlockSize:], block)
+	for i := BlockSize - 1; i >= 0; i-- {
+		pad := byte(BlockSize - i)
+		for j := i + 1; j < BlockSize; j++ {
+			query[j] = result[j] ^ pad
+		}
+		found := false
+		for guess := 0; guess < 256 && !found; guess++ {
+			query[i] = byte(guess)
+			ok, err := oracle(query)
+			if err != nil {
+				return nil, err
+			}
+			if ok && i == BlockSize-1 {
+				// the padding may be longer than one byte by chance: changing the previous byte
+				// breaks it
+				query[i-1] ^= 0xff
+				ok, err = oracle(query)
+				query[i-1] ^= 0xff
+				if err != nil {
+					return nil, err
+				}
+			}
+			if ok {
+				result[i] = byte(guess) ^ pad
+				found = true
+			}
+		}
+		if !found {
+			return nil, ErrNoPadding
+		}
+	}
+	return result, nil
+}
+
+// Decrypt recovers the plaintext of the IV and ciphertext, without the padding, through the oracle
+func Decrypt(oracle Oracle, message []byte) ([]byte, error) {
+	if len(message) < 2*BlockSize || len(message)%BlockSize != 0 {
+		return nil, errors.New("Invalid message length")
+	}
+	plaintext := make([]byte, len(message)-BlockSize)
+	for i := BlockSize; i < len(message); i += BlockSize {
+		decrypted, err := intermediate(oracle, message[i:i+BlockSize])
+		if err != nil {
+			return nil, err
+		}
+		subtle.XORBytes(plaintext[i-BlockSize:i], decrypted, message[i-BlockSize:i])
+	}
+	pad := int(plaintext[len(plaintext)-1])
+	if pad < 1 || pad > BlockSize {
+		return nil, errors.New("Invalid padding of the recovered plaintext")
+	}
+	return plaintext[:len(plaintext)-pad], nil
+}
+
+// Forge builds an IV and ciphertext decrypting to plaintext without knowing the key, starting from a
+// random last block and choosing each previous block from the decryption of the next one
+func Forge(oracle Oracle, plaintext []byte) ([]byte, error) {
+	pad := BlockSize - len(plaintext)%BlockSize
+	padded := append([]byte{}, plaintext...)
+	for i := 0; i < pad; i++ {
+		padded = append(padded, byte(pad))
+	}
+	message := make([]byte, len(padded)+BlockSize)
+	if _, err := rand.Read(message[len(padded):]); err != nil {
+		return nil, err
+	}
+	for i := len(padded); i > 0; i -= BlockSize {
+		decrypted, err := intermediate(oracle, message[i:i+BlockSize])
+		if err != nil {
+			return nil, err
+		}
+		subtle.XORBytes(message[i-BlockSize:i], decrypted, padded[i-BlockSize:i])
+	}
+	return message, nil
+}
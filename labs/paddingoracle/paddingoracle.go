@@ -0,0 +1,172 @@
+// Package paddingoracle is a lab of the CBC padding oracle attack (Vaudenay, 2002). Server decrypts CBC
+// ciphertext with modes.Reader and reports only whether the padding was valid, locally or through a
+// loopback HTTP handler, and Decrypt and Forge attack it one byte at a time.
+//
+// The padding check of modes.Reader runs in constant time, which does not stop the attack: the answer
+// itself is the leak. Only authenticating the messages before decrypting them does.
+package paddingoracle
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"github.com/emanuelzabka/crypt-aes/modes/cbc"
+	"io"
+	"net/http"
+)
+
+// BlockSize is the block size of the lab cipher
+const BlockSize = 16
+
+// TagSize is the size of the HMAC-SHA256 tag appended by an authenticating server
+const TagSize = sha256.Size
+
+// Options are the countermeasures of a Server
+type Options struct {
+	// Authenticate appends an HMAC-SHA256 tag of the IV and ciphertext (encrypt-then-MAC) and
+	// rejects messages with an invalid tag before looking at the padding.
+	Authenticate bool
+}
+
+// Server holds the secret keys and answers the padding queries
+type Server struct {
+	key     []byte
+	macKey  []byte
+	options Options
+}
+
+// NewServer creates a server with random keys
+func NewServer(options Options) (*Server, error) {
+	s := new(Server)
+	s.key = make([]byte, 16)
+	s.macKey = make([]byte, 32)
+	if _, err := rand.Read(s.key); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(s.macKey); err != nil {
+		return nil, err
+	}
+	s.options = options
+	return s, nil
+}
+
+// Encrypt returns the random IV followed by the CBC ciphertext of the padded plaintext, and by the
+// tag when the server authenticates
+func (s *Server) Encrypt(plaintext []byte) ([]byte, error) {
+	cipher, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	mode, err := cbc.NewMode(cipher, iv)
+	if err != nil {
+		return nil, err
+	}
+	reader := modes.NewReader(mode, bytes.NewReader(plaintext), modes.ENCRYPTION)
+	ciphertext, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	message := append(iv, ciphertext...)
+	if s.options.Authenticate {
+		message = append(message, s.tag(message)...)
+	}
+	return message, nil
+}
+
+func (s *Server) tag(data []byte) []byte {
+	mac := hmac.New(sha256.New, s.macKey)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// CheckPadding decrypts the message and reports only whether its padding is valid
+func (s *Server) CheckPadding(message []byte) bool {
+	if s.options.Authenticate {
+		if len(message) < TagSize {
+			return false
+		}
+		data, tag := message[:len(message)-TagSize], message[len(message)-TagSize:]
+		if !hmac.Equal(tag, s.tag(data)) {
+			return false
+		}
+		message = data
+	}
+	// the IV and at least one block are needed, the reader rejects a partial block
+	if len(message) < 2*BlockSize {
+		return false
+	}
+	cipher, err := aes.NewCipher(s.key)
+	if err != nil {
+		return false
+	}
+	iv, ciphertext := message[:BlockSize], message[BlockSize:]
+	mode, err := cbc.NewMode(cipher, iv)
+	if err != nil {
+		return false
+	}
+	reader := modes.NewReader(mode, bytes.NewReader(ciphertext), modes.DECRYPTION)
+	block := make([]byte, BlockSize)
+	for {
+		// the reader checks the padding of the last block and returns io.EOF when it is valid
+		_, err := reader.Read(block)
+		if err != nil {
+			return err == io.EOF
+		}
+	}
+}
+
+// ServeHTTP answers a POST of the raw message with 200 "padding ok" or 400 "padding bad"
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	message, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.CheckPadding(message) {
+		io.WriteString(w, "padding ok")
+		return
+	}
+	http.Error(w, "padding bad", http.StatusBadRequest)
+}
+
+// Oracle reports whether the padding of the IV and ciphertext is valid
+type Oracle func(message []byte) (bool, error)
+
+// LocalOracle queries the server directly. tag, if not nil, is appended to every query as an attacker
+// replaying the tag of an intercepted message would do.
+func LocalOracle(s *Server, tag []byte) Oracle {
+	return func(message []byte) (bool, error) {
+		return s.CheckPadding(append(message[:len(message):len(message)], tag...)), nil
+	}
+}
+
+// HTTPOracle queries a server through its HTTP handler at url
+func HTTPOracle(client *http.Client, url string) Oracle {
+	return func(message []byte) (bool, error) {
+		response, err := client.Post(url, "application/octet-stream", bytes.NewReader(message))
+		if err != nil {
+			return false, err
+		}
+		defer response.Body.Close()
+		io.Copy(io.Discard, response.Body)
+		switch response.StatusCode {
+		case http.StatusOK:
+			return true, nil
+		case http.StatusBadRequest:
+			return false, nil
+		}
+		return false, errors.New("Unexpected oracle response " + response.Status)
+	}
+}
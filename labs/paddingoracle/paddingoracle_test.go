@@ -0,0 +1,96 @@
+package paddingoracle
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+)
+
+var secret = []byte("Attack at dawn, the password is 'correct horse'")
+
+func newServer(t *testing.T, options Options) *Server {
+	s, err := NewServer(options)
+	if err != nil {
+		t.Fatalf("Error creating server: %s", err.Error())
+	}
+	return s
+}
+
+func TestCheckPadding(t *testing.T) {
+	s := newServer(t, Options{})
+	message, err := s.Encrypt(secret)
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err.Error())
+	}
+	if len(message) != BlockSize+48 || !s.CheckPadding(message) {
+		t.Fatalf("Invalid padding of an encrypted message")
+	}
+	// flipping the last byte of the previous block changes the padding byte
+	message[len(message)-BlockSize-1] ^= 0x01
+	if s.CheckPadding(message) {
+		t.Errorf("Accepting a corrupted padding")
+	}
+	if s.CheckPadding(message[:BlockSize]) || s.CheckPadding(message[:40]) {
+		t.Errorf("Accepting a message of invalid length")
+	}
+}
+
+// The padding check of modes.Reader runs in constant time, and the attack still works
+func TestAttackNaive(t *testing.T) {
+	s := newServer(t, Options{})
+	message, err := s.Encrypt(secret)
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err.Error())
+	}
+	oracle := LocalOracle(s, nil)
+	plaintext, err := Decrypt(oracle, message)
+	if err != nil {
+		t.Fatalf("Error decrypting: %s", err.Error())
+	}
+	if !bytes.Equal(plaintext, secret) {
+		t.Errorf("Invalid decrypted plaintext. Expected: %q Got: %q", secret, plaintext)
+	}
+	forged, err := Forge(oracle, []byte("transfer 1000000 to mallory"))
+	if err != nil {
+		t.Fatalf("Error forging: %s", err.Error())
+	}
+	if plaintext, err := Decrypt(oracle, forged); err != nil || string(plaintext) != "transfer 1000000 to mallory" {
+		t.Errorf("Invalid forged message. Got: %q", plaintext)
+	}
+}
+
+func TestAttackHTTP(t *testing.T) {
+	s := newServer(t, Options{})
+	server := httptest.NewServer(s)
+	defer server.Close()
+	message, err := s.Encrypt([]byte("loopback"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err.Error())
+	}
+	plaintext, err := Decrypt(HTTPOracle(server.Client(), server.URL), message)
+	if err != nil {
+		t.Fatalf("Error decrypting: %s", err.Error())
+	}
+	if string(plaintext) != "loopback" {
+		t.Errorf("Invalid decrypted plaintext. Expected: loopback Got: %q", plaintext)
+	}
+}
+
+func TestAttackAuthenticated(t *testing.T) {
+	s := newServer(t, Options{Authenticate: true})
+	message, err := s.Encrypt(secret)
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err.Error())
+	}
+	if !s.CheckPadding(message) {
+		t.Fatalf("Rejecting an authentic message")
+	}
+	data, tag := message[:len(message)-TagSize], message[len(message)-TagSize:]
+	oracle := LocalOracle(s, tag)
+	if _, err := Decrypt(oracle, data); err != ErrNoPadding {
+		t.Errorf("Attack against the authenticated server. Expected: %v Got: %v", ErrNoPadding, err)
+	}
+	if _, err := Forge(oracle, []byte("transfer")); err != ErrNoPadding {
+		t.Errorf("Forgery against the authenticated server. Expected: %v Got: %v", ErrNoPadding, err)
+	}
+}
@@ -2,34 +2,165 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/camellia"
+	"github.com/emanuelzabka/crypt-aes/des"
 	"github.com/emanuelzabka/crypt-aes/modes"
+	"github.com/emanuelzabka/crypt-aes/modes/cbc"
+	"github.com/emanuelzabka/crypt-aes/modes/ctr"
 	"github.com/emanuelzabka/crypt-aes/modes/ecb"
+	"github.com/emanuelzabka/crypt-aes/saes"
+	"github.com/emanuelzabka/crypt-aes/sm4"
 	flags "github.com/jessevdk/go-flags"
+	"io"
 	"os"
 	"strings"
 )
 
 var opts struct {
-	Encrypt   bool   `short:"e" long:"encrypt" description:"Perform encryption operation (default)"`
-	Decrypt   bool   `short:"d" long:"decrypt" description:"Perform decryption operation"`
-	Key       string `short:"k" long:"key" description:"Cipher key"`
-	NewKey    bool   `long:"newkey" description:"Generates and outputs a new cipher key"`
-	KeyLength int    `short:"l" long:"key-length" description:"Key length for the operation" choice:"128" choice:"192" choice:"256" default:"192"`
-	OpMode    string `short:"m" long:"mode" description:"Mode of operation" choice:"ecb" default:"ecb"`
-	Input     string `short:"i" long:"input" description:"Input file path or '-' to stdin" default:"-"`
-	Output    string `short:"o" long:"output" description:"Output file path or '-' to stdout" default:"-"`
+	Encrypt    bool   `short:"e" long:"encrypt" description:"Perform encryption operation (default)"`
+	Decrypt    bool   `short:"d" long:"decrypt" description:"Perform decryption operation"`
+	Key        string `short:"k" long:"key" description:"Cipher key"`
+	NewKey     bool   `long:"newkey" description:"Generates and outputs a new cipher key"`
+	KeyLength  int    `short:"l" long:"key-length" description:"Key length for the operation" choice:"128" choice:"192" choice:"256" default:"192"`
+	Cipher     string `short:"c" long:"cipher" description:"Block cipher" choice:"aes" choice:"saes" choice:"sm4" choice:"camellia" choice:"3des" default:"aes"`
+	OpMode     string `short:"m" long:"mode" description:"Mode of operation" choice:"ecb" choice:"cbc" choice:"ctr" default:"ecb"`
+	IV         string `long:"iv" description:"Initialization vector (initial counter block for ctr) in hexadecimal. Random on encryption when not informed"`
+	Backend    string `short:"b" long:"backend" description:"Cipher implementation" choice:"reference" choice:"masked" default:"reference"`
+	Input      string `short:"i" long:"input" description:"Input file path or '-' to stdin" default:"-"`
+	Output     string `short:"o" long:"output" description:"Output file path or '-' to stdout" default:"-"`
+	Trace      bool   `long:"trace" description:"Outputs the round-by-round trace of the first block to standard error"`
+	TraceAll   bool   `long:"trace-all" description:"Outputs the round-by-round trace of every block to standard error"`
+	FaultCheck string `long:"fault-check" description:"Fault countermeasure of the reference AES backend" choice:"none" choice:"verify" choice:"redundant" default:"none"`
+}
+
+var parser = flags.NewParser(&opts, flags.Default)
+
+// tracedCipher is a cipher reporting its intermediate values
+type tracedCipher interface {
+	modes.Cipher
+	SetTracer(tracer aes.Tracer)
+}
+
+// backends are the available implementations of the cipher
+var backends = map[string]func(key []byte) (tracedCipher, error){
+	"reference": func(key []byte) (tracedCipher, error) {
+		return aes.NewCipher(key)
+	},
+	"masked": func(key []byte) (tracedCipher, error) {
+		return aes.NewMaskedCipher(key)
+	},
+}
+
+// blockCipher describes an available block cipher. deprecated is the warning shown when encrypting
+// with a cipher kept only to decrypt legacy data.
+type blockCipher struct {
+	keySize    func() int
+	create     func(key []byte) (modes.Cipher, error)
+	deprecated string
+}
+
+// ciphers are the available block ciphers. AES uses the implementation selected by --backend.
+var ciphers = map[string]blockCipher{
+	"aes": {
+		keySize: func() int { return opts.KeyLength / 8 },
+		create:  func(key []byte) (modes.Cipher, error) { return backends[opts.Backend](key) },
+	},
+	"saes": {
+		keySize: func() int { return saes.KeySize },
+		create:  func(key []byte) (modes.Cipher, error) { return saes.NewCipher(key) },
+	},
+	"camellia": {
+		keySize: func() int { return opts.KeyLength / 8 },
+		create:  func(key []byte) (modes.Cipher, error) { return camellia.NewCipher(key) },
+	},
+	"3des": {
+		keySize:    func() int { return 24 },
+		create:     func(key []byte) (modes.Cipher, error) { return des.NewTripleDESCipher(key) },
+		deprecated: "3DES (TDEA) is deprecated and insecure for new data: its 64-bit block leaks plaintext after a few GB (Sweet32) and NIST disallows it for encryption since 2024. Use it only to decrypt old archives.",
+	},
+	"sm4": {
+		keySize: func() int { return sm4.KeySize },
+		create:  func(key []byte) (modes.Cipher, error) { return sm4.NewCipher(key) },
+	},
+}
+
+// cipherMode describes a mode of operation. usesIV tells whether create takes an initialization vector
+// of the block size, iv being nil otherwise.
+type cipherMode struct {
+	usesIV bool
+	create func(cipher modes.Cipher, iv []byte) (modes.CipherMode, error)
+}
+
+// cipherModes are the modes of operation offered by --mode
+var cipherModes = map[string]cipherMode{
+	"ecb": {
+		create: func(cipher modes.Cipher, iv []byte) (modes.CipherMode, error) { return ecb.NewMode(cipher), nil },
+	},
+	"cbc": {
+		usesIV: true,
+		create: func(cipher modes.Cipher, iv []byte) (modes.CipherMode, error) { return cbc.NewMode(cipher, iv) },
+	},
+	"ctr": {
+		usesIV: true,
+		create: func(cipher modes.Cipher, iv []byte) (modes.CipherMode, error) { return ctr.NewMode(cipher, iv) },
+	},
+}
+
+// faultCheckModes maps the --fault-check choices to the countermeasure modes
+var faultCheckModes = map[string]int{
+	"none":      aes.FaultCheckNone,
+	"verify":    aes.FaultCheckVerify,
+	"redundant": aes.FaultCheckRedundant,
+}
+
+// checkedCipher runs the checked operations of the cipher. modes.Cipher cannot return errors, so the
+// first detected fault is kept in err and checked by process before writing each block.
+type checkedCipher struct {
+	*aes.AESCipher
+	err error
+}
+
+func (c *checkedCipher) Encrypt(block, dest []byte) {
+	if err := c.EncryptChecked(block, dest); err != nil && c.err == nil {
+		c.err = err
+	}
+}
+
+func (c *checkedCipher) Decrypt(block, dest []byte) {
+	if err := c.DecryptChecked(block, dest); err != nil && c.err == nil {
+		c.err = err
+	}
 }
 
 var cipherKey []byte
+var cipherIV []byte
 var inputReader *bufio.Reader
 var inputFile *os.File
 var outputWriter *bufio.Writer
 var outputFile *os.File
 
+// firstBlockTracer forwards to tracer only the steps of the first processed block
+type firstBlockTracer struct {
+	tracer aes.Tracer
+	done   bool
+}
+
+func (t *firstBlockTracer) Trace(round int, step string, data []byte) {
+	if t.done {
+		return
+	}
+	t.tracer.Trace(round, step, data)
+	if step == "output" || step == "ioutput" {
+		t.done = true
+	}
+}
+
 func askForKey() string {
 	reader := bufio.NewReader(os.Stdin)
 	fmt.Fprintf(os.Stderr, "Enter key: ")
@@ -75,10 +206,43 @@ func byteToHexString(block []byte) string {
 	return hex.EncodeToString(block)
 }
 
+// randomSource is the generator of keys, checked by readRandom
+var randomSource io.Reader = rand.Reader
+
+// rngBlockSize is the size of the blocks compared by the continuous random generator test
+const rngBlockSize = 16
+
+// lastRandomBlock is the previous block of the generator
+var lastRandomBlock []byte
+
+// readRandom fills p from randomSource running the continuous test of FIPS 140-2 4.9.2: each block is
+// compared with the previous one and a repeated block fails the test. The first block is only kept
+// for the comparison.
+func readRandom(p []byte) error {
+	if lastRandomBlock == nil {
+		lastRandomBlock = make([]byte, rngBlockSize)
+		if _, err := io.ReadFull(randomSource, lastRandomBlock); err != nil {
+			return err
+		}
+	}
+	block := make([]byte, rngBlockSize)
+	for i := 0; i < len(p); i += rngBlockSize {
+		if _, err := io.ReadFull(randomSource, block); err != nil {
+			return err
+		}
+		if bytes.Equal(block, lastRandomBlock) {
+			return errors.New("Random generator health test failed: repeated output block")
+		}
+		copy(lastRandomBlock, block)
+		copy(p[i:], block)
+	}
+	return nil
+}
+
 func newKey() (result []byte) {
-	size := opts.KeyLength / 8
+	size := ciphers[opts.Cipher].keySize()
 	result = make([]byte, size)
-	_, err := rand.Read(result)
+	err := readRandom(result)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error generating key: %s\n", err.Error())
 		os.Exit(1)
@@ -87,10 +251,15 @@ func newKey() (result []byte) {
 }
 
 func parseArgs() {
-	_, err := flags.Parse(&opts)
+	parser.SubcommandsOptional = true
+	_, err := parser.Parse()
 	if err != nil {
 		os.Exit(1)
 	}
+	// Subcommands are executed by the parser
+	if parser.Active != nil {
+		os.Exit(0)
+	}
 	if opts.Encrypt && opts.Decrypt {
 		fmt.Fprintln(os.Stderr, "Encrypt and decrypt options cannot be used at the same call")
 		os.Exit(1)
@@ -114,6 +283,20 @@ func parseArgs() {
 			os.Exit(1)
 		}
 	}
+	if opts.IV != "" {
+		if !cipherModes[opts.OpMode].usesIV {
+			fmt.Fprintf(os.Stderr, "* The %s mode does not use an initialization vector\n", opts.OpMode)
+			os.Exit(1)
+		}
+		cipherIV, err = hex.DecodeString(opts.IV)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "* Error decoding the provided IV: %s\n", opts.IV)
+			os.Exit(1)
+		}
+	} else if opts.Decrypt && cipherModes[opts.OpMode].usesIV {
+		fmt.Fprintf(os.Stderr, "* The IV is required for decryption in the %s mode\n", opts.OpMode)
+		os.Exit(1)
+	}
 	if opts.NewKey || (opts.Encrypt && opts.Key == "") {
 		cipherKey = newKey()
 		if !opts.NewKey {
@@ -143,25 +326,81 @@ func closeFiles() {
 	}
 }
 
+// abort reports the error, removes the partially written output file and exits
+func abort(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format, args...)
+	if outputFile != nil {
+		outputFile.Close()
+		os.Remove(opts.Output)
+	}
+	os.Exit(1)
+}
+
 func process(operation int) {
 	var block []byte
-	cipher, err := aes.NewCipher(cipherKey)
+	var checked *checkedCipher
+	if warning := ciphers[opts.Cipher].deprecated; warning != "" && operation == modes.ENCRYPTION {
+		fmt.Fprintf(os.Stderr, "**************************************** WARNING ****************************************\n")
+		fmt.Fprintf(os.Stderr, "%s\n", warning)
+		fmt.Fprintf(os.Stderr, "*****************************************************************************************\n")
+	}
+	if err := modeSelfTests[opts.OpMode](); err != nil {
+		abort("Self test of the %s mode failed, refusing to operate: %s\n", opts.OpMode, err.Error())
+	}
+	cipher, err := ciphers[opts.Cipher].create(cipherKey)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error initializing cipher: %s\n", err.Error())
-		os.Exit(1)
+		abort("Error initializing cipher: %s\n", err.Error())
+	}
+	if opts.Trace || opts.TraceAll {
+		traced, ok := cipher.(tracedCipher)
+		if !ok {
+			abort("Tracing is not supported by the %s cipher\n", opts.Cipher)
+		}
+		if opts.TraceAll {
+			traced.SetTracer(aes.NewTraceWriter(os.Stderr))
+		} else {
+			traced.SetTracer(&firstBlockTracer{tracer: aes.NewTraceWriter(os.Stderr)})
+		}
+	}
+	if opts.FaultCheck != "none" {
+		reference, ok := cipher.(*aes.AESCipher)
+		if !ok {
+			abort("The fault check is only supported by the reference AES backend\n")
+		}
+		reference.SetFaultCheck(faultCheckModes[opts.FaultCheck])
+		checked = &checkedCipher{AESCipher: reference}
+		cipher = checked
 	}
 	block = make([]byte, cipher.BlockSize())
-	ecbCipher := ecb.NewMode(cipher)
-	reader := modes.NewReader(ecbCipher, inputReader, operation)
+	if cipherModes[opts.OpMode].usesIV && cipherIV == nil {
+		cipherIV = make([]byte, cipher.BlockSize())
+		if err := readRandom(cipherIV); err != nil {
+			abort("Error generating IV: %s\n", err.Error())
+		}
+		fmt.Fprintf(os.Stderr, "Using IV: %s\n", byteToHexString(cipherIV))
+	}
+	mode, err := cipherModes[opts.OpMode].create(cipher, cipherIV)
+	if err != nil {
+		abort("Error initializing the %s mode: %s\n", opts.OpMode, err.Error())
+	}
+	reader := modes.NewReader(mode, inputReader, operation)
 	for true {
 		n, err := reader.Read(block)
+		if checked != nil && checked.err != nil {
+			abort("Error processing: %s\n", checked.err.Error())
+		}
+		if err == modes.ErrInvalidPadding {
+			abort("Error decrypting: invalid padding, the key or the input is wrong\n")
+		}
+		if err == modes.ErrInvalidLength {
+			abort("Error decrypting: the input length is not a multiple of the block size\n")
+		}
 		if n == 0 {
 			break
 		}
 		_, err = outputWriter.Write(block[0:n])
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error writing to output: %s\n", err.Error())
-			os.Exit(1)
+			abort("Error writing to output: %s\n", err.Error())
 		}
 		if opts.Output == "-" {
 			outputWriter.Flush()
@@ -0,0 +1,52 @@
+package cbc
+
+import (
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/modes"
+)
+
+// CBC is the Cipher Block Chaining mode. Each block depends on the previous one, so a CBC value
+// processes the blocks of a single message in order.
+type CBC struct {
+	cipher   modes.Cipher
+	previous []byte
+	auxBlock []byte
+}
+
+// NewMode creates a new mode of operation CBC using the cipher and the initialization vector iv, which
+// must have the length of a block and be unpredictable
+func NewMode(cipher modes.Cipher, iv []byte) (*CBC, error) {
+	if len(iv) != cipher.BlockSize() {
+		return nil, errors.New("Invalid IV length. It must have the length of a block")
+	}
+	cbcCipher := new(CBC)
+	cbcCipher.cipher = cipher
+	cbcCipher.previous = make([]byte, len(iv))
+	copy(cbcCipher.previous, iv)
+	cbcCipher.auxBlock = make([]byte, len(iv))
+	return cbcCipher, nil
+}
+
+// Encrypt encrypts a block into dest, chaining it to the previous ciphertext block
+func (c *CBC) Encrypt(block, dest []byte) {
+	for i := range c.auxBlock {
+		c.auxBlock[i] = block[i] ^ c.previous[i]
+	}
+	c.cipher.Encrypt(c.auxBlock, dest)
+	copy(c.previous, dest)
+}
+
+// Decrypt decrypts a block into dest. block and dest may be the same slice.
+func (c *CBC) Decrypt(block, dest []byte) {
+	copy(c.auxBlock, block)
+	c.cipher.Decrypt(c.auxBlock, dest)
+	for i := range c.previous {
+		dest[i] ^= c.previous[i]
+	}
+	c.previous, c.auxBlock = c.auxBlock, c.previous
+}
+
+// BlockSize returns the block size used
+func (c *CBC) BlockSize() int {
+	return c.cipher.BlockSize()
+}
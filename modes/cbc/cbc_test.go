@@ -0,0 +1,60 @@
+package cbc
+
+import (
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"testing"
+)
+
+// NIST SP 800-38A F.2.1 and F.2.2, CBC-AES128
+var (
+	key        = "2b7e151628aed2a6abf7158809cf4f3c"
+	iv         = "000102030405060708090a0b0c0d0e0f"
+	plaintext  = "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710"
+	ciphertext = "7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b273bed6b8e3c1743b7116e69e222295163ff1caa1681fac09120eca307586e1a7"
+)
+
+func newTestMode(t *testing.T) *CBC {
+	k, _ := hex.DecodeString(key)
+	v, _ := hex.DecodeString(iv)
+	cipher, err := aes.NewCipher(k)
+	if err != nil {
+		t.Fatal("Error creating cipher")
+	}
+	mode, err := NewMode(cipher, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mode
+}
+
+func TestEncrypt(t *testing.T) {
+	mode := newTestMode(t)
+	data, _ := hex.DecodeString(plaintext)
+	dest := make([]byte, len(data))
+	for i := 0; i < len(data); i += 16 {
+		mode.Encrypt(data[i:i+16], dest[i:i+16])
+	}
+	if hex.EncodeToString(dest) != ciphertext {
+		t.Errorf("Invalid encryption. Expected: %s Got: %x", ciphertext, dest)
+	}
+}
+
+func TestDecrypt(t *testing.T) {
+	mode := newTestMode(t)
+	data, _ := hex.DecodeString(ciphertext)
+	// decrypts in place, as the block and dest may be the same slice
+	for i := 0; i < len(data); i += 16 {
+		mode.Decrypt(data[i:i+16], data[i:i+16])
+	}
+	if hex.EncodeToString(data) != plaintext {
+		t.Errorf("Invalid decryption. Expected: %s Got: %x", plaintext, data)
+	}
+}
+
+func TestNewModeIVLength(t *testing.T) {
+	cipher, _ := aes.NewCipher(make([]byte, 16))
+	if _, err := NewMode(cipher, make([]byte, 8)); err == nil {
+		t.Errorf("Invalid IV length accepted")
+	}
+}
@@ -0,0 +1,158 @@
+// Package ccm implements the Counter with CBC-MAC mode of NIST SP 800-38C (RFC 3610), an authenticated
+// encryption mode for 128-bit block ciphers computing a CBC-MAC of the message and encrypting it with
+// the counter mode.
+package ccm
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/modes"
+)
+
+// CCM seals and opens messages with a key. A nonce must never be reused with the same key.
+type CCM struct {
+	cipher  modes.Cipher
+	tagSize int
+}
+
+// NewMode creates a new CCM using the cipher, which must have blocks of 128 bits, and tags of tagSize
+// bytes, an even number from 4 to 16
+func NewMode(cipher modes.Cipher, tagSize int) (*CCM, error) {
+	if cipher.BlockSize() != 16 {
+		return nil, errors.New("Invalid block size. CCM needs a cipher of 128 bits")
+	}
+	if tagSize < 4 || tagSize > 16 || tagSize%2 != 0 {
+		return nil, errors.New("Invalid tag size. It must be an even number from 4 to 16 bytes")
+	}
+	c := new(CCM)
+	c.cipher = cipher
+	c.tagSize = tagSize
+	return c, nil
+}
+
+// TagSize returns the length of the tags in bytes
+func (c *CCM) TagSize() int {
+	return c.tagSize
+}
+
+// checkLengths validates the nonce, from 7 to 13 bytes, and the message length, which must fit in the
+// 15 - nonce length bytes left in the first block
+func checkLengths(nonce []byte, length int) error {
+	if len(nonce) < 7 || len(nonce) > 13 {
+		return errors.New("Invalid nonce length. It must be from 7 to 13 bytes")
+	}
+	if q := 15 - len(nonce); q < 8 && uint64(length) >= 1<<uint(8*q) {
+		return errors.New("Message too long for the nonce length")
+	}
+	return nil
+}
+
+// mac returns the CBC-MAC of the first block B0, holding the flags, nonce and message length, of the
+// encoded additional data and of the plaintext, each padded with zeros to a multiple of the block size
+func (c *CCM) mac(nonce, plaintext, additionalData []byte) []byte {
+	q := 15 - len(nonce)
+	state := make([]byte, 16)
+	state[0] = byte(8*((c.tagSize-2)/2) + q - 1)
+	if len(additionalData) > 0 {
+		state[0] |= 0x40
+	}
+	copy(state[1:], nonce)
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(plaintext)))
+	copy(state[16-q:], length[8-q:])
+	c.cipher.Encrypt(state, state)
+	if len(additionalData) > 0 {
+		// the length of the additional data takes 2, 6 or 10 bytes
+		var encoded []byte
+		switch n := uint64(len(additionalData)); {
+		case n < 1<<16-1<<8:
+			encoded = []byte{byte(n >> 8), byte(n)}
+		case n < 1<<32:
+			encoded = []byte{0xff, 0xfe, 0, 0, 0, 0}
+			binary.BigEndian.PutUint32(encoded[2:], uint32(n))
+		default:
+			encoded = []byte{0xff, 0xff, 0, 0, 0, 0, 0, 0, 0, 0}
+			binary.BigEndian.PutUint64(encoded[2:], n)
+		}
+		c.update(state, append(encoded, additionalData...))
+	}
+	c.update(state, plaintext)
+	return state
+}
+
+// update chains the data, padded with zeros, into the CBC-MAC state
+func (c *CCM) update(state, data []byte) {
+	for i := 0; i < len(data); i += 16 {
+		for j := i; j < len(data) && j < i+16; j++ {
+			state[j-i] ^= data[j]
+		}
+		c.cipher.Encrypt(state, state)
+	}
+}
+
+// crypt XORs the input with the key stream of the counter blocks 1, 2, ... and returns the encryption
+// of the counter block 0, which masks the tag
+func (c *CCM) crypt(nonce, input, dest []byte) []byte {
+	q := 15 - len(nonce)
+	counter := make([]byte, 16)
+	counter[0] = byte(q - 1)
+	copy(counter[1:], nonce)
+	keyStream := make([]byte, 16)
+	mask := make([]byte, 16)
+	c.cipher.Encrypt(counter, mask)
+	for i := 0; i < len(input); i += 16 {
+		for j := 15; j > 15-q; j-- {
+			counter[j]++
+			if counter[j] != 0 {
+				break
+			}
+		}
+		c.cipher.Encrypt(counter, keyStream)
+		for j := i; j < len(input) && j < i+16; j++ {
+			dest[j] = input[j] ^ keyStream[j-i]
+		}
+	}
+	return mask
+}
+
+// Seal encrypts and authenticates the plaintext and authenticates the additional data, returning the
+// ciphertext followed by the tag
+func (c *CCM) Seal(nonce, plaintext, additionalData []byte) ([]byte, error) {
+	if err := checkLengths(nonce, len(plaintext)); err != nil {
+		return nil, err
+	}
+	tag := c.mac(nonce, plaintext, additionalData)
+	result := make([]byte, len(plaintext), len(plaintext)+c.tagSize)
+	mask := c.crypt(nonce, plaintext, result)
+	for i := 0; i < c.tagSize; i++ {
+		result = append(result, tag[i]^mask[i])
+	}
+	return result, nil
+}
+
+// Open decrypts the ciphertext and checks the tag at its end with the additional data, returning the
+// plaintext only if it matches. It returns modes.ErrAuthentication if the tag does not match.
+func (c *CCM) Open(nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < c.tagSize {
+		return nil, modes.ErrAuthentication
+	}
+	tag := ciphertext[len(ciphertext)-c.tagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-c.tagSize]
+	if err := checkLengths(nonce, len(ciphertext)); err != nil {
+		return nil, err
+	}
+	result := make([]byte, len(ciphertext))
+	mask := c.crypt(nonce, ciphertext, result)
+	expected := c.mac(nonce, result, additionalData)
+	for i := range mask {
+		expected[i] ^= mask[i]
+	}
+	if subtle.ConstantTimeCompare(expected[:c.tagSize], tag) != 1 {
+		for i := range result {
+			result[i] = 0
+		}
+		return nil, modes.ErrAuthentication
+	}
+	return result, nil
+}
@@ -0,0 +1,115 @@
+package ccm
+
+import (
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"strings"
+	"testing"
+)
+
+// NIST SP 800-38C C.1 to C.3, and a last vector with 70000 bytes of additional data, which encodes its
+// length in 6 bytes
+const key = "404142434445464748494a4b4c4d4e4f"
+
+var vectors = []struct {
+	nonce     string
+	plaintext string
+	aad       string
+	tagSize   int
+	sealed    string
+}{
+	{"10111213141516", "20212223", "0001020304050607", 4, "7162015b4dac255d"},
+	{"1011121314151617", "202122232425262728292a2b2c2d2e2f", "000102030405060708090a0b0c0d0e0f", 6,
+		"d2a1f0e051ea5f62081a7792073d593d1fc64fbfaccd"},
+	{"101112131415161718191a1b", "202122232425262728292a2b2c2d2e2f3031323334353637", "000102030405060708090a0b0c0d0e0f10111213", 8,
+		"e3b201a9f5b71a7a9b1ceaeccd97e70b6176aad9a4428aa5484392fbc1b09951"},
+	{"101112131415161718191a1b1c", "202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f", longAAD(), 16,
+		"69915dad1e84c6376a68c2967e4dab615ae0fd1faec44cc484828529463ccf72695280f2f991c40174d722951a9b8b13"},
+}
+
+// longAAD returns the additional data of the last vector, the bytes 0 to 255 repeated
+func longAAD() string {
+	var b strings.Builder
+	for i := 0; i < 70000; i++ {
+		b.WriteString(hex.EncodeToString([]byte{byte(i)}))
+	}
+	return b.String()
+}
+
+func newMode(t *testing.T, tagSize int) *CCM {
+	k, _ := hex.DecodeString(key)
+	cipher, err := aes.NewCipher(k)
+	if err != nil {
+		t.Fatal("Error creating cipher")
+	}
+	mode, err := NewMode(cipher, tagSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mode
+}
+
+func TestSeal(t *testing.T) {
+	for _, v := range vectors {
+		nonce, _ := hex.DecodeString(v.nonce)
+		p, _ := hex.DecodeString(v.plaintext)
+		a, _ := hex.DecodeString(v.aad)
+		sealed, err := newMode(t, v.tagSize).Seal(nonce, p, a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(sealed); got != v.sealed {
+			t.Errorf("Invalid sealing with nonce %s. Expected: %s Got: %s", v.nonce, v.sealed, got)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	for _, v := range vectors {
+		nonce, _ := hex.DecodeString(v.nonce)
+		a, _ := hex.DecodeString(v.aad)
+		sealed, _ := hex.DecodeString(v.sealed)
+		mode := newMode(t, v.tagSize)
+		p, err := mode.Open(nonce, sealed, a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(p); got != v.plaintext {
+			t.Errorf("Invalid opening with nonce %s. Expected: %s Got: %s", v.nonce, v.plaintext, got)
+		}
+		sealed[len(sealed)-1] ^= 1
+		if _, err := mode.Open(nonce, sealed, a); err != modes.ErrAuthentication {
+			t.Errorf("Accepting modified tag with nonce %s", v.nonce)
+		}
+		sealed[len(sealed)-1] ^= 1
+		sealed[0] ^= 0x80
+		if _, err := mode.Open(nonce, sealed, a); err != modes.ErrAuthentication {
+			t.Errorf("Accepting modified ciphertext with nonce %s", v.nonce)
+		}
+		sealed[0] ^= 0x80
+		if _, err := mode.Open(nonce, sealed, append(a, 0)); err != modes.ErrAuthentication {
+			t.Errorf("Accepting modified additional data with nonce %s", v.nonce)
+		}
+	}
+}
+
+func TestInvalidParameters(t *testing.T) {
+	k, _ := hex.DecodeString(key)
+	cipher, _ := aes.NewCipher(k)
+	for _, size := range []int{0, 2, 5, 18} {
+		if _, err := NewMode(cipher, size); err == nil {
+			t.Errorf("Accepting tag size %d", size)
+		}
+	}
+	mode := newMode(t, 16)
+	for _, size := range []int{6, 14} {
+		if _, err := mode.Seal(make([]byte, size), nil, nil); err == nil {
+			t.Errorf("Accepting nonce of %d bytes", size)
+		}
+	}
+	// a 13-byte nonce leaves 2 bytes for the message length
+	if _, err := mode.Seal(make([]byte, 13), make([]byte, 1<<16), nil); err == nil {
+		t.Errorf("Accepting message longer than its length field")
+	}
+}
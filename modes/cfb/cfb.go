@@ -0,0 +1,113 @@
+package cfb
+
+import (
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/modes"
+)
+
+// CFB is the Cipher Feedback mode of NIST SP 800-38A with segments of 1 bit, 8 bits or a block. The
+// shift register starts as the IV and takes in each ciphertext segment.
+type CFB struct {
+	cipher    modes.Cipher
+	segment   int
+	register  []byte
+	keyStream []byte
+}
+
+// NewMode creates a new mode of operation CFB using the cipher, the initialization vector iv, which
+// must have the length of a block, and a segment of segmentSize bits: 1, 8 or the block size
+func NewMode(cipher modes.Cipher, iv []byte, segmentSize int) (*CFB, error) {
+	if len(iv) != cipher.BlockSize() {
+		return nil, errors.New("Invalid IV length. It must have the length of a block")
+	}
+	if segmentSize != 1 && segmentSize != 8 && segmentSize != 8*cipher.BlockSize() {
+		return nil, errors.New("Invalid segment size. Allowed: 1, 8 or the block size in bits")
+	}
+	cfbCipher := new(CFB)
+	cfbCipher.cipher = cipher
+	cfbCipher.segment = segmentSize
+	cfbCipher.register = make([]byte, len(iv))
+	copy(cfbCipher.register, iv)
+	cfbCipher.keyStream = make([]byte, len(iv))
+	return cfbCipher, nil
+}
+
+// shift shifts the register left by the length of the ciphertext segment and appends it
+func (c *CFB) shift(segment []byte) {
+	copy(c.register, c.register[len(segment):])
+	copy(c.register[len(c.register)-len(segment):], segment)
+}
+
+// shiftBit shifts the register left by one bit and appends bit
+func (c *CFB) shiftBit(bit byte) {
+	last := len(c.register) - 1
+	for i := 0; i < last; i++ {
+		c.register[i] = c.register[i]<<1 | c.register[i+1]>>7
+	}
+	c.register[last] = c.register[last]<<1 | bit
+}
+
+// EncryptBit encrypts a segment of 1 bit, the least significant bit of bit. It is only valid for the
+// 1-bit segment.
+func (c *CFB) EncryptBit(bit byte) byte {
+	c.cipher.Encrypt(c.register, c.keyStream)
+	out := (bit ^ c.keyStream[0]>>7) & 1
+	c.shiftBit(out)
+	return out
+}
+
+// DecryptBit decrypts a segment of 1 bit, the least significant bit of bit. It is only valid for the
+// 1-bit segment.
+func (c *CFB) DecryptBit(bit byte) byte {
+	c.cipher.Encrypt(c.register, c.keyStream)
+	out := (bit ^ c.keyStream[0]>>7) & 1
+	c.shiftBit(bit & 1)
+	return out
+}
+
+// Encrypt encrypts BlockSize bytes into dest, as 8 segments, one segment or a block. block and dest may
+// be the same slice.
+func (c *CFB) Encrypt(block, dest []byte) {
+	if c.segment == 1 {
+		dest[0] = c.processBits(block[0], c.EncryptBit)
+		return
+	}
+	c.cipher.Encrypt(c.register, c.keyStream)
+	n := c.segment / 8
+	for i := 0; i < n; i++ {
+		dest[i] = block[i] ^ c.keyStream[i]
+	}
+	c.shift(dest[:n])
+}
+
+// Decrypt decrypts BlockSize bytes into dest. block and dest may be the same slice.
+func (c *CFB) Decrypt(block, dest []byte) {
+	if c.segment == 1 {
+		dest[0] = c.processBits(block[0], c.DecryptBit)
+		return
+	}
+	c.cipher.Encrypt(c.register, c.keyStream)
+	n := c.segment / 8
+	c.shift(block[:n])
+	for i := 0; i < n; i++ {
+		dest[i] = block[i] ^ c.keyStream[i]
+	}
+}
+
+// processBits runs the 1-bit operation on the bits of b, most significant first
+func (c *CFB) processBits(b byte, operation func(bit byte) byte) byte {
+	var out byte
+	for i := 7; i >= 0; i-- {
+		out |= operation(b>>uint(i)) << uint(i)
+	}
+	return out
+}
+
+// BlockSize returns the number of bytes processed by Encrypt and Decrypt: one for the 1 and 8-bit
+// segments, a block otherwise
+func (c *CFB) BlockSize() int {
+	if c.segment < 8*c.cipher.BlockSize() {
+		return 1
+	}
+	return c.cipher.BlockSize()
+}
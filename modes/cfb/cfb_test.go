@@ -0,0 +1,79 @@
+package cfb
+
+import (
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"testing"
+)
+
+const (
+	key       = "2b7e151628aed2a6abf7158809cf4f3c"
+	iv        = "000102030405060708090a0b0c0d0e0f"
+	plaintext = "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710"
+)
+
+// The examples of NIST SP 800-38A F.3, CFB-AES128, extended to the whole plaintext for the 1 and 8-bit
+// segments
+var ciphertexts = map[int]string{
+	1:   "68b3a264f838f5f8c3101070d1ab4c2e22e7f950383a0b71ade4fad0095cb188a57972c3c1882615f7511411fbebf1193997069704fc1d1f27028434c99e60f4",
+	8:   "3b79424c9c0dd436bace9e0ed4586a4f32b9ded50ae3ba69d472e88267fb505270cbad1e257691f7c47c5038297edda32ff26d0ed19174096161ecc14086dd62",
+	128: "3b3fd92eb72dad20333449f8e83cfb4ac8a64537a0b3a93fcde3cdad9f1ce58b26751f67a3cbb140b1808cf187a4f4dfc04b05357c5d1c0eeac4c66f9ff7f2e6",
+}
+
+func newTestMode(t *testing.T, segment int) *CFB {
+	k, _ := hex.DecodeString(key)
+	v, _ := hex.DecodeString(iv)
+	cipher, err := aes.NewCipher(k)
+	if err != nil {
+		t.Fatal("Error creating cipher")
+	}
+	mode, err := NewMode(cipher, v, segment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mode
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	for segment, ciphertext := range ciphertexts {
+		encrypter := newTestMode(t, segment)
+		decrypter := newTestMode(t, segment)
+		data, _ := hex.DecodeString(plaintext)
+		size := encrypter.BlockSize()
+		for i := 0; i < len(data); i += size {
+			encrypter.Encrypt(data[i:i+size], data[i:i+size])
+		}
+		if hex.EncodeToString(data) != ciphertext {
+			t.Errorf("Invalid CFB%d encryption. Expected: %s Got: %x", segment, ciphertext, data)
+		}
+		for i := 0; i < len(data); i += size {
+			decrypter.Decrypt(data[i:i+size], data[i:i+size])
+		}
+		if hex.EncodeToString(data) != plaintext {
+			t.Errorf("Invalid CFB%d decryption. Expected: %s Got: %x", segment, plaintext, data)
+		}
+	}
+}
+
+// F.3.1 lists the CFB1 segments one bit at a time
+func TestBits(t *testing.T) {
+	plainBits := []byte{0, 1, 1, 0, 1, 0, 1, 1, 1, 1, 0, 0, 0, 0, 0, 1}
+	cipherBits := []byte{0, 1, 1, 0, 1, 0, 0, 0, 1, 0, 1, 1, 0, 0, 1, 1}
+	encrypter := newTestMode(t, 1)
+	decrypter := newTestMode(t, 1)
+	for i := range plainBits {
+		if got := encrypter.EncryptBit(plainBits[i]); got != cipherBits[i] {
+			t.Errorf("Invalid encryption of bit %d. Expected: %d Got: %d", i, cipherBits[i], got)
+		}
+		if got := decrypter.DecryptBit(cipherBits[i]); got != plainBits[i] {
+			t.Errorf("Invalid decryption of bit %d. Expected: %d Got: %d", i, plainBits[i], got)
+		}
+	}
+}
+
+func TestNewModeSegment(t *testing.T) {
+	cipher, _ := aes.NewCipher(make([]byte, 16))
+	if _, err := NewMode(cipher, make([]byte, 16), 64); err == nil {
+		t.Errorf("Invalid segment size accepted")
+	}
+}
@@ -0,0 +1,103 @@
+// Package ciphertest is a test harness shared by the block cipher packages. It checks known-answer
+// vectors and the consistency of a modes.Cipher with itself and through the modes of operation.
+package ciphertest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"github.com/emanuelzabka/crypt-aes/modes/ecb"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// Vector is a known-answer test with hexadecimal values
+type Vector struct {
+	Key        string
+	Plaintext  string
+	Ciphertext string
+}
+
+// NewCipherFunc creates a cipher for a key
+type NewCipherFunc func(key []byte) (modes.Cipher, error)
+
+// CheckVectors encrypts and decrypts each vector, also with dest aliasing the input block
+func CheckVectors(t *testing.T, newCipher NewCipherFunc, vectors []Vector) {
+	t.Helper()
+	for _, v := range vectors {
+		key, _ := hex.DecodeString(v.Key)
+		plaintext, _ := hex.DecodeString(v.Plaintext)
+		c, err := newCipher(key)
+		if err != nil {
+			t.Errorf("Error creating cipher with key 0x%s: %s", v.Key, err.Error())
+			continue
+		}
+		res := make([]byte, c.BlockSize())
+		c.Encrypt(plaintext, res)
+		if hex.EncodeToString(res) != v.Ciphertext {
+			t.Errorf("Invalid encryption result with key 0x%s. Expected: 0x%s Got: 0x%x", v.Key, v.Ciphertext, res)
+		}
+		c.Decrypt(res, res)
+		if !bytes.Equal(res, plaintext) {
+			t.Errorf("Invalid decryption result with key 0x%s. Expected: 0x%s Got: 0x%x", v.Key, v.Plaintext, res)
+		}
+	}
+}
+
+// CheckRoundTrip checks with random keys of the lengths given that decryption inverts encryption for
+// random blocks and for random messages read in random chunks through modes.Reader in ECB mode
+func CheckRoundTrip(t *testing.T, newCipher NewCipherFunc, keyLengths []int) {
+	t.Helper()
+	random := rand.New(rand.NewSource(1))
+	for _, keyLength := range keyLengths {
+		key := make([]byte, keyLength)
+		random.Read(key)
+		c, err := newCipher(key)
+		if err != nil {
+			t.Fatalf("Error creating cipher with %d-byte key: %s", keyLength, err.Error())
+		}
+		size := c.BlockSize()
+		block := make([]byte, size)
+		res := make([]byte, size)
+		for i := 0; i < 100; i++ {
+			random.Read(block)
+			c.Encrypt(block, res)
+			if bytes.Equal(res, block) {
+				t.Errorf("Encryption returned the plaintext 0x%x", block)
+			}
+			c.Decrypt(res, res)
+			if !bytes.Equal(res, block) {
+				t.Fatalf("Invalid round trip with %d-byte key. Expected: 0x%x Got: 0x%x", keyLength, block, res)
+			}
+		}
+		for _, length := range []int{0, 1, size - 1, size, size + 1, 5*size + 3} {
+			plaintext := make([]byte, length)
+			random.Read(plaintext)
+			encrypted := readAll(t, modes.NewReader(ecb.NewMode(c), bytes.NewReader(plaintext), modes.ENCRYPTION), size)
+			if len(encrypted) != length+size-length%size {
+				t.Errorf("Invalid padded length of %d bytes: %d", length, len(encrypted))
+			}
+			decrypted := readAll(t, modes.NewReader(ecb.NewMode(c), bytes.NewReader(encrypted), modes.DECRYPTION), size)
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("Invalid message round trip of %d bytes with %d-byte key", length, keyLength)
+			}
+		}
+	}
+}
+
+// readAll reads r block by block until io.EOF
+func readAll(t *testing.T, r io.Reader, size int) []byte {
+	var result []byte
+	block := make([]byte, size)
+	for {
+		n, err := r.Read(block)
+		result = append(result, block[:n]...)
+		if err == io.EOF || n == 0 {
+			return result
+		}
+		if err != nil {
+			t.Fatalf("Error reading: %s", err.Error())
+		}
+	}
+}
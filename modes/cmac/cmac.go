@@ -0,0 +1,95 @@
+// Package cmac implements the CMAC message authentication code of NIST SP 800-38B, also known as OMAC1
+// and specified for AES in RFC 4493.
+package cmac
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/modes"
+)
+
+// CMAC computes and verifies the CMAC tags of messages with a block cipher of 64 or 128 bits
+type CMAC struct {
+	cipher modes.Cipher
+	k1     []byte
+	k2     []byte
+}
+
+// New creates a CMAC using the cipher, deriving the subkeys from the encryption of the zero block
+func New(cipher modes.Cipher) (*CMAC, error) {
+	var rb byte
+	switch cipher.BlockSize() {
+	case 8:
+		rb = 0x1b
+	case 16:
+		rb = 0x87
+	default:
+		return nil, errors.New("Invalid block size. CMAC needs a cipher of 64 or 128 bits")
+	}
+	m := new(CMAC)
+	m.cipher = cipher
+	l := make([]byte, cipher.BlockSize())
+	cipher.Encrypt(l, l)
+	m.k1 = double(l, rb)
+	m.k2 = double(m.k1, rb)
+	return m, nil
+}
+
+// double multiplies the block by x in the binary field of the block size, rb being its reduction
+func double(block []byte, rb byte) []byte {
+	result := make([]byte, len(block))
+	for i := 0; i < len(block)-1; i++ {
+		result[i] = block[i]<<1 | block[i+1]>>7
+	}
+	// the reduction is applied in constant time when the most significant bit is set
+	result[len(block)-1] = block[len(block)-1]<<1 ^ rb&-(block[0]>>7)
+	return result
+}
+
+// Sum returns the full tag of the message, of the length of a block
+func (m *CMAC) Sum(message []byte) []byte {
+	size := m.cipher.BlockSize()
+	state := make([]byte, size)
+	n := (len(message) + size - 1) / size
+	if n == 0 {
+		n = 1
+	}
+	for i := 0; i < n-1; i++ {
+		for j := 0; j < size; j++ {
+			state[j] ^= message[i*size+j]
+		}
+		m.cipher.Encrypt(state, state)
+	}
+	// the last block is XORed with K1 when complete and with K2 after the 10* padding otherwise
+	last := message[(n-1)*size:]
+	subkey := m.k1
+	if len(last) < size {
+		subkey = m.k2
+		state[len(last)] ^= 0x80
+	}
+	for j := 0; j < size; j++ {
+		if j < len(last) {
+			state[j] ^= last[j]
+		}
+		state[j] ^= subkey[j]
+	}
+	m.cipher.Encrypt(state, state)
+	return state
+}
+
+// Verify checks in constant time the tag of the message, which may be truncated to at least a byte.
+// It returns modes.ErrAuthentication if the tag does not match.
+func (m *CMAC) Verify(message, tag []byte) error {
+	if len(tag) == 0 || len(tag) > m.cipher.BlockSize() {
+		return modes.ErrAuthentication
+	}
+	if subtle.ConstantTimeCompare(m.Sum(message)[:len(tag)], tag) != 1 {
+		return modes.ErrAuthentication
+	}
+	return nil
+}
+
+// BlockSize returns the block size used, the length of a full tag
+func (m *CMAC) BlockSize() int {
+	return m.cipher.BlockSize()
+}
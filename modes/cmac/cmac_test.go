@@ -0,0 +1,67 @@
+package cmac
+
+import (
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"testing"
+)
+
+// RFC 4493 section 4 and NIST SP 800-38B D.3, CMAC-AES128 and CMAC-AES256
+var message = "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710"
+
+var vectors = []struct {
+	key    string
+	length int
+	tag    string
+}{
+	{"2b7e151628aed2a6abf7158809cf4f3c", 0, "bb1d6929e95937287fa37d129b756746"},
+	{"2b7e151628aed2a6abf7158809cf4f3c", 16, "070a16b46b4d4144f79bdd9dd04a287c"},
+	{"2b7e151628aed2a6abf7158809cf4f3c", 40, "dfa66747de9ae63030ca32611497c827"},
+	{"2b7e151628aed2a6abf7158809cf4f3c", 64, "51f0bebf7e3b9d92fc49741779363cfe"},
+	{"603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4", 40, "aaf3d8f1de5640c232f5b169b9c911e6"},
+}
+
+func newCMAC(t *testing.T, key string) *CMAC {
+	k, _ := hex.DecodeString(key)
+	cipher, err := aes.NewCipher(k)
+	if err != nil {
+		t.Fatal("Error creating cipher")
+	}
+	m, err := New(cipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestSum(t *testing.T) {
+	data, _ := hex.DecodeString(message)
+	for _, v := range vectors {
+		if got := hex.EncodeToString(newCMAC(t, v.key).Sum(data[:v.length])); got != v.tag {
+			t.Errorf("Invalid tag of %d bytes. Expected: %s Got: %s", v.length, v.tag, got)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	data, _ := hex.DecodeString(message)
+	m := newCMAC(t, vectors[2].key)
+	tag, _ := hex.DecodeString(vectors[2].tag)
+	if err := m.Verify(data[:40], tag); err != nil {
+		t.Errorf("Rejecting valid tag: %s", err.Error())
+	}
+	if err := m.Verify(data[:40], tag[:8]); err != nil {
+		t.Errorf("Rejecting valid truncated tag: %s", err.Error())
+	}
+	tag[15] ^= 1
+	if err := m.Verify(data[:40], tag); err != modes.ErrAuthentication {
+		t.Errorf("Accepting modified tag")
+	}
+	if err := m.Verify(data[:39], tag[:8]); err != modes.ErrAuthentication {
+		t.Errorf("Accepting tag of another message")
+	}
+	if err := m.Verify(data[:40], nil); err != modes.ErrAuthentication {
+		t.Errorf("Accepting empty tag")
+	}
+}
@@ -0,0 +1,53 @@
+package ctr
+
+import (
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/modes"
+)
+
+// CTR is the Counter mode of NIST SP 800-38A. Each block is XORed with the encryption of a counter
+// incremented as a big-endian integer of the block size, so encryption and decryption are the same
+// operation. A counter block must never be reused with the same key.
+type CTR struct {
+	cipher    modes.Cipher
+	counter   []byte
+	keyStream []byte
+}
+
+// NewMode creates a new mode of operation CTR using the cipher and the initial counter block iv, which
+// must have the length of a block
+func NewMode(cipher modes.Cipher, iv []byte) (*CTR, error) {
+	if len(iv) != cipher.BlockSize() {
+		return nil, errors.New("Invalid IV length. It must have the length of a block")
+	}
+	ctrCipher := new(CTR)
+	ctrCipher.cipher = cipher
+	ctrCipher.counter = make([]byte, len(iv))
+	copy(ctrCipher.counter, iv)
+	ctrCipher.keyStream = make([]byte, len(iv))
+	return ctrCipher, nil
+}
+
+// Encrypt encrypts a block into dest. block and dest may be the same slice.
+func (c *CTR) Encrypt(block, dest []byte) {
+	c.cipher.Encrypt(c.counter, c.keyStream)
+	for i := range c.keyStream {
+		dest[i] = block[i] ^ c.keyStream[i]
+	}
+	for i := len(c.counter) - 1; i >= 0; i-- {
+		c.counter[i]++
+		if c.counter[i] != 0 {
+			break
+		}
+	}
+}
+
+// Decrypt decrypts a block into dest, which is the same as encrypting it
+func (c *CTR) Decrypt(block, dest []byte) {
+	c.Encrypt(block, dest)
+}
+
+// BlockSize returns the block size used
+func (c *CTR) BlockSize() int {
+	return c.cipher.BlockSize()
+}
@@ -0,0 +1,57 @@
+package ctr
+
+import (
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"testing"
+)
+
+// NIST SP 800-38A F.5.1 and F.5.2, CTR-AES128
+var (
+	key        = "2b7e151628aed2a6abf7158809cf4f3c"
+	counter    = "f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff"
+	plaintext  = "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710"
+	ciphertext = "874d6191b620e3261bef6864990db6ce9806f66b7970fdff8617187bb9fffdff5ae4df3edbd5d35e5b4f09020db03eab1e031dda2fbe03d1792170a0f3009cee"
+)
+
+func process(t *testing.T, iv, input string, decrypt bool) string {
+	k, _ := hex.DecodeString(key)
+	v, _ := hex.DecodeString(iv)
+	cipher, err := aes.NewCipher(k)
+	if err != nil {
+		t.Fatal("Error creating cipher")
+	}
+	mode, err := NewMode(cipher, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := hex.DecodeString(input)
+	for i := 0; i < len(data); i += 16 {
+		if decrypt {
+			mode.Decrypt(data[i:i+16], data[i:i+16])
+		} else {
+			mode.Encrypt(data[i:i+16], data[i:i+16])
+		}
+	}
+	return hex.EncodeToString(data)
+}
+
+func TestEncrypt(t *testing.T) {
+	if got := process(t, counter, plaintext, false); got != ciphertext {
+		t.Errorf("Invalid encryption. Expected: %s Got: %s", ciphertext, got)
+	}
+}
+
+func TestDecrypt(t *testing.T) {
+	if got := process(t, counter, ciphertext, true); got != plaintext {
+		t.Errorf("Invalid decryption. Expected: %s Got: %s", plaintext, got)
+	}
+}
+
+func TestCounterWraps(t *testing.T) {
+	// the counter carries over every byte and wraps from all ones to zero
+	expected := "e13338e36cb71962e00d020b4cedbd86d3dae15b04bb352fa0f59febfcb4da3e"
+	if got := process(t, "ffffffffffffffffffffffffffffffff", plaintext[:64], false); got != expected {
+		t.Errorf("Invalid encryption on counter wrap. Expected: %s Got: %s", expected, got)
+	}
+}
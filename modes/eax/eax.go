@@ -0,0 +1,95 @@
+// Package eax implements the EAX mode of Bellare, Rogaway and Wagner, an authenticated encryption mode
+// combining the counter mode with three tweaked CMACs (OMAC) of the nonce, the additional data and the
+// ciphertext.
+package eax
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"github.com/emanuelzabka/crypt-aes/modes/cmac"
+	"github.com/emanuelzabka/crypt-aes/modes/ctr"
+)
+
+// EAX seals and opens messages with a key. A nonce, of any length, must never be reused with the same
+// key.
+type EAX struct {
+	cipher  modes.Cipher
+	mac     *cmac.CMAC
+	tagSize int
+}
+
+// NewMode creates a new EAX using the cipher, which must have blocks of 128 bits, and tags of tagSize
+// bytes, from 1 to 16
+func NewMode(cipher modes.Cipher, tagSize int) (*EAX, error) {
+	if cipher.BlockSize() != 16 {
+		return nil, errors.New("Invalid block size. EAX needs a cipher of 128 bits")
+	}
+	if tagSize < 1 || tagSize > 16 {
+		return nil, errors.New("Invalid tag size. It must be from 1 to 16 bytes")
+	}
+	mac, err := cmac.New(cipher)
+	if err != nil {
+		return nil, err
+	}
+	e := new(EAX)
+	e.cipher = cipher
+	e.mac = mac
+	e.tagSize = tagSize
+	return e, nil
+}
+
+// TagSize returns the length of the tags in bytes
+func (e *EAX) TagSize() int {
+	return e.tagSize
+}
+
+// omac returns the CMAC of the data prefixed by the block holding the tweak t
+func (e *EAX) omac(t byte, data []byte) []byte {
+	input := make([]byte, 16, 16+len(data))
+	input[15] = t
+	return e.mac.Sum(append(input, data...))
+}
+
+// crypt XORs the input with the counter mode key stream starting at the OMAC of the nonce
+func (e *EAX) crypt(nonceMAC, input []byte) []byte {
+	mode, _ := ctr.NewMode(e.cipher, nonceMAC)
+	output := make([]byte, (len(input)+15)/16*16)
+	copy(output, input)
+	for i := 0; i < len(output); i += 16 {
+		mode.Encrypt(output[i:i+16], output[i:i+16])
+	}
+	return output[:len(input)]
+}
+
+// tag returns the OMACs of the nonce, additional data and ciphertext XORed, truncated
+func (e *EAX) tag(nonceMAC, additionalData, ciphertext []byte) []byte {
+	result := e.omac(1, additionalData)
+	for i, b := range e.omac(2, ciphertext) {
+		result[i] ^= b ^ nonceMAC[i]
+	}
+	return result[:e.tagSize]
+}
+
+// Seal encrypts and authenticates the plaintext and authenticates the additional data, returning the
+// ciphertext followed by the tag
+func (e *EAX) Seal(nonce, plaintext, additionalData []byte) ([]byte, error) {
+	nonceMAC := e.omac(0, nonce)
+	ciphertext := e.crypt(nonceMAC, plaintext)
+	return append(ciphertext, e.tag(nonceMAC, additionalData, ciphertext)...), nil
+}
+
+// Open checks the tag at the end of the ciphertext and the additional data, and only then decrypts
+// the ciphertext. It returns modes.ErrAuthentication if the tag does not match.
+func (e *EAX) Open(nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < e.tagSize {
+		return nil, modes.ErrAuthentication
+	}
+	tag := ciphertext[len(ciphertext)-e.tagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-e.tagSize]
+	nonceMAC := e.omac(0, nonce)
+	if subtle.ConstantTimeCompare(e.tag(nonceMAC, additionalData, ciphertext), tag) != 1 {
+		return nil, modes.ErrAuthentication
+	}
+	return e.crypt(nonceMAC, ciphertext), nil
+}
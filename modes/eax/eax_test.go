@@ -0,0 +1,87 @@
+package eax
+
+import (
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"testing"
+)
+
+// The first test vectors of the EAX paper by Bellare, Rogaway and Wagner
+var vectors = []struct {
+	key       string
+	nonce     string
+	aad       string
+	plaintext string
+	sealed    string
+}{
+	{"233952dee4d5ed5f9b9c6d6ff80ff478", "62ec67f9c3a4a407fcb2a8c49031a8b3", "6bfb914fd07eae6b", "", "e037830e8389f27b025a2d6527e79d01"},
+	{"91945d3f4dcbee0bf45ef52255f095a4", "becaf043b0a23d843194ba972c66debd", "fa3bfd4806eb53fa", "f7fb", "19dd5c4c9331049d0bdab0277408f67967e5"},
+	{"01f74ad64077f2e704c0f60ada3dd523", "70c3db4f0d26368400a10ed05d2bff5e", "234a3463c1264ac6", "1a47cb4933", "d851d5bae03a59f238a23e39199dc9266626c40f80"},
+}
+
+func newMode(t *testing.T, key string, tagSize int) *EAX {
+	k, _ := hex.DecodeString(key)
+	cipher, err := aes.NewCipher(k)
+	if err != nil {
+		t.Fatal("Error creating cipher")
+	}
+	mode, err := NewMode(cipher, tagSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mode
+}
+
+func TestSeal(t *testing.T) {
+	for _, v := range vectors {
+		n, _ := hex.DecodeString(v.nonce)
+		a, _ := hex.DecodeString(v.aad)
+		p, _ := hex.DecodeString(v.plaintext)
+		sealed, err := newMode(t, v.key, 16).Seal(n, p, a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(sealed); got != v.sealed {
+			t.Errorf("Invalid sealing with key %s. Expected: %s Got: %s", v.key, v.sealed, got)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	for _, v := range vectors {
+		n, _ := hex.DecodeString(v.nonce)
+		a, _ := hex.DecodeString(v.aad)
+		sealed, _ := hex.DecodeString(v.sealed)
+		mode := newMode(t, v.key, 16)
+		p, err := mode.Open(n, sealed, a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(p); got != v.plaintext {
+			t.Errorf("Invalid opening with key %s. Expected: %s Got: %s", v.key, v.plaintext, got)
+		}
+		sealed[len(sealed)-1] ^= 1
+		if _, err := mode.Open(n, sealed, a); err != modes.ErrAuthentication {
+			t.Errorf("Accepting modified tag with key %s", v.key)
+		}
+		sealed[len(sealed)-1] ^= 1
+		if _, err := mode.Open(n[1:], sealed, a); err != modes.ErrAuthentication {
+			t.Errorf("Accepting another nonce with key %s", v.key)
+		}
+		// a truncated tag is the prefix of the full one
+		if _, err := newMode(t, v.key, 8).Open(n, sealed[:len(sealed)-8], a); err != nil {
+			t.Errorf("Rejecting truncated tag with key %s", v.key)
+		}
+	}
+}
+
+func TestInvalidTagSize(t *testing.T) {
+	k, _ := hex.DecodeString(vectors[0].key)
+	cipher, _ := aes.NewCipher(k)
+	for _, size := range []int{0, 17} {
+		if _, err := NewMode(cipher, size); err == nil {
+			t.Errorf("Accepting tag size %d", size)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package modes
+
+import (
+	"bytes"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"io"
+	"testing"
+)
+
+// chunkReader returns the data in reads of at most size bytes, as pipes and sockets can do
+type chunkReader struct {
+	data []byte
+	size int
+}
+
+func (r *chunkReader) Read(p []byte) (n int, err error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n = r.size
+	if n > len(p) {
+		n = len(p)
+	}
+	if n > len(r.data) {
+		n = len(r.data)
+	}
+	copy(p, r.data[:n])
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// readAll reads every block of the reader, failing on out of range lengths
+func readAll(t *testing.T, reader *Reader, maxBlocks int) ([]byte, error) {
+	var result []byte
+	dest := make([]byte, reader.size)
+	for i := 0; i <= maxBlocks; i++ {
+		n, err := reader.Read(dest)
+		if n < 0 || n > len(dest) {
+			t.Fatalf("Out of range read length %d", n)
+		}
+		if err == ErrInvalidPadding || err == ErrInvalidLength {
+			return result, err
+		}
+		if n == 0 {
+			return result, nil
+		}
+		result = append(result, dest[:n]...)
+	}
+	t.Fatalf("Reader does not reach the end of data after %d blocks", maxBlocks)
+	return nil, nil
+}
+
+func fuzzCipher(t *testing.T) Cipher {
+	c, err := aes.NewCipher([]byte("fuzzing-key-0123"))
+	if err != nil {
+		t.Fatalf("Error creating cipher: %s", err.Error())
+	}
+	return c
+}
+
+func FuzzReaderRoundTrip(f *testing.F) {
+	f.Add([]byte{}, uint8(16), uint8(16))
+	f.Add([]byte("sixteen byte msg"), uint8(1), uint8(7))
+	f.Add(bytes.Repeat([]byte{0x10}, 33), uint8(5), uint8(16))
+	f.Fuzz(func(t *testing.T, plaintext []byte, encChunk, decChunk uint8) {
+		if encChunk == 0 || decChunk == 0 {
+			t.Skip()
+		}
+		c := fuzzCipher(t)
+		blocks := len(plaintext)/c.BlockSize() + 1
+		encrypter := NewReader(c, &chunkReader{plaintext, int(encChunk)}, ENCRYPTION)
+		ciphertext, _ := readAll(t, encrypter, blocks)
+		if len(ciphertext) != blocks*c.BlockSize() {
+			t.Fatalf("Invalid ciphertext length. Expected: %d Got: %d", blocks*c.BlockSize(), len(ciphertext))
+		}
+		decrypter := NewReader(c, &chunkReader{ciphertext, int(decChunk)}, DECRYPTION)
+		decrypted, err := readAll(t, decrypter, blocks)
+		if err != nil {
+			t.Fatalf("Error decrypting: %s", err.Error())
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("Invalid round trip. Expected: %x Got: %x", plaintext, decrypted)
+		}
+	})
+}
+
+func FuzzReaderDecrypt(f *testing.F) {
+	f.Add([]byte{}, uint8(16))
+	f.Add(bytes.Repeat([]byte{0xff}, 16), uint8(16))
+	f.Add(bytes.Repeat([]byte{0x00}, 21), uint8(3))
+	f.Fuzz(func(t *testing.T, ciphertext []byte, chunk uint8) {
+		if chunk == 0 {
+			t.Skip()
+		}
+		for _, c := range []Cipher{NewMockCipher(16), fuzzCipher(t)} {
+			decrypter := NewReader(c, &chunkReader{ciphertext, int(chunk)}, DECRYPTION)
+			_, err := readAll(t, decrypter, len(ciphertext)/c.BlockSize()+1)
+			if len(ciphertext)%c.BlockSize() != 0 && err != ErrInvalidLength {
+				t.Errorf("Accepting a ciphertext of length %d. Expected error: %v Got: %v", len(ciphertext), ErrInvalidLength, err)
+			}
+			// The mock cipher does not change the blocks, so the padding is the one of the input
+			if _, ok := c.(*MockCipher); ok && len(ciphertext) >= 16 && len(ciphertext)%16 == 0 {
+				if valid := validPadding(ciphertext[len(ciphertext)-16:]); valid != (err == nil) {
+					t.Errorf("Invalid padding check of %x. Expected valid: %t Got error: %v", ciphertext, valid, err)
+				}
+			}
+		}
+	})
+}
+
+// validPadding is the reference PKCS#7 check of a last block
+func validPadding(block []byte) bool {
+	pad := int(block[len(block)-1])
+	if pad == 0 || pad > len(block) {
+		return false
+	}
+	for _, b := range block[len(block)-pad:] {
+		if int(b) != pad {
+			return false
+		}
+	}
+	return true
+}
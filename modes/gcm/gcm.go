@@ -0,0 +1,175 @@
+// Package gcm implements the Galois/Counter Mode of NIST SP 800-38D, an authenticated encryption mode
+// for 128-bit block ciphers combining the counter mode with the GHASH universal hash.
+package gcm
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/modes"
+)
+
+// GCM seals and opens messages with a key. A nonce must never be reused with the same key, as it
+// reveals the hash key and allows forgeries.
+type GCM struct {
+	cipher  modes.Cipher
+	tagSize int
+	hashKey [2]uint64
+}
+
+// NewMode creates a new GCM using the cipher, which must have blocks of 128 bits, and tags of tagSize
+// bytes: 16, 15, 14, 13 or 12, or 8 and 4 for restricted uses
+func NewMode(cipher modes.Cipher, tagSize int) (*GCM, error) {
+	if cipher.BlockSize() != 16 {
+		return nil, errors.New("Invalid block size. GCM needs a cipher of 128 bits")
+	}
+	if tagSize != 4 && tagSize != 8 && (tagSize < 12 || tagSize > 16) {
+		return nil, errors.New("Invalid tag size. It must be 4, 8 or 12 to 16 bytes")
+	}
+	g := new(GCM)
+	g.cipher = cipher
+	g.tagSize = tagSize
+	h := make([]byte, 16)
+	cipher.Encrypt(h, h)
+	g.hashKey = [2]uint64{binary.BigEndian.Uint64(h), binary.BigEndian.Uint64(h[8:])}
+	return g, nil
+}
+
+// TagSize returns the length of the tags in bytes
+func (g *GCM) TagSize() int {
+	return g.tagSize
+}
+
+// multiply sets x to x * H in GF(2^128), with the bit order of GCM, without branching on the values
+func (g *GCM) multiply(x *[2]uint64) {
+	var z [2]uint64
+	v := g.hashKey
+	for i := 0; i < 128; i++ {
+		bit := x[i/64] >> uint(63-i%64) & 1
+		z[0] ^= v[0] & -bit
+		z[1] ^= v[1] & -bit
+		lsb := v[1] & 1
+		v[1] = v[1]>>1 | v[0]<<63
+		v[0] = v[0]>>1 ^ 0xe1<<56&-lsb
+	}
+	*x = z
+}
+
+// update hashes the data into y, padded with zeros to a multiple of the block size
+func (g *GCM) update(y *[2]uint64, data []byte) {
+	block := make([]byte, 16)
+	for len(data) > 0 {
+		n := copy(block, data)
+		for i := n; i < 16; i++ {
+			block[i] = 0
+		}
+		y[0] ^= binary.BigEndian.Uint64(block)
+		y[1] ^= binary.BigEndian.Uint64(block[8:])
+		g.multiply(y)
+		data = data[n:]
+	}
+}
+
+// ghash returns GHASH of the additional data and the ciphertext, each padded, followed by their lengths
+// in bits
+func (g *GCM) ghash(additionalData, ciphertext []byte) []byte {
+	var y [2]uint64
+	g.update(&y, additionalData)
+	g.update(&y, ciphertext)
+	y[0] ^= uint64(len(additionalData)) * 8
+	y[1] ^= uint64(len(ciphertext)) * 8
+	g.multiply(&y)
+	result := make([]byte, 16)
+	binary.BigEndian.PutUint64(result, y[0])
+	binary.BigEndian.PutUint64(result[8:], y[1])
+	return result
+}
+
+// counter returns the pre-counter block J0: the nonce followed by a counter of 1 for 96-bit nonces and
+// the GHASH of the nonce otherwise
+func (g *GCM) counter(nonce []byte) []byte {
+	if len(nonce) == 12 {
+		j0 := make([]byte, 16)
+		copy(j0, nonce)
+		j0[15] = 1
+		return j0
+	}
+	var y [2]uint64
+	g.update(&y, nonce)
+	y[1] ^= uint64(len(nonce)) * 8
+	g.multiply(&y)
+	j0 := make([]byte, 16)
+	binary.BigEndian.PutUint64(j0, y[0])
+	binary.BigEndian.PutUint64(j0[8:], y[1])
+	return j0
+}
+
+// crypt XORs the input with the key stream starting at the counter block after j0, incrementing only
+// the last 32 bits of the counter
+func (g *GCM) crypt(j0, input, dest []byte) {
+	counter := make([]byte, 16)
+	copy(counter, j0)
+	keyStream := make([]byte, 16)
+	for i := 0; i < len(input); i += 16 {
+		binary.BigEndian.PutUint32(counter[12:], binary.BigEndian.Uint32(counter[12:])+1)
+		g.cipher.Encrypt(counter, keyStream)
+		for j := i; j < len(input) && j < i+16; j++ {
+			dest[j] = input[j] ^ keyStream[j-i]
+		}
+	}
+}
+
+// tag returns the tag of the ciphertext: the encryption of J0 XORed with the GHASH, truncated
+func (g *GCM) tag(j0, additionalData, ciphertext []byte) []byte {
+	s := g.ghash(additionalData, ciphertext)
+	mask := make([]byte, 16)
+	g.cipher.Encrypt(j0, mask)
+	for i := range s {
+		s[i] ^= mask[i]
+	}
+	return s[:g.tagSize]
+}
+
+// checkLengths validates the nonce, which can not be empty, and the length limits of the message and
+// additional data
+func checkLengths(nonce []byte, length int, additionalData []byte) error {
+	if len(nonce) == 0 {
+		return errors.New("Invalid nonce length. It can not be empty")
+	}
+	if uint64(length) > 1<<36-32 || uint64(len(additionalData)) > 1<<61-1 {
+		return errors.New("Message too long")
+	}
+	return nil
+}
+
+// Seal encrypts and authenticates the plaintext and authenticates the additional data, returning the
+// ciphertext followed by the tag
+func (g *GCM) Seal(nonce, plaintext, additionalData []byte) ([]byte, error) {
+	if err := checkLengths(nonce, len(plaintext), additionalData); err != nil {
+		return nil, err
+	}
+	j0 := g.counter(nonce)
+	result := make([]byte, len(plaintext), len(plaintext)+g.tagSize)
+	g.crypt(j0, plaintext, result)
+	return append(result, g.tag(j0, additionalData, result)...), nil
+}
+
+// Open checks the tag at the end of the ciphertext and the additional data, and only then decrypts
+// the ciphertext. It returns modes.ErrAuthentication if the tag does not match.
+func (g *GCM) Open(nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < g.tagSize {
+		return nil, modes.ErrAuthentication
+	}
+	tag := ciphertext[len(ciphertext)-g.tagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-g.tagSize]
+	if err := checkLengths(nonce, len(ciphertext), additionalData); err != nil {
+		return nil, err
+	}
+	j0 := g.counter(nonce)
+	if subtle.ConstantTimeCompare(g.tag(j0, additionalData, ciphertext), tag) != 1 {
+		return nil, modes.ErrAuthentication
+	}
+	result := make([]byte, len(ciphertext))
+	g.crypt(j0, ciphertext, result)
+	return result, nil
+}
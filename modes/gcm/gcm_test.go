@@ -0,0 +1,108 @@
+package gcm
+
+import (
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"testing"
+)
+
+// Test cases 2, 4, 5 and 6 of the GCM specification by McGrew and Viega, the last one with the tag
+// truncated as NIST SP 800-38D allows
+var (
+	key       = "feffe9928665731c6d6a8f9467308308"
+	plaintext = "d9313225f88406e5a55909c5aff5269a86a7a9531534f7da2e4c303d8a318a721c3c0c95956809532fcf0e2449a6b525b16aedf5aa0de657ba637b39"
+	aad       = "feedfacedeadbeeffeedfacedeadbeefabaddad2"
+)
+
+var vectors = []struct {
+	key       string
+	nonce     string
+	plaintext string
+	aad       string
+	tagSize   int
+	sealed    string
+}{
+	{"00000000000000000000000000000000", "000000000000000000000000", "00000000000000000000000000000000", "", 16,
+		"0388dace60b6a392f328c2b971b2fe78ab6e47d42cec13bdf53a67b21257bddf"},
+	{key, "cafebabefacedbaddecaf888", plaintext, aad, 16,
+		"42831ec2217774244b7221b784d0d49ce3aa212f2c02a4e035c17e2329aca12e21d514b25466931c7d8f6a5aac84aa051ba30b396a0aac973d58e0915bc94fbc3221a5db94fae95ae7121a47"},
+	{key, "cafebabefacedbad", plaintext, aad, 16,
+		"61353b4c2806934a777ff51fa22a4755699b2a714fcdc6f83766e5f97b6c742373806900e49f24b22b097544d4896b424989b5e1ebac0f07c23f45983612d2e79e3b0785561be14aaca2fccb"},
+	{key, "9313225df88406e555909c5aff5269aa6a7a9538534f7da1e4c303d2a318a728c3c0c95156809539fcf0e2429a6b525416aedbf5a0de6a57a637b39b", plaintext, aad, 8,
+		"8ce24998625615b603a033aca13fb894be9112a5c3a211a8ba262a3cca7e2ca701e4a9a4fba43c90ccdcb281d48c7c6fd62875d2aca417034c34aee5619cc5aefffe0bfa"},
+}
+
+func newMode(t *testing.T, key string, tagSize int) *GCM {
+	k, _ := hex.DecodeString(key)
+	cipher, err := aes.NewCipher(k)
+	if err != nil {
+		t.Fatal("Error creating cipher")
+	}
+	mode, err := NewMode(cipher, tagSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mode
+}
+
+func TestSeal(t *testing.T) {
+	for _, v := range vectors {
+		nonce, _ := hex.DecodeString(v.nonce)
+		p, _ := hex.DecodeString(v.plaintext)
+		a, _ := hex.DecodeString(v.aad)
+		sealed, err := newMode(t, v.key, v.tagSize).Seal(nonce, p, a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(sealed); got != v.sealed {
+			t.Errorf("Invalid sealing with nonce %s. Expected: %s Got: %s", v.nonce, v.sealed, got)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	for _, v := range vectors {
+		nonce, _ := hex.DecodeString(v.nonce)
+		a, _ := hex.DecodeString(v.aad)
+		sealed, _ := hex.DecodeString(v.sealed)
+		mode := newMode(t, v.key, v.tagSize)
+		p, err := mode.Open(nonce, sealed, a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(p); got != v.plaintext {
+			t.Errorf("Invalid opening with nonce %s. Expected: %s Got: %s", v.nonce, v.plaintext, got)
+		}
+		// any modified byte of the ciphertext, tag or additional data is rejected
+		sealed[len(sealed)-1] ^= 1
+		if _, err := mode.Open(nonce, sealed, a); err != modes.ErrAuthentication {
+			t.Errorf("Accepting modified tag with nonce %s", v.nonce)
+		}
+		sealed[len(sealed)-1] ^= 1
+		sealed[0] ^= 0x80
+		if _, err := mode.Open(nonce, sealed, a); err != modes.ErrAuthentication {
+			t.Errorf("Accepting modified ciphertext with nonce %s", v.nonce)
+		}
+		sealed[0] ^= 0x80
+		if _, err := mode.Open(nonce, sealed, append(a, 0)); err != modes.ErrAuthentication {
+			t.Errorf("Accepting modified additional data with nonce %s", v.nonce)
+		}
+		if _, err := mode.Open(nonce, sealed[:v.tagSize-1], a); err != modes.ErrAuthentication {
+			t.Errorf("Accepting ciphertext shorter than the tag")
+		}
+	}
+}
+
+func TestInvalidParameters(t *testing.T) {
+	k, _ := hex.DecodeString(key)
+	cipher, _ := aes.NewCipher(k)
+	for _, size := range []int{0, 3, 9, 11, 17} {
+		if _, err := NewMode(cipher, size); err == nil {
+			t.Errorf("Accepting tag size %d", size)
+		}
+	}
+	if _, err := newMode(t, key, 16).Seal(nil, []byte("message"), nil); err == nil {
+		t.Errorf("Accepting empty nonce")
+	}
+}
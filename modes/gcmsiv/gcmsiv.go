@@ -0,0 +1,211 @@
+// Package gcmsiv implements AES-GCM-SIV of RFC 8452, a nonce misuse-resistant authenticated encryption
+// mode: the tag is computed from the plaintext with POLYVAL and used as the initial counter, and the
+// authentication and encryption keys are derived from the key and the nonce of each message.
+package gcmsiv
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/modes"
+)
+
+// NewCipherFunc creates a block cipher of 128 bits for a key of 16 or 32 bytes
+type NewCipherFunc func(key []byte) (modes.Cipher, error)
+
+// GCMSIV seals and opens messages with a key generating key. Reusing a nonce only reveals whether two
+// messages with the same nonce and additional data are equal.
+type GCMSIV struct {
+	newCipher NewCipherFunc
+	keyCipher modes.Cipher
+	keySize   int
+}
+
+// NewMode creates a new GCM-SIV with the key generating key, of 16 or 32 bytes, and newCipher to create
+// the cipher of that key and of the derived encryption keys
+func NewMode(newCipher NewCipherFunc, key []byte) (*GCMSIV, error) {
+	if len(key) != 16 && len(key) != 32 {
+		return nil, errors.New("Invalid key length. GCM-SIV takes keys of 16 or 32 bytes")
+	}
+	keyCipher, err := newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if keyCipher.BlockSize() != 16 {
+		return nil, errors.New("Invalid block size. GCM-SIV needs a cipher of 128 bits")
+	}
+	g := new(GCMSIV)
+	g.newCipher = newCipher
+	g.keyCipher = keyCipher
+	g.keySize = len(key)
+	return g, nil
+}
+
+// deriveKeys returns the authentication key and the cipher of the encryption key of the nonce, made of
+// the first halves of the encryptions of a little-endian counter followed by the nonce
+func (g *GCMSIV) deriveKeys(nonce []byte) ([]byte, modes.Cipher, error) {
+	derived := make([]byte, 0, 16+g.keySize)
+	block := make([]byte, 16)
+	copy(block[4:], nonce)
+	for i := 0; len(derived) < 16+g.keySize; i++ {
+		binary.LittleEndian.PutUint32(block, uint32(i))
+		output := make([]byte, 16)
+		g.keyCipher.Encrypt(block, output)
+		derived = append(derived, output[:8]...)
+	}
+	c, err := g.newCipher(derived[16:])
+	return derived[:16], c, err
+}
+
+// polyval is the POLYVAL universal hash, computed through the GHASH multiplication on byte-reversed
+// blocks as RFC 8452 appendix A describes
+type polyval struct {
+	key [2]uint64
+	sum [2]uint64
+}
+
+// newPolyval creates the hash of the key, using in GHASH the reversed key multiplied by x
+func newPolyval(key []byte) *polyval {
+	p := new(polyval)
+	reversed := reverse(key)
+	p.key = [2]uint64{binary.BigEndian.Uint64(reversed), binary.BigEndian.Uint64(reversed[8:])}
+	lsb := p.key[1] & 1
+	p.key[1] = p.key[1]>>1 | p.key[0]<<63
+	p.key[0] = p.key[0]>>1 ^ 0xe1<<56&-lsb
+	return p
+}
+
+// reverse returns the block with its bytes in reverse order
+func reverse(block []byte) []byte {
+	result := make([]byte, 16)
+	for i := range result {
+		result[i] = block[15-i]
+	}
+	return result
+}
+
+// update hashes the data, padded with zeros to a multiple of the block size
+func (p *polyval) update(data []byte) {
+	block := make([]byte, 16)
+	for len(data) > 0 {
+		for i := range block {
+			block[i] = 0
+		}
+		n := copy(block, data)
+		reversed := reverse(block)
+		p.sum[0] ^= binary.BigEndian.Uint64(reversed)
+		p.sum[1] ^= binary.BigEndian.Uint64(reversed[8:])
+		p.multiply()
+		data = data[n:]
+	}
+}
+
+// multiply sets the sum to sum * key in GF(2^128), with the bit order of GHASH
+func (p *polyval) multiply() {
+	var z [2]uint64
+	v := p.key
+	for i := 0; i < 128; i++ {
+		bit := p.sum[i/64] >> uint(63-i%64) & 1
+		z[0] ^= v[0] & -bit
+		z[1] ^= v[1] & -bit
+		lsb := v[1] & 1
+		v[1] = v[1]>>1 | v[0]<<63
+		v[0] = v[0]>>1 ^ 0xe1<<56&-lsb
+	}
+	p.sum = z
+}
+
+// digest returns the hash value
+func (p *polyval) digest() []byte {
+	result := make([]byte, 16)
+	binary.BigEndian.PutUint64(result, p.sum[0])
+	binary.BigEndian.PutUint64(result[8:], p.sum[1])
+	return reverse(result)
+}
+
+// tag returns the tag of the plaintext: the encryption of the POLYVAL of the additional data, the
+// plaintext and their lengths in bits, XORed with the nonce and with the most significant bit cleared
+func tag(authKey []byte, c modes.Cipher, nonce, plaintext, additionalData []byte) []byte {
+	p := newPolyval(authKey)
+	p.update(additionalData)
+	p.update(plaintext)
+	lengths := make([]byte, 16)
+	binary.LittleEndian.PutUint64(lengths, uint64(len(additionalData))*8)
+	binary.LittleEndian.PutUint64(lengths[8:], uint64(len(plaintext))*8)
+	p.update(lengths)
+	s := p.digest()
+	for i := range nonce {
+		s[i] ^= nonce[i]
+	}
+	s[15] &= 0x7f
+	c.Encrypt(s, s)
+	return s
+}
+
+// crypt XORs the input with the key stream of the counter starting at the tag with the most significant
+// bit set, incrementing its first 32 bits as a little-endian integer
+func crypt(c modes.Cipher, tag, input []byte) []byte {
+	counter := append([]byte{}, tag...)
+	counter[15] |= 0x80
+	keyStream := make([]byte, 16)
+	output := make([]byte, len(input))
+	for i := 0; i < len(input); i += 16 {
+		c.Encrypt(counter, keyStream)
+		for j := i; j < len(input) && j < i+16; j++ {
+			output[j] = input[j] ^ keyStream[j-i]
+		}
+		binary.LittleEndian.PutUint32(counter, binary.LittleEndian.Uint32(counter)+1)
+	}
+	return output
+}
+
+// checkLengths validates the nonce, of 12 bytes, and the length limits of the message and additional
+// data
+func checkLengths(nonce []byte, length int, additionalData []byte) error {
+	if len(nonce) != 12 {
+		return errors.New("Invalid nonce length. It must have 12 bytes")
+	}
+	if uint64(length) > 1<<36 || uint64(len(additionalData)) > 1<<36 {
+		return errors.New("Message too long")
+	}
+	return nil
+}
+
+// Seal encrypts and authenticates the plaintext and authenticates the additional data, returning the
+// ciphertext followed by the tag of 16 bytes
+func (g *GCMSIV) Seal(nonce, plaintext, additionalData []byte) ([]byte, error) {
+	if err := checkLengths(nonce, len(plaintext), additionalData); err != nil {
+		return nil, err
+	}
+	authKey, c, err := g.deriveKeys(nonce)
+	if err != nil {
+		return nil, err
+	}
+	t := tag(authKey, c, nonce, plaintext, additionalData)
+	return append(crypt(c, t, plaintext), t...), nil
+}
+
+// Open decrypts the ciphertext, which ends with the tag, and returns the plaintext if the tag matches it
+// and the additional data. It returns modes.ErrAuthentication otherwise.
+func (g *GCMSIV) Open(nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < 16 {
+		return nil, modes.ErrAuthentication
+	}
+	expected := ciphertext[len(ciphertext)-16:]
+	ciphertext = ciphertext[:len(ciphertext)-16]
+	if err := checkLengths(nonce, len(ciphertext), additionalData); err != nil {
+		return nil, err
+	}
+	authKey, c, err := g.deriveKeys(nonce)
+	if err != nil {
+		return nil, err
+	}
+	plaintext := crypt(c, expected, ciphertext)
+	if subtle.ConstantTimeCompare(tag(authKey, c, nonce, plaintext, additionalData), expected) != 1 {
+		for i := range plaintext {
+			plaintext[i] = 0
+		}
+		return nil, modes.ErrAuthentication
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,93 @@
+package gcmsiv
+
+import (
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"testing"
+)
+
+// RFC 8452 C.1 and C.2, AEAD_AES_128_GCM_SIV and AEAD_AES_256_GCM_SIV
+var vectors = []struct {
+	key       string
+	plaintext string
+	aad       string
+	sealed    string
+}{
+	{"01000000000000000000000000000000", "", "", "dc20e2d83f25705bb49e439eca56de25"},
+	{"01000000000000000000000000000000", "0100000000000000", "", "b5d839330ac7b786578782fff6013b815b287c22493a364c"},
+	{"01000000000000000000000000000000", "02000000000000000000000000000000030000000000000000000000000000000400000000000000", "01",
+		"7b5eb676df9e428faf0527050d1a91b8b8051f65e2e811208809da87a891dc0a9ea53e85af26f5d9a330c37fff2bc6b538e7d33843dd134e"},
+	{"0100000000000000000000000000000000000000000000000000000000000000", "", "", "07f5f4169bbf55a8400cd47ea6fd400f"},
+	{"0100000000000000000000000000000000000000000000000000000000000000", "0200000000000000000000000000000003000000000000000000000000000000", "010000000000000000000000",
+		"41a7dbdd7896cc25ed7ba00d7ece4bd7073fb9f1cf77b7e074e628ae8e4e11791e03b4e0ceb74affd60029e2dfa0d98e"},
+}
+
+const nonce = "030000000000000000000000"
+
+func newCipher(key []byte) (modes.Cipher, error) {
+	return aes.NewCipher(key)
+}
+
+func newMode(t *testing.T, key string) *GCMSIV {
+	k, _ := hex.DecodeString(key)
+	mode, err := NewMode(newCipher, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mode
+}
+
+func TestSeal(t *testing.T) {
+	n, _ := hex.DecodeString(nonce)
+	for _, v := range vectors {
+		p, _ := hex.DecodeString(v.plaintext)
+		a, _ := hex.DecodeString(v.aad)
+		sealed, err := newMode(t, v.key).Seal(n, p, a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(sealed); got != v.sealed {
+			t.Errorf("Invalid sealing of %s. Expected: %s Got: %s", v.plaintext, v.sealed, got)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	n, _ := hex.DecodeString(nonce)
+	for _, v := range vectors {
+		a, _ := hex.DecodeString(v.aad)
+		sealed, _ := hex.DecodeString(v.sealed)
+		mode := newMode(t, v.key)
+		p, err := mode.Open(n, sealed, a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(p); got != v.plaintext {
+			t.Errorf("Invalid opening of %s. Expected: %s Got: %s", v.sealed, v.plaintext, got)
+		}
+		sealed[0] ^= 1
+		if _, err := mode.Open(n, sealed, a); err != modes.ErrAuthentication {
+			t.Errorf("Accepting modified ciphertext %x", sealed)
+		}
+		sealed[0] ^= 1
+		n[0] ^= 1
+		if _, err := mode.Open(n, sealed, a); err != modes.ErrAuthentication {
+			t.Errorf("Accepting another nonce")
+		}
+		n[0] ^= 1
+	}
+}
+
+func TestInvalidParameters(t *testing.T) {
+	if _, err := NewMode(newCipher, make([]byte, 24)); err == nil {
+		t.Errorf("Accepting key of 24 bytes")
+	}
+	mode := newMode(t, vectors[0].key)
+	if _, err := mode.Seal(make([]byte, 8), nil, nil); err == nil {
+		t.Errorf("Accepting nonce of 8 bytes")
+	}
+	if _, err := mode.Open(make([]byte, 12), make([]byte, 15), nil); err != modes.ErrAuthentication {
+		t.Errorf("Accepting ciphertext shorter than the tag")
+	}
+}
@@ -0,0 +1,144 @@
+// Package kw implements the key wrap (KW) and key wrap with padding (KWP) modes of NIST SP 800-38F,
+// also specified for AES in RFC 3394 and RFC 5649. They encrypt and authenticate keys with a key
+// encryption key, without a nonce.
+package kw
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/modes"
+)
+
+// defaultIV is the initial value of KW and the prefix of the alternative initial value of KWP
+var defaultIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// checkCipher validates the block size of the cipher
+func checkCipher(cipher modes.Cipher) error {
+	if cipher.BlockSize() != 16 {
+		return errors.New("Invalid block size. Key wrapping needs a cipher of 128 bits")
+	}
+	return nil
+}
+
+// wrap runs the wrapping function W on the 64-bit semiblocks of the initial value and the data
+func wrap(cipher modes.Cipher, iv, data []byte) []byte {
+	n := len(data) / 8
+	result := make([]byte, 8+len(data))
+	copy(result, iv)
+	copy(result[8:], data)
+	block := make([]byte, 16)
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(block, result[:8])
+			copy(block[8:], result[8*i:8*i+8])
+			cipher.Encrypt(block, block)
+			binary.BigEndian.PutUint64(result, binary.BigEndian.Uint64(block)^uint64(n*j+i))
+			copy(result[8*i:], block[8:])
+		}
+	}
+	return result
+}
+
+// unwrap runs the unwrapping function W^-1 and returns the initial value and the data
+func unwrap(cipher modes.Cipher, ciphertext []byte) (iv, data []byte) {
+	n := len(ciphertext)/8 - 1
+	result := make([]byte, len(ciphertext))
+	copy(result, ciphertext)
+	block := make([]byte, 16)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			binary.BigEndian.PutUint64(block, binary.BigEndian.Uint64(result)^uint64(n*j+i))
+			copy(block[8:], result[8*i:8*i+8])
+			cipher.Decrypt(block, block)
+			copy(result, block[:8])
+			copy(result[8*i:], block[8:])
+		}
+	}
+	return result[:8], result[8:]
+}
+
+// Wrap wraps the plaintext, a multiple of 8 bytes of at least 16 bytes, with KW
+func Wrap(cipher modes.Cipher, plaintext []byte) ([]byte, error) {
+	if err := checkCipher(cipher); err != nil {
+		return nil, err
+	}
+	if len(plaintext) < 16 || len(plaintext)%8 != 0 {
+		return nil, errors.New("Invalid plaintext length. It must be a multiple of 8 bytes of at least 16 bytes")
+	}
+	return wrap(cipher, defaultIV, plaintext), nil
+}
+
+// Unwrap unwraps a KW ciphertext. It returns modes.ErrAuthentication if the ciphertext length or the
+// integrity check is invalid.
+func Unwrap(cipher modes.Cipher, ciphertext []byte) ([]byte, error) {
+	if err := checkCipher(cipher); err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < 24 || len(ciphertext)%8 != 0 {
+		return nil, modes.ErrAuthentication
+	}
+	iv, data := unwrap(cipher, ciphertext)
+	if subtle.ConstantTimeCompare(iv, defaultIV) != 1 {
+		return nil, modes.ErrAuthentication
+	}
+	return data, nil
+}
+
+// WrapPad wraps the plaintext, of 1 byte to 4 GiB, with KWP. The plaintext is padded with zeros to a
+// multiple of 8 bytes and its length is kept in the initial value.
+func WrapPad(cipher modes.Cipher, plaintext []byte) ([]byte, error) {
+	if err := checkCipher(cipher); err != nil {
+		return nil, err
+	}
+	if len(plaintext) == 0 || uint64(len(plaintext)) > 1<<32-1 {
+		return nil, errors.New("Invalid plaintext length. It must be from 1 byte to 4 GiB")
+	}
+	iv := make([]byte, 8)
+	copy(iv, []byte{0xa6, 0x59, 0x59, 0xa6})
+	binary.BigEndian.PutUint32(iv[4:], uint32(len(plaintext)))
+	padded := make([]byte, (len(plaintext)+7)/8*8)
+	copy(padded, plaintext)
+	if len(padded) == 8 {
+		// a single semiblock is encrypted with the initial value as one block
+		result := append(iv, padded...)
+		cipher.Encrypt(result, result)
+		return result, nil
+	}
+	return wrap(cipher, iv, padded), nil
+}
+
+// UnwrapPad unwraps a KWP ciphertext. It returns modes.ErrAuthentication if the ciphertext length,
+// the initial value, the plaintext length it holds or the padding is invalid.
+func UnwrapPad(cipher modes.Cipher, ciphertext []byte) ([]byte, error) {
+	if err := checkCipher(cipher); err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < 16 || len(ciphertext)%8 != 0 {
+		return nil, modes.ErrAuthentication
+	}
+	var iv, data []byte
+	if len(ciphertext) == 16 {
+		block := make([]byte, 16)
+		cipher.Decrypt(ciphertext, block)
+		iv, data = block[:8], block[8:]
+	} else {
+		iv, data = unwrap(cipher, ciphertext)
+	}
+	// the checks are combined so the error does not tell which one failed
+	valid := subtle.ConstantTimeCompare(iv[:4], []byte{0xa6, 0x59, 0x59, 0xa6})
+	length := binary.BigEndian.Uint32(iv[4:])
+	if uint64(length) > uint64(len(data)) || uint64(length)+8 <= uint64(len(data)) {
+		valid = 0
+		length = uint32(len(data))
+	}
+	var padding byte
+	for _, b := range data[length:] {
+		padding |= b
+	}
+	valid &= subtle.ConstantTimeByteEq(padding, 0)
+	if valid != 1 {
+		return nil, modes.ErrAuthentication
+	}
+	return data[:length], nil
+}
@@ -0,0 +1,117 @@
+package kw
+
+import (
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"testing"
+)
+
+// RFC 3394 4.1 and 4.6, and RFC 5649 section 6
+var vectors = []struct {
+	kek        string
+	plaintext  string
+	ciphertext string
+	pad        bool
+}{
+	{"000102030405060708090a0b0c0d0e0f", "00112233445566778899aabbccddeeff", "1fa68b0a8112b447aef34bd8fb5a7b829d3e862371d2cfe5", false},
+	{"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", "00112233445566778899aabbccddeeff000102030405060708090a0b0c0d0e0f",
+		"28c9f404c4b810f4cbccb35cfb87f8263f5786e2d80ed326cbc7f0e71a99f43bfb988b9b7a02dd21", false},
+	{"5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8", "c37b7e6492584340bed12207808941155068f738",
+		"138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a", true},
+	{"5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8", "466f7250617369", "afbeb0f07dfbf5419200f2ccb50bb24f", true},
+}
+
+func newCipher(t *testing.T, kek string) *aes.AESCipher {
+	k, _ := hex.DecodeString(kek)
+	cipher, err := aes.NewCipher(k)
+	if err != nil {
+		t.Fatal("Error creating cipher")
+	}
+	return cipher
+}
+
+func TestWrap(t *testing.T) {
+	for _, v := range vectors {
+		p, _ := hex.DecodeString(v.plaintext)
+		wrapFunc := Wrap
+		if v.pad {
+			wrapFunc = WrapPad
+		}
+		c, err := wrapFunc(newCipher(t, v.kek), p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(c); got != v.ciphertext {
+			t.Errorf("Invalid wrapping of %s. Expected: %s Got: %s", v.plaintext, v.ciphertext, got)
+		}
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	for _, v := range vectors {
+		c, _ := hex.DecodeString(v.ciphertext)
+		unwrapFunc := Unwrap
+		if v.pad {
+			unwrapFunc = UnwrapPad
+		}
+		cipher := newCipher(t, v.kek)
+		p, err := unwrapFunc(cipher, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(p); got != v.plaintext {
+			t.Errorf("Invalid unwrapping of %s. Expected: %s Got: %s", v.ciphertext, v.plaintext, got)
+		}
+		c[len(c)-1] ^= 1
+		if _, err := unwrapFunc(cipher, c); err != modes.ErrAuthentication {
+			t.Errorf("Accepting modified ciphertext %x", c)
+		}
+		if _, err := unwrapFunc(cipher, c[:len(c)-8]); err != modes.ErrAuthentication {
+			t.Errorf("Accepting truncated ciphertext %x", c[:len(c)-8])
+		}
+	}
+}
+
+func TestUnwrapPadChecks(t *testing.T) {
+	cipher := newCipher(t, vectors[2].kek)
+	data, _ := hex.DecodeString("c37b7e6492584340bed12207808941155068f73800000000")
+	for _, c := range []struct {
+		iv    string
+		data  []byte
+		valid bool
+	}{
+		{"a65959a600000014", data, true},
+		// lengths outside the last semiblock
+		{"a65959a600000010", data, false},
+		{"a65959a600000019", data, false},
+		{"a65959a600000000", data, false},
+		// a plaintext byte taken as padding
+		{"a65959a600000013", data, false},
+		{"a6a6a6a600000014", data, false},
+	} {
+		iv, _ := hex.DecodeString(c.iv)
+		_, err := UnwrapPad(cipher, wrap(cipher, iv, c.data))
+		if (err == nil) != c.valid {
+			t.Errorf("Invalid check of initial value %s. Expected valid: %v Got: %v", c.iv, c.valid, err)
+		}
+	}
+}
+
+func TestInvalidLengths(t *testing.T) {
+	cipher := newCipher(t, vectors[0].kek)
+	for _, size := range []int{0, 8, 20} {
+		if _, err := Wrap(cipher, make([]byte, size)); err == nil {
+			t.Errorf("Wrapping %d bytes", size)
+		}
+	}
+	if _, err := WrapPad(cipher, nil); err == nil {
+		t.Errorf("Wrapping empty plaintext with padding")
+	}
+	if _, err := Unwrap(cipher, make([]byte, 16)); err != modes.ErrAuthentication {
+		t.Errorf("Unwrapping a single block")
+	}
+	if _, err := UnwrapPad(cipher, make([]byte, 20)); err != modes.ErrAuthentication {
+		t.Errorf("Unwrapping ciphertext of 20 bytes")
+	}
+}
@@ -1,5 +1,7 @@
 package modes
 
+import "errors"
+
 // Operations
 const (
 	ENCRYPTION = iota
@@ -15,3 +17,7 @@ type Cipher interface {
 type CipherMode interface {
 	Cipher
 }
+
+// ErrAuthentication is returned by the authenticated modes and the key wraps when the tag or the
+// integrity check of a message does not match, without telling which part of the message is wrong
+var ErrAuthentication = errors.New("Message authentication failed")
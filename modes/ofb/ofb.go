@@ -0,0 +1,47 @@
+package ofb
+
+import (
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/modes"
+)
+
+// OFB is the Output Feedback mode of NIST SP 800-38A. The key stream is the repeated encryption of the
+// IV, so encryption and decryption are the same operation and an IV must never be reused with a key.
+type OFB struct {
+	cipher    modes.Cipher
+	register  []byte
+	keyStream []byte
+}
+
+// NewMode creates a new mode of operation OFB using the cipher and the initialization vector iv, which
+// must have the length of a block
+func NewMode(cipher modes.Cipher, iv []byte) (*OFB, error) {
+	if len(iv) != cipher.BlockSize() {
+		return nil, errors.New("Invalid IV length. It must have the length of a block")
+	}
+	ofbCipher := new(OFB)
+	ofbCipher.cipher = cipher
+	ofbCipher.register = make([]byte, len(iv))
+	copy(ofbCipher.register, iv)
+	ofbCipher.keyStream = make([]byte, len(iv))
+	return ofbCipher, nil
+}
+
+// Encrypt encrypts a block into dest. block and dest may be the same slice.
+func (c *OFB) Encrypt(block, dest []byte) {
+	c.cipher.Encrypt(c.register, c.keyStream)
+	copy(c.register, c.keyStream)
+	for i := range c.keyStream {
+		dest[i] = block[i] ^ c.keyStream[i]
+	}
+}
+
+// Decrypt decrypts a block into dest, which is the same as encrypting it
+func (c *OFB) Decrypt(block, dest []byte) {
+	c.Encrypt(block, dest)
+}
+
+// BlockSize returns the block size used
+func (c *OFB) BlockSize() int {
+	return c.cipher.BlockSize()
+}
@@ -0,0 +1,49 @@
+package ofb
+
+import (
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"testing"
+)
+
+// NIST SP 800-38A F.4.1 and F.4.2, OFB-AES128
+var (
+	key        = "2b7e151628aed2a6abf7158809cf4f3c"
+	iv         = "000102030405060708090a0b0c0d0e0f"
+	plaintext  = "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710"
+	ciphertext = "3b3fd92eb72dad20333449f8e83cfb4a7789508d16918f03f53c52dac54ed8259740051e9c5fecf64344f7a82260edcc304c6528f659c77866a510d9c1d6ae5e"
+)
+
+func process(t *testing.T, input string, decrypt bool) string {
+	k, _ := hex.DecodeString(key)
+	v, _ := hex.DecodeString(iv)
+	cipher, err := aes.NewCipher(k)
+	if err != nil {
+		t.Fatal("Error creating cipher")
+	}
+	mode, err := NewMode(cipher, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := hex.DecodeString(input)
+	for i := 0; i < len(data); i += 16 {
+		if decrypt {
+			mode.Decrypt(data[i:i+16], data[i:i+16])
+		} else {
+			mode.Encrypt(data[i:i+16], data[i:i+16])
+		}
+	}
+	return hex.EncodeToString(data)
+}
+
+func TestEncrypt(t *testing.T) {
+	if got := process(t, plaintext, false); got != ciphertext {
+		t.Errorf("Invalid encryption. Expected: %s Got: %s", ciphertext, got)
+	}
+}
+
+func TestDecrypt(t *testing.T) {
+	if got := process(t, ciphertext, true); got != plaintext {
+		t.Errorf("Invalid decryption. Expected: %s Got: %s", plaintext, got)
+	}
+}
@@ -1,9 +1,18 @@
 package modes
 
 import (
+	"crypto/subtle"
+	"errors"
 	"io"
 )
 
+// ErrInvalidPadding is returned on decryption when the last block does not end with a valid PKCS#7
+// padding: 1 to block size bytes, all holding the padding length
+var ErrInvalidPadding = errors.New("Invalid padding")
+
+// ErrInvalidLength is returned on decryption when the input length is not a multiple of the block size
+var ErrInvalidLength = errors.New("Invalid length. The input must be a multiple of the block size")
+
 type Reader struct {
 	size      int
 	cipher    Cipher
@@ -39,7 +48,7 @@ func NewReader(cipher Cipher, reader io.Reader, op int) *Reader {
 // encRead is the method used to read on encryption mode
 func encRead(r *Reader, dest []byte) (n int, err error) {
 	// block can be of unfixed size
-	n, err = r.reader.Read(r.auxBlock)
+	n, err = r.readBlock()
 	if r.nextN >= 0 {
 		return n, err
 	}
@@ -64,19 +73,31 @@ func decRead(r *Reader, dest []byte) (n int, err error) {
 	if r.nextN > 0 {
 		n, err = r.nextN, r.nextErr
 		copy(dest, r.nextBlock)
-		r.nextN, r.nextErr = r.reader.Read(r.auxBlock)
+		r.nextN, r.nextErr = r.readBlock()
+		if r.nextErr == ErrInvalidLength {
+			r.nextN = 0
+			return 0, ErrInvalidLength
+		}
 		if r.nextErr == io.EOF {
-			n, err = r.size-int(dest[r.size-1]), r.nextErr
+			n, err = r.unpad(dest)
 		} else {
 			r.cipher.Decrypt(r.auxBlock, r.nextBlock)
 		}
 	} else {
-		n, err = r.reader.Read(r.auxBlock)
+		n, err = r.readBlock()
+		if err == ErrInvalidLength {
+			r.nextN = 0
+			return 0, err
+		}
 		if n > 0 {
 			r.cipher.Decrypt(r.auxBlock, dest)
-			r.nextN, r.nextErr = r.reader.Read(r.auxBlock)
+			r.nextN, r.nextErr = r.readBlock()
+			if r.nextErr == ErrInvalidLength {
+				r.nextN = 0
+				return 0, ErrInvalidLength
+			}
 			if r.nextErr == io.EOF {
-				n, err = r.size-int(dest[r.size-1]), r.nextErr
+				n, err = r.unpad(dest)
 			} else {
 				r.cipher.Decrypt(r.auxBlock, r.nextBlock)
 			}
@@ -85,6 +106,36 @@ func decRead(r *Reader, dest []byte) (n int, err error) {
 	return n, err
 }
 
+// readBlock fills the auxiliary block from the source reader, which may return it in smaller chunks.
+// A short last block ends the data on encryption, where it is padded, and is an error on decryption.
+func (r *Reader) readBlock() (n int, err error) {
+	n, err = io.ReadFull(r.reader, r.auxBlock)
+	if err == io.ErrUnexpectedEOF {
+		if r.op == DECRYPTION {
+			return n, ErrInvalidLength
+		}
+		err = io.EOF
+	}
+	return n, err
+}
+
+// unpad returns the length of the last decrypted block without its padding and marks the end of data.
+// Every byte of the block is checked without branching on its value, so that the time taken does not
+// tell where the padding check failed.
+func (r *Reader) unpad(dest []byte) (n int, err error) {
+	r.nextN = 0
+	pad := int(dest[r.size-1])
+	good := subtle.ConstantTimeLessOrEq(1, pad) & subtle.ConstantTimeLessOrEq(pad, r.size)
+	for i := 0; i < r.size; i++ {
+		inPad := subtle.ConstantTimeLessOrEq(r.size, i+pad)
+		good &= subtle.ConstantTimeSelect(inPad, subtle.ConstantTimeByteEq(dest[i], byte(pad)), 1)
+	}
+	if good != 1 {
+		return 0, ErrInvalidPadding
+	}
+	return r.size - pad, io.EOF
+}
+
 // Read reads the next block of data managing the possible paddings and the encryption/decryption depending
 // on the operation used in NewCipher
 func (r *Reader) Read(dest []byte) (n int, err error) {
@@ -83,9 +83,10 @@ func TestReadSizes(t *testing.T) {
 		if ops[i] == ENCRYPTION {
 			data[i] = make([]byte, sizes[i])
 		} else {
-			data[i] = make([]byte, 16*(len(expectedReads[i])-1))
-			if sizes[i]%16 != 0 {
-				data[i][len(data[i])-1] = byte(16 - sizes[i]%16)
+			pad := 16 - sizes[i]%16
+			data[i] = make([]byte, sizes[i]+pad)
+			for j := sizes[i]; j < len(data[i]); j++ {
+				data[i][j] = byte(pad)
 			}
 		}
 	}
@@ -109,3 +110,21 @@ func TestReadSizes(t *testing.T) {
 		}
 	}
 }
+
+func TestDecryptInvalidLength(t *testing.T) {
+	for _, size := range []int{1, 15, 17, 31, 40} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = 16
+		}
+		reader := NewReader(NewMockCipher(16), NewMockReader(data), DECRYPTION)
+		dest := make([]byte, 16)
+		var err error
+		for i := 0; i <= size/16 && err == nil; i++ {
+			_, err = reader.Read(dest)
+		}
+		if err != ErrInvalidLength {
+			t.Errorf("Accepting a ciphertext of length %d. Expected error: %v Got: %v", size, ErrInvalidLength, err)
+		}
+	}
+}
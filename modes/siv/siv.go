@@ -0,0 +1,124 @@
+// Package siv implements the Synthetic Initialization Vector mode of RFC 5297 (AES-SIV-CMAC), a
+// deterministic authenticated encryption mode: the IV is the S2V CMAC of the additional data and the
+// plaintext, so a nonce is optional and reusing it only reveals that two messages are equal.
+package siv
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"github.com/emanuelzabka/crypt-aes/modes/cmac"
+	"github.com/emanuelzabka/crypt-aes/modes/ctr"
+)
+
+// SIV seals and opens messages with the two halves of a key, one for S2V and one for the counter mode
+type SIV struct {
+	mac    *cmac.CMAC
+	cipher modes.Cipher
+}
+
+// NewMode creates a new SIV using macCipher, keyed with the first half of the SIV key, for S2V and
+// cipher, keyed with the second half, for the counter mode. Both must have blocks of 128 bits.
+func NewMode(macCipher, cipher modes.Cipher) (*SIV, error) {
+	if macCipher.BlockSize() != 16 || cipher.BlockSize() != 16 {
+		return nil, errors.New("Invalid block size. SIV needs ciphers of 128 bits")
+	}
+	mac, err := cmac.New(macCipher)
+	if err != nil {
+		return nil, err
+	}
+	s := new(SIV)
+	s.mac = mac
+	s.cipher = cipher
+	return s, nil
+}
+
+// double multiplies the block by x in GF(2^128)
+func double(block []byte) {
+	carry := block[0] >> 7
+	for i := 0; i < 15; i++ {
+		block[i] = block[i]<<1 | block[i+1]>>7
+	}
+	block[15] = block[15]<<1 ^ 0x87&-carry
+}
+
+// s2v returns the synthetic IV of the strings, the last one being the plaintext
+func (s *SIV) s2v(inputs [][]byte) []byte {
+	d := s.mac.Sum(make([]byte, 16))
+	for _, input := range inputs[:len(inputs)-1] {
+		double(d)
+		for i, b := range s.mac.Sum(input) {
+			d[i] ^= b
+		}
+	}
+	last := inputs[len(inputs)-1]
+	var t []byte
+	if len(last) >= 16 {
+		// the block is XORed into the end of the last string
+		t = append([]byte{}, last...)
+		for i := range d {
+			t[len(t)-16+i] ^= d[i]
+		}
+	} else {
+		double(d)
+		t = d
+		for i, b := range last {
+			t[i] ^= b
+		}
+		t[len(last)] ^= 0x80
+	}
+	return s.mac.Sum(t)
+}
+
+// crypt XORs the input with the counter mode key stream starting at the IV with the bits 63 and 31
+// cleared
+func (s *SIV) crypt(v, input []byte) []byte {
+	counter := append([]byte{}, v...)
+	counter[8] &= 0x7f
+	counter[12] &= 0x7f
+	mode, _ := ctr.NewMode(s.cipher, counter)
+	output := make([]byte, (len(input)+15)/16*16)
+	copy(output, input)
+	for i := 0; i < len(output); i += 16 {
+		mode.Encrypt(output[i:i+16], output[i:i+16])
+	}
+	return output[:len(input)]
+}
+
+// checkStrings validates the number of additional data strings, of which S2V takes up to 126
+func checkStrings(additionalData [][]byte) error {
+	if len(additionalData) > 126 {
+		return errors.New("Too many additional data strings. SIV takes up to 126")
+	}
+	return nil
+}
+
+// Seal encrypts the plaintext and authenticates it with the additional data strings, one of which may be
+// a nonce, returning the synthetic IV followed by the ciphertext
+func (s *SIV) Seal(plaintext []byte, additionalData ...[]byte) ([]byte, error) {
+	if err := checkStrings(additionalData); err != nil {
+		return nil, err
+	}
+	v := s.s2v(append(append([][]byte{}, additionalData...), plaintext))
+	return append(v, s.crypt(v, plaintext)...), nil
+}
+
+// Open decrypts the ciphertext, which starts with the synthetic IV, and returns the plaintext if the IV
+// matches it and the additional data strings. It returns modes.ErrAuthentication otherwise.
+func (s *SIV) Open(ciphertext []byte, additionalData ...[]byte) ([]byte, error) {
+	if err := checkStrings(additionalData); err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < 16 {
+		return nil, modes.ErrAuthentication
+	}
+	v := ciphertext[:16]
+	plaintext := s.crypt(v, ciphertext[16:])
+	if subtle.ConstantTimeCompare(s.s2v(append(append([][]byte{}, additionalData...), plaintext)), v) != 1 {
+		for i := range plaintext {
+			plaintext[i] = 0
+		}
+		return nil, modes.ErrAuthentication
+	}
+	return plaintext, nil
+}
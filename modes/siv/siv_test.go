@@ -0,0 +1,102 @@
+package siv
+
+import (
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"testing"
+)
+
+// RFC 5297 A.1 (deterministic) and A.2 (nonce based, with three additional data strings)
+var vectors = []struct {
+	key       string
+	aad       []string
+	plaintext string
+	sealed    string
+}{
+	{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff", []string{"101112131415161718191a1b1c1d1e1f2021222324252627"},
+		"112233445566778899aabbccddee", "85632d07c6e8f37f950acd320a2ecc9340c02b9690c4dc04daef7f6afe5c"},
+	{"7f7e7d7c7b7a79787776757473727170404142434445464748494a4b4c4d4e4f",
+		[]string{"00112233445566778899aabbccddeeffdeaddadadeaddadaffeeddccbbaa99887766554433221100", "102030405060708090a0", "09f911029d74e35bd84156c5635688c0"},
+		"7468697320697320736f6d6520706c61696e7465787420746f20656e6372797074207573696e67205349562d414553",
+		"7bdb6e3b432667eb06f4d14bff2fbd0fcb900f2fddbe404326601965c889bf17dba77ceb094fa663b7a3f748ba8af829ea64ad544a272e9c485b62a3fd5c0d"},
+}
+
+func newMode(t *testing.T, key string) *SIV {
+	k, _ := hex.DecodeString(key)
+	macCipher, err := aes.NewCipher(k[:len(k)/2])
+	if err != nil {
+		t.Fatal("Error creating cipher")
+	}
+	cipher, err := aes.NewCipher(k[len(k)/2:])
+	if err != nil {
+		t.Fatal("Error creating cipher")
+	}
+	mode, err := NewMode(macCipher, cipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mode
+}
+
+func decodeAll(values []string) [][]byte {
+	result := make([][]byte, len(values))
+	for i, v := range values {
+		result[i], _ = hex.DecodeString(v)
+	}
+	return result
+}
+
+func TestSeal(t *testing.T) {
+	for _, v := range vectors {
+		p, _ := hex.DecodeString(v.plaintext)
+		sealed, err := newMode(t, v.key).Seal(p, decodeAll(v.aad)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(sealed); got != v.sealed {
+			t.Errorf("Invalid sealing. Expected: %s Got: %s", v.sealed, got)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	for _, v := range vectors {
+		sealed, _ := hex.DecodeString(v.sealed)
+		aad := decodeAll(v.aad)
+		mode := newMode(t, v.key)
+		p, err := mode.Open(sealed, aad...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(p); got != v.plaintext {
+			t.Errorf("Invalid opening. Expected: %s Got: %s", v.plaintext, got)
+		}
+		sealed[len(sealed)-1] ^= 1
+		if _, err := mode.Open(sealed, aad...); err != modes.ErrAuthentication {
+			t.Errorf("Accepting modified ciphertext")
+		}
+		sealed[len(sealed)-1] ^= 1
+		// the strings are authenticated in order
+		if len(aad) > 1 {
+			aad[0], aad[1] = aad[1], aad[0]
+			if _, err := mode.Open(sealed, aad...); err != modes.ErrAuthentication {
+				t.Errorf("Accepting swapped additional data")
+			}
+		}
+		if _, err := mode.Open(sealed[:15]); err != modes.ErrAuthentication {
+			t.Errorf("Accepting ciphertext shorter than the IV")
+		}
+	}
+}
+
+func TestEmptyPlaintext(t *testing.T) {
+	mode := newMode(t, vectors[0].key)
+	sealed, err := mode.Seal(nil)
+	if err != nil || len(sealed) != 16 {
+		t.Fatalf("Invalid sealing of empty plaintext: %x %v", sealed, err)
+	}
+	if p, err := mode.Open(sealed); err != nil || len(p) != 0 {
+		t.Errorf("Invalid opening of empty plaintext: %x %v", p, err)
+	}
+}
@@ -0,0 +1,126 @@
+// Package xts implements the XTS mode of IEEE 1619 and NIST SP 800-38E for the encryption of storage.
+// Each data unit (a disk sector) is encrypted with a tweak, usually its number, so equal data units
+// encrypt differently, and a last partial block is handled with ciphertext stealing.
+package xts
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/modes"
+)
+
+// XTS encrypts and decrypts data units with two keys, one for the data and one for the tweaks
+type XTS struct {
+	cipher      modes.Cipher
+	tweakCipher modes.Cipher
+}
+
+// NewMode creates a new mode XTS using cipher for the data and tweakCipher for the tweaks, which must
+// have blocks of 128 bits and different keys of the same length
+func NewMode(cipher, tweakCipher modes.Cipher) (*XTS, error) {
+	if cipher.BlockSize() != 16 || tweakCipher.BlockSize() != 16 {
+		return nil, errors.New("Invalid block size. XTS needs ciphers of 128 bits")
+	}
+	x := new(XTS)
+	x.cipher = cipher
+	x.tweakCipher = tweakCipher
+	return x, nil
+}
+
+// SequenceTweak returns the tweak of the data unit number n, as a 128-bit little-endian integer
+func SequenceTweak(n uint64) []byte {
+	tweak := make([]byte, 16)
+	binary.LittleEndian.PutUint64(tweak, n)
+	return tweak
+}
+
+// multiply multiplies the tweak by x in GF(2^128), with the little-endian bit order of XTS
+func multiply(tweak []byte) {
+	carry := tweak[15] >> 7
+	for i := 15; i > 0; i-- {
+		tweak[i] = tweak[i]<<1 | tweak[i-1]>>7
+	}
+	tweak[0] = tweak[0]<<1 ^ 0x87&-carry
+}
+
+// processBlock encrypts or decrypts a block XORed before and after with the tweak
+func processBlock(process func(block, dest []byte), tweak, block, dest []byte) {
+	for i := range tweak {
+		dest[i] = block[i] ^ tweak[i]
+	}
+	process(dest, dest)
+	for i := range tweak {
+		dest[i] ^= tweak[i]
+	}
+}
+
+// checkUnit validates the tweak and the data unit, which must have at least a block
+func checkUnit(tweak, data, dest []byte) error {
+	if len(tweak) != 16 {
+		return errors.New("Invalid tweak length. It must have the length of a block")
+	}
+	if len(data) < 16 {
+		return errors.New("Invalid data unit length. It must have at least a block")
+	}
+	if len(dest) < len(data) {
+		return errors.New("Destination shorter than the data unit")
+	}
+	return nil
+}
+
+// EncryptUnit encrypts the data unit with the tweak into dest, which may be the same slice
+func (x *XTS) EncryptUnit(tweak, data, dest []byte) error {
+	if err := checkUnit(tweak, data, dest); err != nil {
+		return err
+	}
+	t := make([]byte, 16)
+	x.tweakCipher.Encrypt(tweak, t)
+	full := len(data) / 16 * 16
+	for i := 0; i < full; i += 16 {
+		processBlock(x.cipher.Encrypt, t, data[i:i+16], dest[i:i+16])
+		multiply(t)
+	}
+	if rest := len(data) - full; rest > 0 {
+		// the last full ciphertext block gives its head to the partial block and its tail pads the
+		// partial plaintext, encrypted in its place
+		last := dest[full-16 : full]
+		block := make([]byte, 16)
+		copy(block, data[full:])
+		copy(block[rest:], last[rest:])
+		copy(dest[full:], last[:rest])
+		processBlock(x.cipher.Encrypt, t, block, last)
+	}
+	return nil
+}
+
+// DecryptUnit decrypts the data unit with the tweak into dest, which may be the same slice
+func (x *XTS) DecryptUnit(tweak, data, dest []byte) error {
+	if err := checkUnit(tweak, data, dest); err != nil {
+		return err
+	}
+	t := make([]byte, 16)
+	x.tweakCipher.Encrypt(tweak, t)
+	full := len(data) / 16 * 16
+	rest := len(data) - full
+	if rest > 0 {
+		// the last full block was encrypted with the tweak after its own
+		full -= 16
+	}
+	for i := 0; i < full; i += 16 {
+		processBlock(x.cipher.Decrypt, t, data[i:i+16], dest[i:i+16])
+		multiply(t)
+	}
+	if rest > 0 {
+		next := make([]byte, 16)
+		copy(next, t)
+		multiply(next)
+		block := make([]byte, 16)
+		processBlock(x.cipher.Decrypt, next, data[full:full+16], block)
+		partial := make([]byte, 16)
+		copy(partial, data[full+16:])
+		copy(partial[rest:], block[rest:])
+		copy(dest[full+16:], block[:rest])
+		processBlock(x.cipher.Decrypt, t, partial, dest[full:full+16])
+	}
+	return nil
+}
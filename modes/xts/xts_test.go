@@ -0,0 +1,80 @@
+package xts
+
+import (
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"testing"
+)
+
+// IEEE 1619 D.4 vectors 2 and 15 to 17, the last ones with ciphertext stealing, and a three block
+// data unit with the keys of vector 15
+var vectors = []struct {
+	key        string
+	sequence   uint64
+	plaintext  string
+	ciphertext string
+}{
+	{"1111111111111111111111111111111122222222222222222222222222222222", 0x3333333333,
+		"4444444444444444444444444444444444444444444444444444444444444444",
+		"c454185e6a16936e39334038acef838bfb186fff7480adc4289382ecd6d394f0"},
+	{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+		"000102030405060708090a0b0c0d0e0f10", "6c1625db4671522d3d7599601de7ca09ed"},
+	{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+		"000102030405060708090a0b0c0d0e0f10111213", "9d84c813f719aa2c7be3f66171c7c5c2edbf9dac"},
+	{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e", "d05bc090a8e04f1b3d3ecdd5baec0fd4edbf9dace45d6f6a7306e64be5dd82"},
+	{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f",
+		"edbf9dace45d6f6a7306e64be5dd824b2538f5724fcf24249ac111ab45ad39233ad6183c66fa548a3cdf3e36d2b21ccd"},
+}
+
+func newMode(t *testing.T, key string) *XTS {
+	k, _ := hex.DecodeString(key)
+	cipher, err := aes.NewCipher(k[:len(k)/2])
+	if err != nil {
+		t.Fatal("Error creating cipher")
+	}
+	tweakCipher, err := aes.NewCipher(k[len(k)/2:])
+	if err != nil {
+		t.Fatal("Error creating cipher")
+	}
+	mode, err := NewMode(cipher, tweakCipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mode
+}
+
+func TestEncryptUnit(t *testing.T) {
+	for _, v := range vectors {
+		data, _ := hex.DecodeString(v.plaintext)
+		if err := newMode(t, v.key).EncryptUnit(SequenceTweak(v.sequence), data, data); err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(data); got != v.ciphertext {
+			t.Errorf("Invalid encryption of %d bytes. Expected: %s Got: %s", len(data), v.ciphertext, got)
+		}
+	}
+}
+
+func TestDecryptUnit(t *testing.T) {
+	for _, v := range vectors {
+		data, _ := hex.DecodeString(v.ciphertext)
+		if err := newMode(t, v.key).DecryptUnit(SequenceTweak(v.sequence), data, data); err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(data); got != v.plaintext {
+			t.Errorf("Invalid decryption of %d bytes. Expected: %s Got: %s", len(data), v.plaintext, got)
+		}
+	}
+}
+
+func TestInvalidUnit(t *testing.T) {
+	mode := newMode(t, vectors[0].key)
+	if err := mode.EncryptUnit(SequenceTweak(0), make([]byte, 15), make([]byte, 15)); err == nil {
+		t.Errorf("Accepting data unit shorter than a block")
+	}
+	if err := mode.DecryptUnit(make([]byte, 8), make([]byte, 16), make([]byte, 16)); err == nil {
+		t.Errorf("Accepting tweak shorter than a block")
+	}
+}
@@ -0,0 +1,113 @@
+// Package ppm decodes and encodes binary Netpbm PPM (P6) images with 8-bit samples. Importing it
+// registers the format with the image package.
+package ppm
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+func init() {
+	image.RegisterFormat("ppm", "P6", Decode, DecodeConfig)
+}
+
+// readHeader reads the magic number, width, height and maximum sample value, skipping comments
+func readHeader(r *bufio.Reader) (width, height, maxValue int, err error) {
+	var fields []int
+	magic := make([]byte, 2)
+	if _, err = io.ReadFull(r, magic); err != nil {
+		return 0, 0, 0, err
+	}
+	if string(magic) != "P6" {
+		return 0, 0, 0, errors.New("Invalid PPM magic number. Only binary P6 images are supported")
+	}
+	for len(fields) < 3 {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		switch {
+		case b == '#':
+			if _, err := r.ReadString('\n'); err != nil {
+				return 0, 0, 0, err
+			}
+		case b >= '0' && b <= '9':
+			value := int(b - '0')
+			for {
+				b, err = r.ReadByte()
+				if err != nil {
+					return 0, 0, 0, err
+				}
+				if b < '0' || b > '9' {
+					break
+				}
+				value = value*10 + int(b-'0')
+				if value > 1<<16 {
+					return 0, 0, 0, errors.New("Invalid PPM header value")
+				}
+			}
+			fields = append(fields, value)
+			if b == '#' {
+				r.UnreadByte()
+			}
+		case b == ' ' || b == '\t' || b == '\n' || b == '\r':
+		default:
+			return 0, 0, 0, fmt.Errorf("Invalid PPM header byte 0x%02x", b)
+		}
+	}
+	if fields[2] < 1 || fields[2] > 255 {
+		return 0, 0, 0, errors.New("Invalid PPM maximum value. Only 8-bit samples are supported")
+	}
+	return fields[0], fields[1], fields[2], nil
+}
+
+// DecodeConfig returns the dimensions of the image
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	width, height, _, err := readHeader(bufio.NewReader(r))
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.RGBAModel, Width: width, Height: height}, nil
+}
+
+// Decode reads a P6 image, scaling the samples to 8 bits
+func Decode(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	width, height, maxValue, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	row := make([]byte, 3*width)
+	for y := 0; y < height; y++ {
+		if _, err := io.ReadFull(br, row); err != nil {
+			return nil, err
+		}
+		for x := 0; x < width; x++ {
+			offset := img.PixOffset(x, y)
+			for i := 0; i < 3; i++ {
+				img.Pix[offset+i] = byte(int(row[3*x+i]) * 255 / maxValue)
+			}
+			img.Pix[offset+3] = 0xff
+		}
+	}
+	return img, nil
+}
+
+// Encode writes the image as P6 with 8-bit samples, dropping the alpha channel
+func Encode(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "P6\n%d %d\n255\n", bounds.Dx(), bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			bw.Write([]byte{c.R, c.G, c.B})
+		}
+	}
+	return bw.Flush()
+}
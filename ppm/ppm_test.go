@@ -0,0 +1,65 @@
+package ppm
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	data := "P6\n# comment\n2 1\n255\n\xff\x00\x00\x00\x80\xff"
+	img, format, err := image.Decode(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error decoding: %s", err.Error())
+	}
+	if format != "ppm" || img.Bounds().Dx() != 2 || img.Bounds().Dy() != 1 {
+		t.Fatalf("Invalid decoded image. Format: %s Bounds: %v", format, img.Bounds())
+	}
+	expected := []color.RGBA{{0xff, 0, 0, 0xff}, {0, 0x80, 0xff, 0xff}}
+	for x, c := range expected {
+		if got := img.At(x, 0); got != c {
+			t.Errorf("Invalid pixel %d. Expected: %v Got: %v", x, c, got)
+		}
+	}
+}
+
+func TestDecodeScaled(t *testing.T) {
+	img, err := Decode(strings.NewReader("P6 1 1 15 \x0f\x00\x05"))
+	if err != nil {
+		t.Fatalf("Error decoding: %s", err.Error())
+	}
+	if got := img.At(0, 0); got != (color.RGBA{0xff, 0, 0x55, 0xff}) {
+		t.Errorf("Invalid scaled pixel. Got: %v", got)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for _, data := range []string{"P3\n1 1\n255\n", "P6\n1 1\n65535\n", "P6\n2 2\n255\n\x00\x00\x00", "P6 x"} {
+		if _, err := Decode(strings.NewReader(data)); err == nil {
+			t.Errorf("Accepting invalid image %q", data)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i * 17)
+	}
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xff
+	}
+	var buffer bytes.Buffer
+	if err := Encode(&buffer, img); err != nil {
+		t.Fatalf("Error encoding: %s", err.Error())
+	}
+	decoded, err := Decode(&buffer)
+	if err != nil {
+		t.Fatalf("Error decoding: %s", err.Error())
+	}
+	if !bytes.Equal(decoded.(*image.RGBA).Pix, img.Pix) {
+		t.Errorf("Invalid round trip")
+	}
+}
@@ -0,0 +1,213 @@
+// Package saes implements the Simplified AES (S-AES) teaching cipher by Musa, Schaefer and Wedig, with
+// a 16-bit block, a 16-bit key and two rounds working on a 2x2 state of nibbles over GF(2^4).
+//
+// The block is stored as two bytes, one state column each, the high nibble being the first row, as the
+// columns of the AES state.
+package saes
+
+import (
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/aes"
+)
+
+// BlockSize is the S-AES block size in bytes
+const BlockSize = 2
+
+// KeySize is the S-AES key size in bytes
+const KeySize = 2
+
+// Rounds is the number of rounds of S-AES
+const Rounds = 2
+
+var sBox = []byte{0x9, 0x4, 0xa, 0xb, 0xd, 0x1, 0x8, 0x5, 0x6, 0x2, 0x0, 0x3, 0xc, 0xe, 0xf, 0x7}
+var invSBox = []byte{0xa, 0x5, 0x9, 0xb, 0x1, 0x7, 0x8, 0xf, 0x6, 0x0, 0x2, 0x3, 0xc, 0x4, 0xd, 0xe}
+
+// rCon are the round constants of the key expansion
+var rCon = []byte{0x80, 0x30}
+
+// SAESCipher is the S-AES cipher
+type SAESCipher struct {
+	roundKeys [][]byte
+	tracer    aes.Tracer
+}
+
+// NewCipher creates a new S-AES cipher using the 2-byte key
+func NewCipher(key []byte) (*SAESCipher, error) {
+	if len(key) != KeySize {
+		return nil, errors.New("Invalid key length. The S-AES key must be 2 bytes long")
+	}
+	c := new(SAESCipher)
+	c.roundKeys = expandKey(key)
+	return c, nil
+}
+
+// gf16Mul returns the multiplication in GF(2^4) with the polynomial x^4 + x + 1
+func gf16Mul(a, b byte) byte {
+	var prod byte = 0
+	for a != 0 && b != 0 {
+		if b&1 != 0 {
+			prod ^= a
+		}
+		b >>= 1
+		a <<= 1
+		if a&0x10 != 0 {
+			a ^= 0x13
+		}
+	}
+	return prod
+}
+
+// subNibbles substitutes both nibbles of b using table
+func subNibbles(b byte, table []byte) byte {
+	return table[b>>4]<<4 | table[b&0xf]
+}
+
+// rotNibble swaps the nibbles of b
+func rotNibble(b byte) byte {
+	return b<<4 | b>>4
+}
+
+// expandKey returns the three round keys of the key
+func expandKey(key []byte) [][]byte {
+	w := make([]byte, 6)
+	copy(w, key)
+	for i := 2; i < 6; i += 2 {
+		w[i] = w[i-2] ^ rCon[i/2-1] ^ subNibbles(rotNibble(w[i-1]), sBox)
+		w[i+1] = w[i] ^ w[i-1]
+	}
+	return [][]byte{w[0:2], w[2:4], w[4:6]}
+}
+
+func subBytes(state []byte, table []byte) {
+	state[0] = subNibbles(state[0], table)
+	state[1] = subNibbles(state[1], table)
+}
+
+// shiftRows swaps the nibbles of the second row
+func shiftRows(state []byte) {
+	low0, low1 := state[0]&0xf, state[1]&0xf
+	state[0] = state[0]&0xf0 | low1
+	state[1] = state[1]&0xf0 | low0
+}
+
+// mixColumns multiplies each column by the matrix [[a, b], [b, a]]
+func mixColumns(state []byte, a, b byte) {
+	for c := 0; c < 2; c++ {
+		s0, s1 := state[c]>>4, state[c]&0xf
+		state[c] = (gf16Mul(a, s0)^gf16Mul(b, s1))<<4 | gf16Mul(b, s0) ^ gf16Mul(a, s1)
+	}
+}
+
+func addRoundKey(state []byte, roundKey []byte) {
+	state[0] ^= roundKey[0]
+	state[1] ^= roundKey[1]
+}
+
+// SetTracer sets the tracer called during Encrypt and Decrypt, using the same step names as the AES
+// cipher. A nil tracer disables tracing.
+func (c *SAESCipher) SetTracer(tracer aes.Tracer) {
+	c.tracer = tracer
+}
+
+func (c *SAESCipher) hook(round int, step string, data []byte) {
+	if c.tracer != nil {
+		c.tracer.Trace(round, step, data)
+	}
+}
+
+// RoundKeys returns a copy of the three round keys
+func (c *SAESCipher) RoundKeys() [][]byte {
+	result := make([][]byte, len(c.roundKeys))
+	for i, k := range c.roundKeys {
+		result[i] = append([]byte{}, k...)
+	}
+	return result
+}
+
+// Encrypt encrypts a 2-byte block into dest
+func (c *SAESCipher) Encrypt(block, dest []byte) {
+	state := []byte{block[0], block[1]}
+	c.hook(0, "input", state)
+	addRoundKey(state, c.roundKeys[0])
+	c.hook(0, "k_sch", c.roundKeys[0])
+	c.hook(1, "start", state)
+	subBytes(state, sBox)
+	c.hook(1, "s_box", state)
+	shiftRows(state)
+	c.hook(1, "s_row", state)
+	mixColumns(state, 1, 4)
+	c.hook(1, "m_col", state)
+	addRoundKey(state, c.roundKeys[1])
+	c.hook(1, "k_sch", c.roundKeys[1])
+	c.hook(2, "start", state)
+	subBytes(state, sBox)
+	c.hook(2, "s_box", state)
+	shiftRows(state)
+	c.hook(2, "s_row", state)
+	addRoundKey(state, c.roundKeys[2])
+	c.hook(2, "k_sch", c.roundKeys[2])
+	c.hook(2, "output", state)
+	copy(dest, state)
+}
+
+// Decrypt decrypts a 2-byte block into dest
+func (c *SAESCipher) Decrypt(block, dest []byte) {
+	state := []byte{block[0], block[1]}
+	c.hook(0, "iinput", state)
+	addRoundKey(state, c.roundKeys[2])
+	c.hook(0, "ik_sch", c.roundKeys[2])
+	c.hook(1, "istart", state)
+	shiftRows(state)
+	c.hook(1, "is_row", state)
+	subBytes(state, invSBox)
+	c.hook(1, "is_box", state)
+	addRoundKey(state, c.roundKeys[1])
+	c.hook(1, "ik_sch", c.roundKeys[1])
+	c.hook(1, "ik_add", state)
+	mixColumns(state, 9, 2)
+	c.hook(2, "istart", state)
+	shiftRows(state)
+	c.hook(2, "is_row", state)
+	subBytes(state, invSBox)
+	c.hook(2, "is_box", state)
+	addRoundKey(state, c.roundKeys[0])
+	c.hook(2, "ik_sch", c.roundKeys[0])
+	c.hook(2, "ioutput", state)
+	copy(dest, state)
+}
+
+// BlockSize returns the block size of S-AES
+func (c *SAESCipher) BlockSize() int {
+	return BlockSize
+}
+
+// Search tries all the 2^16 keys and returns the ones that encrypt every plaintext block into the
+// ciphertext block of the same index
+func Search(plaintexts, ciphertexts [][]byte) ([][]byte, error) {
+	if len(plaintexts) == 0 || len(plaintexts) != len(ciphertexts) {
+		return nil, errors.New("Invalid pairs. At least one plaintext/ciphertext pair is required")
+	}
+	for i := range plaintexts {
+		if len(plaintexts[i]) != BlockSize || len(ciphertexts[i]) != BlockSize {
+			return nil, errors.New("Invalid block length. S-AES blocks are 2 bytes long")
+		}
+	}
+	var keys [][]byte
+	dest := make([]byte, BlockSize)
+	for k := 0; k < 1<<16; k++ {
+		key := []byte{byte(k >> 8), byte(k)}
+		c, _ := NewCipher(key)
+		match := true
+		for i := range plaintexts {
+			c.Encrypt(plaintexts[i], dest)
+			if dest[0] != ciphertexts[i][0] || dest[1] != ciphertexts[i][1] {
+				match = false
+				break
+			}
+		}
+		if match {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,89 @@
+package saes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"github.com/emanuelzabka/crypt-aes/modes/ecb"
+	"io"
+	"testing"
+)
+
+// worked examples from Musa, Schaefer and Wedig and from Stallings (Cryptography and Network Security)
+var saesTests = []struct {
+	key        string
+	plaintext  string
+	ciphertext string
+	roundKeys  []string
+}{
+	{"4af5", "d728", "24ec", []string{"4af5", "dd28", "87af"}},
+	{"a73b", "6f6b", "0738", []string{"a73b", "1c27", "7651"}},
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	for _, test := range saesTests {
+		key, _ := hex.DecodeString(test.key)
+		plaintext, _ := hex.DecodeString(test.plaintext)
+		c, err := NewCipher(key)
+		if err != nil {
+			t.Fatalf("Error creating cipher: %s", err.Error())
+		}
+		for i, roundKey := range c.RoundKeys() {
+			if hex.EncodeToString(roundKey) != test.roundKeys[i] {
+				t.Errorf("Invalid round key %d. Expected: 0x%s Got: 0x%x", i, test.roundKeys[i], roundKey)
+			}
+		}
+		dest := make([]byte, BlockSize)
+		c.Encrypt(plaintext, dest)
+		if hex.EncodeToString(dest) != test.ciphertext {
+			t.Errorf("Invalid encryption result. Expected: 0x%s Got: 0x%x", test.ciphertext, dest)
+		}
+		c.Decrypt(dest, dest)
+		if !bytes.Equal(dest, plaintext) {
+			t.Errorf("Invalid decryption result. Expected: 0x%s Got: 0x%x", test.plaintext, dest)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	key, _ := hex.DecodeString("a73b")
+	c, _ := NewCipher(key)
+	plaintexts := [][]byte{{0x6f, 0x6b}, {0x12, 0x34}, {0xff, 0x00}}
+	ciphertexts := make([][]byte, len(plaintexts))
+	for i, p := range plaintexts {
+		ciphertexts[i] = make([]byte, BlockSize)
+		c.Encrypt(p, ciphertexts[i])
+	}
+	keys, err := Search(plaintexts, ciphertexts)
+	if err != nil {
+		t.Fatalf("Error searching key: %s", err.Error())
+	}
+	if len(keys) != 1 || !bytes.Equal(keys[0], key) {
+		t.Errorf("Invalid keys found. Expected: [a73b] Got: %x", keys)
+	}
+}
+
+func TestReader(t *testing.T) {
+	key, _ := hex.DecodeString("4af5")
+	c, _ := NewCipher(key)
+	for _, size := range []int{0, 1, 2, 3, 10} {
+		plaintext := make([]byte, size)
+		for i := range plaintext {
+			plaintext[i] = byte(i * 37)
+		}
+		encrypted, err := io.ReadAll(modes.NewReader(ecb.NewMode(c), bytes.NewReader(plaintext), modes.ENCRYPTION))
+		if err != nil {
+			t.Fatalf("Error encrypting: %s", err.Error())
+		}
+		if len(encrypted) != size+BlockSize-size%BlockSize {
+			t.Errorf("Invalid encrypted length for %d bytes: %d", size, len(encrypted))
+		}
+		decrypted, err := io.ReadAll(modes.NewReader(ecb.NewMode(c), bytes.NewReader(encrypted), modes.DECRYPTION))
+		if err != nil {
+			t.Fatalf("Error decrypting: %s", err.Error())
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("Invalid round trip of %d bytes. Expected: 0x%x Got: 0x%x", size, plaintext, decrypted)
+		}
+	}
+}
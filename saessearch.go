@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/saes"
+	"time"
+)
+
+type saesSearchCommand struct {
+	Plaintexts  []string `short:"p" long:"plaintext" description:"Known plaintext block (repeat for more pairs)" required:"yes"`
+	Ciphertexts []string `short:"c" long:"ciphertext" description:"Ciphertext block of the plaintext of the same position (repeat for more pairs)" required:"yes"`
+}
+
+func init() {
+	parser.AddCommand(
+		"saes-search",
+		"Exhaustive key search on S-AES",
+		"Tries all the 2^16 S-AES keys and prints the ones that encrypt each known plaintext block into its ciphertext block. A single pair usually leaves a few candidates; more pairs narrow them down.",
+		&saesSearchCommand{},
+	)
+}
+
+func decodeBlocks(values []string) ([][]byte, error) {
+	blocks := make([][]byte, len(values))
+	for i, v := range values {
+		block, err := hex.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding the provided block: %s", v)
+		}
+		blocks[i] = block
+	}
+	return blocks, nil
+}
+
+func (c *saesSearchCommand) Execute(args []string) error {
+	plaintexts, err := decodeBlocks(c.Plaintexts)
+	if err != nil {
+		return err
+	}
+	ciphertexts, err := decodeBlocks(c.Ciphertexts)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	keys, err := saes.Search(plaintexts, ciphertexts)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		fmt.Println(hex.EncodeToString(key))
+	}
+	fmt.Printf("Candidate keys: %d of 65536\n", len(keys))
+	fmt.Printf("Time: %s\n", time.Since(start))
+	return nil
+}
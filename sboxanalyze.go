@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/cryptanalysis/sbox"
+	"strings"
+)
+
+type sBoxAnalyzeCommand struct {
+	SBox    string `short:"s" long:"sbox" description:"S-box to analyze as 256 hex encoded bytes (default: AES S-box)"`
+	Inverse bool   `long:"inverse" description:"Analyze the inverse of the S-box"`
+	DDT     bool   `long:"ddt" description:"Outputs the difference distribution table"`
+	LAT     bool   `long:"lat" description:"Outputs the linear approximation table"`
+}
+
+func init() {
+	parser.AddCommand(
+		"sbox-analyze",
+		"Computes the cryptographic properties of an S-box",
+		"Computes the difference distribution table, linear approximation table, differential uniformity, nonlinearity, algebraic degree and fixed points of an 8-bit S-box",
+		&sBoxAnalyzeCommand{},
+	)
+}
+
+func (c *sBoxAnalyzeCommand) Execute(args []string) error {
+	var table []byte
+	var err error
+	if c.SBox != "" {
+		table, err = hex.DecodeString(c.SBox)
+		if err != nil {
+			return errors.New("Error decoding the provided S-box")
+		}
+	} else {
+		table, _ = aes.GenerateSBox()
+	}
+	if c.Inverse {
+		table, err = aes.InvertSBox(table)
+		if err != nil {
+			return err
+		}
+	}
+	report, err := sbox.Analyze(table)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Bijective:               %t\n", report.Bijective)
+	fmt.Printf("Differential uniformity: %d\n", report.DifferentialUniformity)
+	fmt.Printf("Nonlinearity:            %d\n", report.Nonlinearity)
+	fmt.Printf("Algebraic degree:        %d\n", report.AlgebraicDegree)
+	fmt.Printf("Fixed points:            %d %s\n", len(report.FixedPoints), hex.EncodeToString(report.FixedPoints))
+	if c.DDT {
+		fmt.Println("DDT:")
+		printTable(report.DDT)
+	}
+	if c.LAT {
+		fmt.Println("LAT:")
+		printTable(report.LAT)
+	}
+	return nil
+}
+
+func printTable(table [][]int) {
+	for _, row := range table {
+		values := make([]string, len(row))
+		for i, v := range row {
+			values[i] = fmt.Sprint(v)
+		}
+		fmt.Println(strings.Join(values, " "))
+	}
+}
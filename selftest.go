@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/cavp"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"io"
+	"sort"
+)
+
+type selfTestCommand struct {
+	CAVP    bool   `long:"cavp" description:"Also runs the embedded AESAVS known-answer files, rebuilt from the AESAVS inputs"`
+	CAVPDir string `long:"cavp-dir" description:"Also runs the .rsp files of the directory, such as the extracted NIST CAVP AES archives"`
+	Verbose bool   `short:"v" long:"verbose" description:"Lists every test run"`
+}
+
+func init() {
+	parser.AddCommand(
+		"selftest",
+		"Runs the implementation self tests",
+		"Runs the known-answer tests of AES in every key size and backend and of each mode of operation, and the continuous test of the random generator. --cavp also runs the AESAVS known-answer .rsp files embedded in the binary, which are rebuilt from the AESAVS inputs and are not the NIST files, and --cavp-dir the .rsp files of a directory, such as the official NIST CAVP archives.",
+		&selfTestCommand{},
+	)
+}
+
+// modeSelfTests are the known-answer tests of the modes of operation offered by --mode, using the
+// AES-128 examples of NIST SP 800-38A appendix F
+var modeSelfTests = map[string]func() error{
+	// F.1.1 ECB-AES128.Encrypt
+	"ecb": modeSelfTest("ecb", "", "3ad77bb40d7a3660a89ecaf32466ef97f5d3d58503b9699de785895a96fdbaaf43b1cd7f598ece23881b00e3ed0306887b0c785e27e8ad3f8223207104725dd4"),
+	// F.2.1 CBC-AES128.Encrypt
+	"cbc": modeSelfTest("cbc", "000102030405060708090a0b0c0d0e0f", "7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b273bed6b8e3c1743b7116e69e222295163ff1caa1681fac09120eca307586e1a7"),
+	// F.5.1 CTR-AES128.Encrypt
+	"ctr": modeSelfTest("ctr", "f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff", "874d6191b620e3261bef6864990db6ce9806f66b7970fdff8617187bb9fffdff5ae4df3edbd5d35e5b4f09020db03eab1e031dda2fbe03d1792170a0f3009cee"),
+}
+
+// modeSelfTest returns the test running an SP 800-38A example of the mode through modes.Reader. The
+// examples share the key and the plaintext.
+func modeSelfTest(name, ivHex, ciphertextHex string) func() error {
+	return func() error {
+		key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+		plaintext, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710")
+		iv, _ := hex.DecodeString(ivHex)
+		ciphertext, _ := hex.DecodeString(ciphertextHex)
+		c, err := aes.NewCipher(key)
+		if err != nil {
+			return err
+		}
+		// the modes keep the chaining state, so each direction uses a new one
+		mode, err := cipherModes[name].create(c, iv)
+		if err != nil {
+			return err
+		}
+		encrypted, err := io.ReadAll(modes.NewReader(mode, bytes.NewReader(plaintext), modes.ENCRYPTION))
+		if err != nil {
+			return err
+		}
+		// the reader appends a block of padding
+		if len(encrypted) != len(ciphertext)+mode.BlockSize() || !bytes.Equal(encrypted[:len(ciphertext)], ciphertext) {
+			return fmt.Errorf("Invalid encryption. Expected: 0x%x Got: 0x%x", ciphertext, encrypted)
+		}
+		if mode, err = cipherModes[name].create(c, iv); err != nil {
+			return err
+		}
+		decrypted, err := io.ReadAll(modes.NewReader(mode, bytes.NewReader(encrypted), modes.DECRYPTION))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			return fmt.Errorf("Invalid decryption. Expected: 0x%x Got: 0x%x", plaintext, decrypted)
+		}
+		return nil
+	}
+}
+
+func (c *selfTestCommand) Execute(args []string) error {
+	var results []aes.SelfTestResult
+	results = append(results, aes.SelfTest().Results...)
+	names := make([]string, 0, len(modeSelfTests))
+	for name := range modeSelfTests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		results = append(results, aes.SelfTestResult{Name: "mode " + name, Err: modeSelfTests[name]()})
+	}
+	results = append(results, aes.SelfTestResult{Name: "random generator", Err: readRandom(make([]byte, 4*rngBlockSize))})
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			fmt.Printf("FAILED %s: %s\n", result.Name, result.Err.Error())
+		} else if c.Verbose {
+			fmt.Printf("ok %s\n", result.Name)
+		}
+	}
+	fmt.Printf("Self tests: %d passed, %d failed\n", len(results)-failed, failed)
+	if c.CAVP {
+		if err := c.runCAVP(); err != nil {
+			return err
+		}
+	}
+	if c.CAVPDir != "" {
+		report, err := cavp.RunDir(c.CAVPDir)
+		if err != nil {
+			return err
+		}
+		if err := printCAVPReport(c.CAVPDir, report); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return errors.New("Self test failed")
+	}
+	return nil
+}
+
+func (c *selfTestCommand) runCAVP() error {
+	if c.Verbose {
+		for _, name := range cavp.Files() {
+			fmt.Println(name)
+		}
+	}
+	report, err := cavp.RunEmbedded()
+	if err != nil {
+		return err
+	}
+	return printCAVPReport("CAVP", report)
+}
+
+// printCAVPReport prints the skipped files and the failures of a CAVP run, titled by name
+func printCAVPReport(name string, report *cavp.Report) error {
+	for _, skipped := range report.Skipped {
+		fmt.Printf("Skipped %s\n", skipped)
+	}
+	for _, failure := range report.Failures {
+		fmt.Printf("FAILED %s\n", failure)
+	}
+	fmt.Printf("%s: %d passed, %d failed\n", name, report.Passed, report.Failed)
+	if report.Failed > 0 {
+		return errors.New("CAVP self test failed")
+	}
+	return nil
+}
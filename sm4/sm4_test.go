@@ -0,0 +1,53 @@
+package sm4
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// GB/T 32907-2016 appendix A
+func TestEncryptDecrypt(t *testing.T) {
+	key, _ := hex.DecodeString("0123456789abcdeffedcba9876543210")
+	plaintext, _ := hex.DecodeString("0123456789abcdeffedcba9876543210")
+	expected := "681edf34d206965e86b3e94f536e4246"
+	c, err := NewCipher(key)
+	if err != nil {
+		t.Fatalf("Error creating cipher: %s", err.Error())
+	}
+	res := make([]byte, BlockSize)
+	c.Encrypt(plaintext, res)
+	if hex.EncodeToString(res) != expected {
+		t.Errorf("Invalid encryption result. Expected: 0x%s Got: 0x%x", expected, res)
+	}
+	c.Decrypt(res, res)
+	if !bytes.Equal(res, plaintext) {
+		t.Errorf("Invalid decryption result. Expected: 0x%x Got: 0x%x", plaintext, res)
+	}
+	if _, err := NewCipher(key[:8]); err == nil {
+		t.Errorf("Accepting invalid key length")
+	}
+}
+
+// GB/T 32907-2016 appendix A, example 2: the plaintext encrypted 1,000,000 times
+func TestMillionIterations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping 1,000,000 iterations in short mode")
+	}
+	key, _ := hex.DecodeString("0123456789abcdeffedcba9876543210")
+	res, _ := hex.DecodeString("0123456789abcdeffedcba9876543210")
+	expected := "595298c7c6fd271f0402f804c33d3f66"
+	c, _ := NewCipher(key)
+	for i := 0; i < 1000000; i++ {
+		c.Encrypt(res, res)
+	}
+	if hex.EncodeToString(res) != expected {
+		t.Errorf("Invalid result after 1,000,000 encryptions. Expected: 0x%s Got: 0x%x", expected, res)
+	}
+	for i := 0; i < 1000000; i++ {
+		c.Decrypt(res, res)
+	}
+	if hex.EncodeToString(res) != hex.EncodeToString(key) {
+		t.Errorf("Invalid result after 1,000,000 decryptions. Got: 0x%x", res)
+	}
+}
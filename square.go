@@ -0,0 +1,45 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/cryptanalysis/square"
+	"time"
+)
+
+type squareCommand struct{}
+
+func init() {
+	parser.AddCommand(
+		"square",
+		"Runs the integral (Square) attack against 4-round AES-128",
+		"Runs the integral (Square) attack against a 4-round AES-128 with a random key, recovering the last round key from chosen plaintexts and inverting the key schedule to the cipher key",
+		&squareCommand{},
+	)
+}
+
+func (c *squareCommand) Execute(args []string) error {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	cipher, err := aes.NewCipherWithRounds(key, square.Rounds)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	result, err := square.Attack(cipher)
+	if err != nil {
+		return err
+	}
+	elapsed := time.Since(start)
+	fmt.Printf("Cipher key:           %s\n", hex.EncodeToString(key))
+	fmt.Printf("Recovered round key:  %s\n", hex.EncodeToString(result.RoundKey))
+	fmt.Printf("Recovered cipher key: %s\n", hex.EncodeToString(result.Key))
+	fmt.Printf("Lambda-sets:          %d\n", result.Sets)
+	fmt.Printf("Chosen plaintexts:    %d\n", result.ChosenPlaintexts)
+	fmt.Printf("Time:                 %s\n", elapsed)
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"github.com/emanuelzabka/crypt-aes/ppm"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type visualizeCommand struct {
+	Input  string   `short:"i" long:"input" description:"PNG or PPM image" required:"true"`
+	Output string   `short:"o" long:"output" description:"Output image, PNG or PPM by the extension" default:"visualize.png"`
+	Key    string   `short:"k" long:"key" description:"AES key in hexadecimal, random by default"`
+	Modes  []string `short:"m" long:"mode" description:"Mode of operation (repeatable, default all)" choice:"ecb" choice:"cbc" choice:"ctr"`
+}
+
+func init() {
+	parser.AddCommand(
+		"visualize",
+		"Shows the pattern leakage of the modes of operation on an image",
+		"Encrypts only the pixel data of a PNG or PPM image with each mode of operation and writes the original and the encrypted images side by side. Areas of the same color keep their shapes under ECB (the \"ECB penguin\"), while CBC and CTR turn the whole image into noise. The IV of CBC and CTR is random.",
+		&visualizeCommand{},
+	)
+}
+
+// encryptPixels encrypts the RGB bytes of the image, row by row from the top, with the mode. The bytes
+// of a trailing partial block are left as they are.
+func encryptPixels(img image.Image, mode modes.CipherMode) *image.RGBA {
+	bounds := img.Bounds()
+	result := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(result, result.Bounds(), img, bounds.Min, draw.Src)
+	data := make([]byte, 0, 3*bounds.Dx()*bounds.Dy())
+	for i := 0; i < len(result.Pix); i += 4 {
+		data = append(data, result.Pix[i:i+3]...)
+	}
+	size := mode.BlockSize()
+	for i := 0; i+size <= len(data); i += size {
+		mode.Encrypt(data[i:i+size], data[i:i+size])
+	}
+	for i := 0; i < len(result.Pix); i += 4 {
+		copy(result.Pix[i:i+3], data[3*i/4:])
+		result.Pix[i+3] = 0xff
+	}
+	return result
+}
+
+// sideBySide draws the images from left to right separated by a white gap
+func sideBySide(images []image.Image) *image.RGBA {
+	const gap = 8
+	width, height := 0, 0
+	for _, img := range images {
+		width += img.Bounds().Dx()
+		if img.Bounds().Dy() > height {
+			height = img.Bounds().Dy()
+		}
+	}
+	width += gap * (len(images) - 1)
+	result := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(result, result.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	x := 0
+	for _, img := range images {
+		rect := image.Rect(x, 0, x+img.Bounds().Dx(), img.Bounds().Dy())
+		draw.Draw(result, rect, img, img.Bounds().Min, draw.Src)
+		x += img.Bounds().Dx() + gap
+	}
+	return result
+}
+
+func (c *visualizeCommand) Execute(args []string) error {
+	input, err := os.Open(c.Input)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+	img, _, err := image.Decode(input)
+	if err != nil {
+		return fmt.Errorf("Error decoding %s: %s", c.Input, err.Error())
+	}
+	var key []byte
+	if c.Key != "" {
+		if key, err = hex.DecodeString(c.Key); err != nil {
+			return errors.New("Error decoding the provided key")
+		}
+	} else {
+		key = make([]byte, 16)
+		if err := readRandom(key); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Using key: %s\n", byteToHexString(key))
+	}
+	modeNames := c.Modes
+	if len(modeNames) == 0 {
+		// ECB first, next to the original, then the modes hiding the patterns
+		modeNames = []string{"ecb", "cbc", "ctr"}
+	}
+	images := []image.Image{img}
+	for _, name := range modeNames {
+		cipher, err := aes.NewCipher(key)
+		if err != nil {
+			return err
+		}
+		var iv []byte
+		if cipherModes[name].usesIV {
+			iv = make([]byte, cipher.BlockSize())
+			if err := readRandom(iv); err != nil {
+				return err
+			}
+		}
+		mode, err := cipherModes[name].create(cipher, iv)
+		if err != nil {
+			return err
+		}
+		images = append(images, encryptPixels(img, mode))
+	}
+	fmt.Fprintf(os.Stderr, "Writing original, %s from left to right\n", strings.Join(modeNames, ", "))
+	output, err := os.Create(c.Output)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+	if strings.EqualFold(filepath.Ext(c.Output), ".ppm") {
+		return ppm.Encode(output, sideBySide(images))
+	}
+	return png.Encode(output, sideBySide(images))
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/whitebox"
+	"os"
+	"time"
+)
+
+type whiteboxCommand struct{}
+
+type whiteboxGenerateCommand struct {
+	Key    string `short:"k" long:"key" description:"128-bit cipher key (default: random key)"`
+	Output string `short:"o" long:"output" description:"Output file path for the tables" required:"true"`
+}
+
+type whiteboxAttackCommand struct {
+	Tables string `short:"t" long:"tables" description:"Path of the tables generated by the generate command" required:"true"`
+	Traces int    `short:"n" long:"traces" description:"Number of traced encryptions" default:"200"`
+}
+
+func init() {
+	command, _ := parser.AddCommand(
+		"whitebox",
+		"Generates and attacks white-box AES-128 tables",
+		"Compiles an AES-128 key into Chow et al. white-box tables with internal encodings, and extracts the key back from the tables by differential computation analysis (DCA)",
+		&whiteboxCommand{},
+	)
+	command.AddCommand(
+		"generate",
+		"Compiles a key into white-box tables",
+		"Compiles an AES-128 key into white-box tables and writes them to a file",
+		&whiteboxGenerateCommand{},
+	)
+	command.AddCommand(
+		"attack",
+		"Extracts the key from white-box tables",
+		"Extracts the key from white-box tables by differential computation analysis of traced encryptions",
+		&whiteboxAttackCommand{},
+	)
+}
+
+func (c *whiteboxGenerateCommand) Execute(args []string) error {
+	var key []byte
+	var err error
+	if c.Key != "" {
+		key, err = hex.DecodeString(c.Key)
+		if err != nil {
+			return fmt.Errorf("Error decoding the provided key: %s", c.Key)
+		}
+	} else {
+		key = make([]byte, 16)
+		if _, err = rand.Read(key); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Using key: %s\n", hex.EncodeToString(key))
+	}
+	tables, err := whitebox.Generate(key)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(c.Output, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	if _, err = tables.WriteTo(writer); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+func (c *whiteboxAttackCommand) Execute(args []string) error {
+	file, err := os.Open(c.Tables)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	tables, err := whitebox.ReadTables(bufio.NewReader(file))
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	result, err := whitebox.DCA(tables, c.Traces)
+	if err != nil {
+		return err
+	}
+	elapsed := time.Since(start)
+	// the tables must behave as the cipher with the extracted key
+	cipher, err := aes.NewCipher(result.Key)
+	if err != nil {
+		return err
+	}
+	block := make([]byte, 16)
+	expected := make([]byte, 16)
+	got := make([]byte, 16)
+	if _, err = rand.Read(block); err != nil {
+		return err
+	}
+	cipher.Encrypt(block, expected)
+	tables.Encrypt(block, got)
+	if !bytes.Equal(expected, got) {
+		return errors.New("The extracted key does not match the tables")
+	}
+	fmt.Printf("Extracted key: %s\n", hex.EncodeToString(result.Key))
+	fmt.Printf("Traces:        %d\n", c.Traces)
+	fmt.Printf("Time:          %s\n", elapsed)
+	return nil
+}
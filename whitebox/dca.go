@@ -0,0 +1,105 @@
+package whitebox
+
+import (
+	"crypto/rand"
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"math"
+)
+
+// columnTraceLength is the number of values traced for each column of a round: the outputs of four Ty
+// tables followed by the outputs of 24 XOR tables
+const columnTraceLength = 4*4 + 8*3
+
+// traceLength is the number of traced values of the first round
+const traceLength = 4 * columnTraceLength
+
+// DCAResult holds the outcome of the differential computation analysis
+type DCAResult struct {
+	Key []byte
+	// Scores are the highest differences of means found for each key byte
+	Scores []float64
+}
+
+// DCA runs a differential computation analysis on the tables: the encryption of n random plaintexts is
+// traced and the bits of the values read from the tables are split by a bit of the first round S-box
+// output predicted for each key byte guess. Like power traces, the encoded values still correlate with
+// the values they encode, so the right guess gives the highest difference of means. The analysis only
+// looks at the first round Ty tables of each byte, as an attacker locates them by inspecting the traces.
+func DCA(t *Tables, n int) (*DCAResult, error) {
+	if n < 1 {
+		return nil, errors.New("Invalid number of traces. At least 1 is needed")
+	}
+	plaintexts := make([]byte, 16*n)
+	if _, err := rand.Read(plaintexts); err != nil {
+		return nil, err
+	}
+	traces := make([][]byte, n)
+	var trace []byte
+	t.SetTracer(func(value byte) {
+		if len(trace) < traceLength {
+			trace = append(trace, value)
+		}
+	})
+	defer t.SetTracer(nil)
+	dest := make([]byte, 16)
+	for i := range traces {
+		trace = make([]byte, 0, traceLength)
+		t.Encrypt(plaintexts[16*i:16*i+16], dest)
+		traces[i] = trace
+	}
+	result := new(DCAResult)
+	result.Key = make([]byte, 16)
+	result.Scores = make([]float64, 16)
+	for i := 0; i < 16; i++ {
+		// the Ty table of position i of the shifted state reads the byte shifted(i) of the plaintext
+		source := shifted(i)
+		offset := columnTraceLength*(i/4) + 4*(i%4)
+		for k := 0; k < 256; k++ {
+			for bit := uint(0); bit < 8; bit++ {
+				var sums [2][32]float64
+				var counts [2]float64
+				for j := range traces {
+					selector := aes.SBox(plaintexts[16*j+source]^byte(k)) >> bit & 1
+					counts[selector]++
+					for p := 0; p < 32; p++ {
+						sums[selector][p] += float64(traces[j][offset+p/8] >> uint(p%8) & 1)
+					}
+				}
+				if counts[0] == 0 || counts[1] == 0 {
+					continue
+				}
+				for p := 0; p < 32; p++ {
+					score := math.Abs(sums[1][p]/counts[1] - sums[0][p]/counts[0])
+					if score > result.Scores[source] {
+						result.Scores[source] = score
+						result.Key[source] = byte(k)
+					}
+				}
+			}
+		}
+	}
+	if !verify(t, result.Key) {
+		return result, errors.New("The recovered key does not match the tables")
+	}
+	return result, nil
+}
+
+// verify compares the tables against a cipher created with the key
+func verify(t *Tables, key []byte) bool {
+	cipher, err := aes.NewCipher(key)
+	if err != nil {
+		return false
+	}
+	block := make([]byte, 16)
+	expected := make([]byte, 16)
+	got := make([]byte, 16)
+	cipher.Encrypt(block, expected)
+	t.Encrypt(block, got)
+	for i := range expected {
+		if expected[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
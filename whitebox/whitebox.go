@@ -0,0 +1,249 @@
+// Package whitebox compiles an AES-128 key into white-box lookup tables following Chow et al.
+//
+// The AddRoundKey and SubBytes of each round are merged into T-boxes, which are combined with the
+// columns of the MixColumns matrix into Ty tables with 32-bit outputs. The four Ty outputs of each column
+// are added by XOR tables working on nibbles. Every table output is protected by a random nibble
+// bijection (internal encoding) that is undone inside the table that reads it. The last round T-boxes
+// also add the last round key and output the ciphertext. The plaintext and the ciphertext are not
+// encoded and there are no mixing bijections, which keeps the construction simple to follow and, as
+// the DCA demo shows, simple to break.
+package whitebox
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/gf256"
+	"io"
+	mrand "math/rand"
+)
+
+// Tables holds the lookup tables of a white-box AES-128
+type Tables struct {
+	// TyBoxes[r][i] are the tables of round r+1 for the byte i of the shifted state, combining the
+	// T-box and the column of MixColumns. The output nibbles are encoded.
+	TyBoxes [9][16][256][4]byte
+	// XorTables[r][c][n] add the nibble n of the Ty outputs of the column c: tables 0 and 1 add the
+	// pairs of Ty outputs and table 2 adds their results
+	XorTables [9][4][8][3][256]byte
+	// TBoxes are the last round tables, adding the last round key
+	TBoxes [16][256]byte
+	tracer func(value byte)
+}
+
+// encoding is a bijection on nibbles and its inverse
+type encoding struct {
+	encode [16]byte
+	decode [16]byte
+}
+
+var identity = newIdentity()
+
+func newIdentity() *encoding {
+	e := new(encoding)
+	for i := range e.encode {
+		e.encode[i] = byte(i)
+		e.decode[i] = byte(i)
+	}
+	return e
+}
+
+func newEncoding(random *mrand.Rand) *encoding {
+	e := new(encoding)
+	for i, v := range random.Perm(16) {
+		e.encode[i] = byte(v)
+		e.decode[v] = byte(i)
+	}
+	return e
+}
+
+// decodeByte decodes a byte whose nibbles are encoded by hi and lo
+func decodeByte(x byte, hi, lo *encoding) byte {
+	return hi.decode[x>>4]<<4 | lo.decode[x&0x0f]
+}
+
+// mixColumn[row][j] is the MixColumns coefficient applied to the byte of row j for the output row
+var mixColumn = [4][4]byte{
+	{0x02, 0x03, 0x01, 0x01},
+	{0x01, 0x02, 0x03, 0x01},
+	{0x01, 0x01, 0x02, 0x03},
+	{0x03, 0x01, 0x01, 0x02},
+}
+
+// shifted returns the position of the state that moves to position i by ShiftRows
+func shifted(i int) int {
+	row, column := i&3, i>>2
+	return row + 4*((column+row)&3)
+}
+
+// Generate compiles the AES-128 key into white-box tables
+func Generate(key []byte) (*Tables, error) {
+	if len(key) != 16 {
+		return nil, errors.New("Invalid key length. The white-box construction supports 128-bit keys (16 bytes)")
+	}
+	cipher, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	var seed [8]byte
+	if _, err := rand.Read(seed[:]); err != nil {
+		return nil, err
+	}
+	random := mrand.New(mrand.NewSource(int64(binary.LittleEndian.Uint64(seed[:]))))
+	roundKeys := cipher.RoundKeys()
+	t := new(Tables)
+	// state[p][0] and state[p][1] are the encodings of the high and low nibbles of the state byte p
+	var state [16][2]*encoding
+	for p := range state {
+		state[p] = [2]*encoding{identity, identity}
+	}
+	for r := 0; r < 9; r++ {
+		var tyOut [16][8]*encoding
+		for i := 0; i < 16; i++ {
+			source := shifted(i)
+			k := roundKeys[r][source]
+			for n := range tyOut[i] {
+				tyOut[i][n] = newEncoding(random)
+			}
+			for x := 0; x < 256; x++ {
+				s := aes.SBox(decodeByte(byte(x), state[source][0], state[source][1]) ^ k)
+				for row := 0; row < 4; row++ {
+					v := gf256.Mul(mixColumn[row][i&3], s)
+					hi := tyOut[i][2*row].encode[v>>4]
+					lo := tyOut[i][2*row+1].encode[v&0x0f]
+					t.TyBoxes[r][i][x][row] = hi<<4 | lo
+				}
+			}
+		}
+		for c := 0; c < 4; c++ {
+			for n := 0; n < 8; n++ {
+				first := newEncoding(random)
+				second := newEncoding(random)
+				out := newEncoding(random)
+				xorTable(&t.XorTables[r][c][n][0], tyOut[4*c][n], tyOut[4*c+1][n], first)
+				xorTable(&t.XorTables[r][c][n][1], tyOut[4*c+2][n], tyOut[4*c+3][n], second)
+				xorTable(&t.XorTables[r][c][n][2], first, second, out)
+				state[4*c+n/2][n%2] = out
+			}
+		}
+	}
+	for i := 0; i < 16; i++ {
+		source := shifted(i)
+		for x := 0; x < 256; x++ {
+			s := aes.SBox(decodeByte(byte(x), state[source][0], state[source][1]) ^ roundKeys[9][source])
+			t.TBoxes[i][x] = s ^ roundKeys[10][i]
+		}
+	}
+	return t, nil
+}
+
+// xorTable fills the table adding the nibbles encoded by a and b into a nibble encoded by out
+func xorTable(table *[256]byte, a, b, out *encoding) {
+	for x := 0; x < 256; x++ {
+		table[x] = out.encode[a.decode[x>>4]^b.decode[x&0x0f]]
+	}
+}
+
+// Encrypt encrypts a block of data using only the tables
+// block and dest must be a slice with length 16
+// The resulting encrypted block is stored in dest
+func (t *Tables) Encrypt(block, dest []byte) {
+	var state, next [16]byte
+	var words [4][4]byte
+	copy(state[:], block)
+	for r := 0; r < 9; r++ {
+		for c := 0; c < 4; c++ {
+			for j := 0; j < 4; j++ {
+				words[j] = t.TyBoxes[r][4*c+j][state[shifted(4*c+j)]]
+				t.trace(words[j][:]...)
+			}
+			for n := 0; n < 8; n++ {
+				var nibbles [4]byte
+				for j := range nibbles {
+					nibbles[j] = words[j][n/2] >> (4 * uint(1-n%2)) & 0x0f
+				}
+				tables := &t.XorTables[r][c][n]
+				first := tables[0][nibbles[0]<<4|nibbles[1]]
+				second := tables[1][nibbles[2]<<4|nibbles[3]]
+				out := tables[2][first<<4|second]
+				t.trace(first, second, out)
+				if n%2 == 0 {
+					next[4*c+n/2] = out << 4
+				} else {
+					next[4*c+n/2] |= out
+				}
+			}
+		}
+		state = next
+	}
+	for i := 0; i < 16; i++ {
+		dest[i] = t.TBoxes[i][state[shifted(i)]]
+	}
+}
+
+// BlockSize returns the block size used
+func (t *Tables) BlockSize() int {
+	return 16
+}
+
+// SetTracer sets a function called with every value read from the tables, as seen by a debugger or a
+// memory tracer. A nil tracer disables tracing.
+func (t *Tables) SetTracer(tracer func(value byte)) {
+	t.tracer = tracer
+}
+
+func (t *Tables) trace(values ...byte) {
+	if t.tracer != nil {
+		for _, v := range values {
+			t.tracer(v)
+		}
+	}
+}
+
+// WriteTo serializes the tables
+func (t *Tables) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	for _, table := range t.sections() {
+		written, err := w.Write(table)
+		n += int64(written)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// ReadTables reads tables serialized by WriteTo
+func ReadTables(r io.Reader) (*Tables, error) {
+	t := new(Tables)
+	for _, table := range t.sections() {
+		if _, err := io.ReadFull(r, table); err != nil {
+			return nil, errors.New("Invalid white-box tables")
+		}
+	}
+	return t, nil
+}
+
+// sections returns the tables as byte slices, in the serialization order
+func (t *Tables) sections() [][]byte {
+	var sections [][]byte
+	for r := range t.TyBoxes {
+		for i := range t.TyBoxes[r] {
+			for x := range t.TyBoxes[r][i] {
+				sections = append(sections, t.TyBoxes[r][i][x][:])
+			}
+		}
+		for c := range t.XorTables[r] {
+			for n := range t.XorTables[r][c] {
+				for k := range t.XorTables[r][c][n] {
+					sections = append(sections, t.XorTables[r][c][n][k][:])
+				}
+			}
+		}
+	}
+	for i := range t.TBoxes {
+		sections = append(sections, t.TBoxes[i][:])
+	}
+	return sections
+}
@@ -0,0 +1,90 @@
+package whitebox
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"testing"
+)
+
+func TestEncrypt(t *testing.T) {
+	for n := 0; n < 3; n++ {
+		key := make([]byte, 16)
+		rand.Read(key)
+		tables, err := Generate(key)
+		if err != nil {
+			t.Fatalf("Error generating tables: %s", err.Error())
+		}
+		cipher, _ := aes.NewCipher(key)
+		for i := 0; i < 20; i++ {
+			block := make([]byte, 16)
+			rand.Read(block)
+			expected := make([]byte, 16)
+			res := make([]byte, 16)
+			cipher.Encrypt(block, expected)
+			tables.Encrypt(block, res)
+			if !bytes.Equal(res, expected) {
+				t.Errorf("Invalid white-box encryption for key 0x%s. Expected: 0x%s Got: 0x%s",
+					hex.EncodeToString(key), hex.EncodeToString(expected), hex.EncodeToString(res))
+			}
+		}
+	}
+}
+
+func TestGenerateInvalidKey(t *testing.T) {
+	if _, err := Generate(make([]byte, 24)); err == nil {
+		t.Errorf("Accepting 192-bit key")
+	}
+}
+
+func TestSerialization(t *testing.T) {
+	key := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f}
+	block := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	expected := []byte{0x69, 0xc4, 0xe0, 0xd8, 0x6a, 0x7b, 0x04, 0x30, 0xd8, 0xcd, 0xb7, 0x80, 0x70, 0xb4, 0xc5, 0x5a}
+	tables, _ := Generate(key)
+	var buffer bytes.Buffer
+	if _, err := tables.WriteTo(&buffer); err != nil {
+		t.Fatalf("Error writing tables: %s", err.Error())
+	}
+	if bytes.Contains(buffer.Bytes(), key) {
+		t.Errorf("Serialized tables contain the key")
+	}
+	size := buffer.Len()
+	loaded, err := ReadTables(&buffer)
+	if err != nil {
+		t.Fatalf("Error reading tables: %s", err.Error())
+	}
+	res := make([]byte, 16)
+	loaded.Encrypt(block, res)
+	if !bytes.Equal(res, expected) {
+		t.Errorf("Invalid encryption with loaded tables. Expected: 0x%s Got: 0x%s",
+			hex.EncodeToString(expected), hex.EncodeToString(res))
+	}
+	if _, err := ReadTables(bytes.NewReader(make([]byte, size-1))); err == nil {
+		t.Errorf("Accepting truncated tables")
+	}
+}
+
+func TestDCA(t *testing.T) {
+	key := make([]byte, 16)
+	rand.Read(key)
+	tables, _ := Generate(key)
+	result, err := DCA(tables, 200)
+	if err != nil {
+		t.Fatalf("DCA failed for key 0x%s: %s", hex.EncodeToString(key), err.Error())
+	}
+	if !bytes.Equal(result.Key, key) {
+		t.Errorf("Invalid recovered key. Expected: 0x%s Got: 0x%s",
+			hex.EncodeToString(key), hex.EncodeToString(result.Key))
+	}
+}
+
+func TestDCAInvalidCount(t *testing.T) {
+	tables, _ := Generate(make([]byte, 16))
+	for _, n := range []int{0, -1} {
+		if _, err := DCA(tables, n); err == nil {
+			t.Errorf("Accepting %d traces", n)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package wycheproof
+
+import (
+	"bytes"
+	"errors"
+	"github.com/emanuelzabka/crypt-aes/aes"
+	"github.com/emanuelzabka/crypt-aes/modes"
+	"github.com/emanuelzabka/crypt-aes/modes/cbc"
+	"github.com/emanuelzabka/crypt-aes/modes/ccm"
+	"github.com/emanuelzabka/crypt-aes/modes/cmac"
+	"github.com/emanuelzabka/crypt-aes/modes/eax"
+	"github.com/emanuelzabka/crypt-aes/modes/gcm"
+	"github.com/emanuelzabka/crypt-aes/modes/gcmsiv"
+	"github.com/emanuelzabka/crypt-aes/modes/kw"
+	"github.com/emanuelzabka/crypt-aes/modes/siv"
+	"io"
+)
+
+func init() {
+	Register("AES-GCM", aeadRunner(newGCM))
+	Register("AES-CCM", aeadRunner(newCCM))
+	Register("AES-EAX", aeadRunner(newEAX))
+	Register("AES-GCM-SIV", aeadRunner(newGCMSIV))
+	Register("AES-SIV-CMAC", sivRunner)
+	Register("AES-CBC-PKCS5", cbcRunner)
+	Register("KW", keyWrapRunner(kw.Wrap, kw.Unwrap))
+	Register("KWP", keyWrapRunner(kw.WrapPad, kw.UnwrapPad))
+	Register("AES-CMAC", cmacRunner)
+}
+
+// aead is the interface of the modes sealing and opening messages with a nonce
+type aead interface {
+	Seal(nonce, plaintext, additionalData []byte) ([]byte, error)
+	Open(nonce, ciphertext, additionalData []byte) ([]byte, error)
+}
+
+func newGCM(group *TestGroup, key []byte) (aead, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := gcm.NewMode(c, group.TagSize/8)
+	if err != nil {
+		return nil, err
+	}
+	return mode, nil
+}
+
+func newCCM(group *TestGroup, key []byte) (aead, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := ccm.NewMode(c, group.TagSize/8)
+	if err != nil {
+		return nil, err
+	}
+	return mode, nil
+}
+
+func newEAX(group *TestGroup, key []byte) (aead, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := eax.NewMode(c, group.TagSize/8)
+	if err != nil {
+		return nil, err
+	}
+	return mode, nil
+}
+
+func newGCMSIV(group *TestGroup, key []byte) (aead, error) {
+	if group.TagSize != 128 {
+		return nil, errors.New("Invalid tag size. GCM-SIV tags have 128 bits")
+	}
+	mode, err := gcmsiv.NewMode(func(key []byte) (modes.Cipher, error) { return aes.NewCipher(key) }, key)
+	if err != nil {
+		return nil, err
+	}
+	return mode, nil
+}
+
+// checkOutput returns an error if the output of an operation differs from the expected one
+func checkOutput(operation string, output, expected []byte) error {
+	if !bytes.Equal(output, expected) {
+		return errors.New("Invalid " + operation + " result")
+	}
+	return nil
+}
+
+// aeadRunner returns the runner of an authenticated mode created by newAEAD: the ciphertext followed by
+// the tag must open to the message, and the message must seal to them again
+func aeadRunner(newAEAD func(group *TestGroup, key []byte) (aead, error)) Runner {
+	return func(group *TestGroup, test *Test) error {
+		mode, err := newAEAD(group, test.Key)
+		if err != nil {
+			return err
+		}
+		sealed := append(append([]byte{}, test.Ct...), test.Tag...)
+		msg, err := mode.Open(test.Iv, sealed, test.Aad)
+		if err != nil {
+			return err
+		}
+		if err := checkOutput("decryption", msg, test.Msg); err != nil {
+			return err
+		}
+		resealed, err := mode.Seal(test.Iv, test.Msg, test.Aad)
+		if err != nil {
+			return err
+		}
+		return checkOutput("encryption", resealed, sealed)
+	}
+}
+
+// sivRunner runs a deterministic AES-SIV-CMAC test, whose key is the S2V key followed by the counter
+// mode key and whose ciphertext starts with the synthetic IV
+func sivRunner(group *TestGroup, test *Test) error {
+	macCipher, err := aes.NewCipher(test.Key[:len(test.Key)/2])
+	if err != nil {
+		return err
+	}
+	c, err := aes.NewCipher(test.Key[len(test.Key)/2:])
+	if err != nil {
+		return err
+	}
+	mode, err := siv.NewMode(macCipher, c)
+	if err != nil {
+		return err
+	}
+	msg, err := mode.Open(test.Ct, test.Aad)
+	if err != nil {
+		return err
+	}
+	if err := checkOutput("decryption", msg, test.Msg); err != nil {
+		return err
+	}
+	sealed, err := mode.Seal(test.Msg, test.Aad)
+	if err != nil {
+		return err
+	}
+	return checkOutput("encryption", sealed, test.Ct)
+}
+
+// cbcProcess encrypts or decrypts the data with CBC through modes.Reader, which adds or checks the
+// PKCS#7 padding
+func cbcProcess(key, iv, data []byte, op int) ([]byte, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := cbc.NewMode(c, iv)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(modes.NewReader(mode, bytes.NewReader(data), op))
+}
+
+// cbcRunner runs an AES-CBC-PKCS5 test: the ciphertext must decrypt to the message with a valid padding, and the message must encrypt to it again
+func cbcRunner(group *TestGroup, test *Test) error {
+	msg, err := cbcProcess(test.Key, test.Iv, test.Ct, modes.DECRYPTION)
+	if err != nil {
+		return err
+	}
+	if err := checkOutput("decryption", msg, test.Msg); err != nil {
+		return err
+	}
+	ct, err := cbcProcess(test.Key, test.Iv, test.Msg, modes.ENCRYPTION)
+	if err != nil {
+		return err
+	}
+	return checkOutput("encryption", ct, test.Ct)
+}
+
+// keyWrapRunner returns the runner of a key wrap: the ciphertext must unwrap to the message and the
+// message must wrap to it again
+func keyWrapRunner(wrap, unwrap func(cipher modes.Cipher, data []byte) ([]byte, error)) Runner {
+	return func(group *TestGroup, test *Test) error {
+		c, err := aes.NewCipher(test.Key)
+		if err != nil {
+			return err
+		}
+		msg, err := unwrap(c, test.Ct)
+		if err != nil {
+			return err
+		}
+		if err := checkOutput("unwrapping", msg, test.Msg); err != nil {
+			return err
+		}
+		ct, err := wrap(c, test.Msg)
+		if err != nil {
+			return err
+		}
+		return checkOutput("wrapping", ct, test.Ct)
+	}
+}
+
+// cmacRunner runs an AES-CMAC test: the tag, of the group tag size, must match the message
+func cmacRunner(group *TestGroup, test *Test) error {
+	c, err := aes.NewCipher(test.Key)
+	if err != nil {
+		return err
+	}
+	mac, err := cmac.New(c)
+	if err != nil {
+		return err
+	}
+	if group.TagSize <= 0 || group.TagSize%8 != 0 || len(test.Tag) != group.TagSize/8 {
+		return errors.New("Invalid tag size")
+	}
+	return mac.Verify(test.Msg, test.Tag)
+}
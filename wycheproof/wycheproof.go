@@ -0,0 +1,148 @@
+// Package wycheproof loads the Project Wycheproof JSON test vector files and runs them, enforcing the
+// expected result of each test: a "valid" test must succeed, an "invalid" test must be rejected and an
+// "acceptable" test may do either.
+//
+// The tests are run by the Runner registered for the algorithm of the file. Runners for AES-GCM,
+// AES-CCM, AES-EAX, AES-GCM-SIV, AES-SIV-CMAC, AES-CBC-PKCS5, KW, KWP and AES-CMAC, built on the modes
+// packages, are registered in runners.go; files of other algorithms are reported as skipped.
+package wycheproof
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// HexBytes is a byte slice encoded as a hexadecimal JSON string
+type HexBytes []byte
+
+// UnmarshalJSON decodes the hexadecimal string
+func (h *HexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*h = b
+	return nil
+}
+
+// File is a Wycheproof test vector file
+type File struct {
+	Algorithm        string      `json:"algorithm"`
+	GeneratorVersion string      `json:"generatorVersion"`
+	NumberOfTests    int         `json:"numberOfTests"`
+	Header           []string    `json:"header"`
+	Schema           string      `json:"schema"`
+	TestGroups       []TestGroup `json:"testGroups"`
+}
+
+// TestGroup is a group of tests sharing the sizes in bits of the key, IV and tag
+type TestGroup struct {
+	Type    string `json:"type"`
+	KeySize int    `json:"keySize"`
+	IvSize  int    `json:"ivSize"`
+	TagSize int    `json:"tagSize"`
+	Tests   []Test `json:"tests"`
+}
+
+// Test is a test vector. Result is "valid", "invalid" or "acceptable".
+type Test struct {
+	TcID    int      `json:"tcId"`
+	Comment string   `json:"comment"`
+	Key     HexBytes `json:"key"`
+	Iv      HexBytes `json:"iv"`
+	Aad     HexBytes `json:"aad"`
+	Msg     HexBytes `json:"msg"`
+	Ct      HexBytes `json:"ct"`
+	Tag     HexBytes `json:"tag"`
+	Result  string   `json:"result"`
+	Flags   []string `json:"flags"`
+}
+
+// Runner runs a test, returning an error if the implementation rejects it (for example an invalid tag
+// or padding) or if its output differs from the expected one
+type Runner func(group *TestGroup, test *Test) error
+
+var runners = make(map[string]Runner)
+
+// Register sets the runner of the algorithm, as named by the algorithm field of the files
+func Register(algorithm string, runner Runner) {
+	runners[algorithm] = runner
+}
+
+// Algorithms returns the algorithms with a registered runner
+func Algorithms() []string {
+	names := make([]string, 0, len(runners))
+	for name := range runners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load decodes a test vector file
+func Load(r io.Reader) (*File, error) {
+	f := new(File)
+	if err := json.NewDecoder(r).Decode(f); err != nil {
+		return nil, err
+	}
+	for _, group := range f.TestGroups {
+		for _, test := range group.Tests {
+			if test.Result != "valid" && test.Result != "invalid" && test.Result != "acceptable" {
+				return nil, fmt.Errorf("tcId %d: Invalid result %q", test.TcID, test.Result)
+			}
+		}
+	}
+	return f, nil
+}
+
+// Check enforces the expected result of the test given the error returned by the runner
+func Check(test *Test, err error) error {
+	switch test.Result {
+	case "valid":
+		if err != nil {
+			return fmt.Errorf("tcId %d (%s): valid test rejected: %s", test.TcID, test.Comment, err.Error())
+		}
+	case "invalid":
+		if err == nil {
+			return fmt.Errorf("tcId %d (%s): invalid test accepted, flags %s", test.TcID, test.Comment, strings.Join(test.Flags, ","))
+		}
+	}
+	return nil
+}
+
+// Report is the result of running a file
+type Report struct {
+	Passed   int
+	Failures []error
+	Skipped  string
+}
+
+// Run runs every test of the file with the runner of its algorithm
+func Run(f *File) *Report {
+	report := new(Report)
+	runner, ok := runners[f.Algorithm]
+	if !ok {
+		report.Skipped = fmt.Sprintf("Algorithm %s is not implemented", f.Algorithm)
+		return report
+	}
+	for i := range f.TestGroups {
+		group := &f.TestGroups[i]
+		for j := range group.Tests {
+			test := &group.Tests[j]
+			if err := Check(test, runner(group, test)); err != nil {
+				report.Failures = append(report.Failures, err)
+			} else {
+				report.Passed++
+			}
+		}
+	}
+	return report
+}
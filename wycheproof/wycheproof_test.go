@@ -0,0 +1,102 @@
+package wycheproof
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func loadFixture(t *testing.T, name string) *File {
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Error opening fixture: %s", err.Error())
+	}
+	defer f.Close()
+	file, err := Load(f)
+	if err != nil {
+		t.Fatalf("Error loading fixture %s: %s", name, err.Error())
+	}
+	return file
+}
+
+func TestLoad(t *testing.T) {
+	file := loadFixture(t, "aes_gcm_test.json")
+	if file.Algorithm != "AES-GCM" || len(file.TestGroups) != 42 || file.NumberOfTests != 256 {
+		t.Fatalf("Invalid loaded file")
+	}
+	if test := file.TestGroups[0].Tests[0]; len(test.Key) != 16 || len(test.Iv) != 12 || len(test.Aad) != 0 {
+		t.Errorf("Invalid decoded test")
+	}
+	if _, err := Load(strings.NewReader(`{"testGroups": [{"tests": [{"tcId": 1, "result": "unknown"}]}]}`)); err == nil {
+		t.Errorf("Accepting unknown result")
+	}
+	if _, err := Load(strings.NewReader(`{"testGroups": [{"tests": [{"tcId": 1, "key": "0g", "result": "valid"}]}]}`)); err == nil {
+		t.Errorf("Accepting invalid hexadecimal")
+	}
+}
+
+func TestRun(t *testing.T) {
+	file := loadFixture(t, "aes_gcm_test.json")
+	file.Algorithm = "AES-FOO"
+	if report := Run(file); report.Skipped == "" {
+		t.Errorf("Running algorithm without runner")
+	}
+	// a runner accepting everything fails the invalid tests
+	Register("AES-FOO", func(group *TestGroup, test *Test) error { return nil })
+	defer delete(runners, "AES-FOO")
+	if report := Run(file); len(report.Failures) != 87 {
+		t.Errorf("Invalid tests accepted. Failures: %d", len(report.Failures))
+	}
+}
+
+// TestFiles runs every file of testdata with the runner of its algorithm, which must pass all of them
+func TestFiles(t *testing.T) {
+	names, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	covered := make(map[string]bool)
+	for _, name := range names {
+		file := loadFixture(t, filepath.Base(name))
+		covered[file.Algorithm] = true
+		report := Run(file)
+		if report.Skipped != "" {
+			t.Errorf("%s skipped: %s", name, report.Skipped)
+			continue
+		}
+		for _, failure := range report.Failures {
+			t.Errorf("%s: %s", name, failure.Error())
+		}
+		if report.Passed != file.NumberOfTests {
+			t.Errorf("Invalid number of tests in %s. Expected: %d Got: %d", name, file.NumberOfTests, report.Passed)
+		}
+	}
+	for _, algorithm := range Algorithms() {
+		if !covered[algorithm] {
+			t.Errorf("No test vector file for %s", algorithm)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	rejected := errors.New("rejected")
+	for _, c := range []struct {
+		result string
+		err    error
+		ok     bool
+	}{
+		{"valid", nil, true},
+		{"valid", rejected, false},
+		{"invalid", nil, false},
+		{"invalid", rejected, true},
+		{"acceptable", nil, true},
+		{"acceptable", rejected, true},
+	} {
+		err := Check(&Test{Result: c.result}, c.err)
+		if (err == nil) != c.ok {
+			t.Errorf("Invalid check of %s test with error %v", c.result, c.err)
+		}
+	}
+}